@@ -18,7 +18,15 @@ import (
 	"qd-sc/internal/api/middleware"
 	"qd-sc/internal/client"
 	"qd-sc/internal/config"
+	"qd-sc/internal/filestore"
+	"qd-sc/internal/intent"
+	"qd-sc/internal/jobcache"
+	"qd-sc/internal/match"
+	"qd-sc/internal/redact"
+	"qd-sc/internal/resume"
 	"qd-sc/internal/service"
+	"qd-sc/internal/session"
+	"qd-sc/internal/tool"
 
 	"github.com/gin-gonic/gin"
 )
@@ -54,7 +62,24 @@ func main() {
 	ocrClient := client.NewOCRClient(cfg)
 
 	locationService := service.NewLocationService(cfg, amapClient)
-	jobService := service.NewJobService(cfg, jobClient)
+	matchScorer := match.NewScorer(cfg, client.NewEmbeddingClient(&cfg.Embedding))
+	// 初始化岗位查询缓存
+	jobCache, err := jobcache.New(&cfg.JobCache)
+	if err != nil {
+		log.Fatalf("初始化岗位缓存失败: %v", err)
+	}
+	if jobCache != nil {
+		defer jobCache.Close()
+	}
+	jobService := service.NewJobService(cfg, jobClient, matchScorer, jobCache, amapClient)
+
+	// 初始化上传文件存储
+	fileStore, err := filestore.New(&cfg.Files)
+	if err != nil {
+		log.Fatalf("初始化文件存储失败: %v", err)
+	}
+	defer fileStore.Close()
+	fileParser := service.NewFileParser(cfg, ocrClient, fileStore)
 
 	// 初始化政策服务
 	policyService, err := service.NewPolicyService(cfg)
@@ -63,10 +88,43 @@ func main() {
 	}
 	defer policyService.Close()
 
-	chatService := service.NewChatService(cfg, llmClient, ocrClient, locationService, jobService, policyService)
+	// 注册工具（城市特有工具可在此追加注册）
+	toolRegistry := tool.NewRegistry()
+	if err := service.RegisterBuiltinTools(toolRegistry, service.BuiltinToolDeps{
+		Config:          cfg,
+		LocationService: locationService,
+		JobService:      jobService,
+		PolicyService:   policyService,
+		FileParser:      fileParser,
+		Redactor:        redact.New(&cfg.Redaction),
+	}); err != nil {
+		log.Fatalf("注册工具失败: %v", err)
+	}
+
+	// 初始化会话存储
+	sessionStore, err := session.NewStore(&cfg.Session)
+	if err != nil {
+		log.Fatalf("初始化会话存储失败: %v", err)
+	}
+	defer sessionStore.Close()
+
+	// 初始化意图分类器（规则层 + 可选LLM层）
+	intentClassifier, err := intent.NewClassifier(cfg, llmClient)
+	if err != nil {
+		log.Fatalf("初始化意图分类器失败: %v", err)
+	}
+	defer intentClassifier.Close()
+
+	// 初始化简历画像解析器（规则提取 + LLM结构化输出补全）
+	resumeParser := resume.NewParser(cfg, llmClient)
+
+	chatService := service.NewChatService(cfg, llmClient, fileParser, locationService, jobService, policyService, toolRegistry, sessionStore, intentClassifier, resumeParser)
 
 	chatHandler := handler.NewChatHandler(chatService)
 	policyHandler := handler.NewPolicyHandler(policyService)
+	conversationHandler := handler.NewConversationHandler(sessionStore)
+	jobHandler := handler.NewJobHandler(jobService, locationService)
+	fileHandler := handler.NewFileHandler(fileStore)
 	healthHandler := handler.NewHealthHandler()
 	metricsHandler := handler.NewMetricsHandler()
 
@@ -96,6 +154,15 @@ func main() {
 			"version": "1.0.0",
 			"endpoints": []string{
 				"POST /v1/chat/completions",
+				"POST /v1/files",
+				"GET /v1/files/:id",
+				"DELETE /v1/files/:id",
+				"GET /api/conversations (管理接口)",
+				"GET /api/conversations/:id",
+				"DELETE /api/conversations/:id",
+				"GET /api/jobs/search",
+				"GET /api/jobs/:id",
+				"DELETE /api/jobs/cache (管理接口)",
 				"GET /health",
 				"GET /metrics (性能指标)",
 				"GET /debug/pprof/* (性能分析)",
@@ -115,6 +182,9 @@ func main() {
 	v1 := router.Group("/v1")
 	{
 		v1.POST("/chat/completions", chatHandler.ChatCompletions)
+		v1.POST("/files", fileHandler.Upload)
+		v1.GET("/files/:id", fileHandler.GetFile)
+		v1.DELETE("/files/:id", fileHandler.DeleteFile)
 	}
 
 	api := router.Group("/api")
@@ -124,6 +194,20 @@ func main() {
 			policy.POST("/update", policyHandler.UpdatePolicies)
 			policy.GET("/search", policyHandler.SearchPolicies)
 		}
+
+		conversations := api.Group("/conversations")
+		{
+			conversations.GET("", middleware.AdminAuth(cfg.Server.AdminToken), conversationHandler.ListConversations)
+			conversations.GET("/:id", conversationHandler.GetConversation)
+			conversations.DELETE("/:id", conversationHandler.DeleteConversation)
+		}
+
+		jobs := api.Group("/jobs")
+		{
+			jobs.GET("/search", jobHandler.SearchJobs)
+			jobs.GET("/:id", jobHandler.GetJobDetail)
+			jobs.DELETE("/cache", middleware.AdminAuth(cfg.Server.AdminToken), jobHandler.PurgeCache)
+		}
 	}
 
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
@@ -1,12 +1,17 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"qd-sc/internal/model"
 	"qd-sc/internal/service"
+	"qd-sc/internal/session"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,6 +19,15 @@ import (
 
 // 使用服务层定义的固定模型名称
 
+// ConversationIDHeader 会话ID请求/响应头
+const ConversationIDHeader = "X-Conversation-ID"
+
+// ConversationTokenHeader 会话令牌请求/响应头：创建会话时在响应中返回，之后继续、读取、删除该会话时须携带
+const ConversationTokenHeader = "X-Conversation-Token"
+
+// conversationIDPattern 会话ID格式（由客户端生成，如UUID）
+var conversationIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
 // ChatHandler 聊天处理器
 type ChatHandler struct {
 	chatService *service.ChatService
@@ -32,7 +46,7 @@ func NewChatHandler(chatService *service.ChatService) *ChatHandler {
 func (h *ChatHandler) ChatCompletions(c *gin.Context) {
 	var req model.ChatCompletionRequest
 
-	// 只支持 JSON 请求（文件通过 image_url 字段以 URL 方式传递）
+	// 只支持 JSON 请求（文件通过 image_url 字段以 URL 方式传递，或先经 /v1/files 上传后以 file 字段引用文件ID）
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.response.Error(c, http.StatusBadRequest, "invalid_request", "无效的请求格式: "+err.Error())
 		return
@@ -53,6 +67,31 @@ func (h *ChatHandler) ChatCompletions(c *gin.Context) {
 		return
 	}
 
+	// 会话ID：请求体字段优先，其次为请求头
+	if req.ConversationID == "" {
+		req.ConversationID = c.GetHeader(ConversationIDHeader)
+	}
+	if req.ConversationID != "" {
+		if !conversationIDPattern.MatchString(req.ConversationID) {
+			h.response.Error(c, http.StatusBadRequest, "invalid_request", "conversation_id格式无效，只能包含字母、数字、下划线和短横线，长度不超过128")
+			return
+		}
+		c.Header(ConversationIDHeader, req.ConversationID)
+
+		token, err := h.chatService.ClaimConversation(c.Request.Context(), req.ConversationID, c.GetHeader(ConversationTokenHeader))
+		if err != nil {
+			if errors.Is(err, session.ErrForbidden) {
+				h.response.Error(c, http.StatusForbidden, "forbidden", err.Error())
+				return
+			}
+			h.response.Error(c, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+		if token != "" {
+			c.Header(ConversationTokenHeader, token)
+		}
+	}
+
 	// 根据stream参数决定返回方式
 	if req.Stream {
 		h.handleStreamResponse(c, &req)
@@ -61,19 +100,117 @@ func (h *ChatHandler) ChatCompletions(c *gin.Context) {
 	}
 }
 
-// handleNonStreamResponse 处理非流式响应
+// jobCardInterval 流式输出岗位卡片之间的间隔
+const jobCardInterval = 1 * time.Second
+
+// handleNonStreamResponse 处理非流式响应：汇总智能体事件为一条完整消息
 func (h *ChatHandler) handleNonStreamResponse(c *gin.Context, req *model.ChatCompletionRequest) {
-	resp, err := h.chatService.ProcessChatRequest(req)
-	if err != nil {
+	events, errs := h.chatService.RunAgent(c.Request.Context(), req)
+
+	var content strings.Builder
+	finishReason := "stop"
+	var verdict *model.GroundingVerdict
+	for event := range events {
+		switch event.Type {
+		case service.AgentEventContentDelta:
+			content.WriteString(event.Content)
+		case service.AgentEventJobCards:
+			for _, segment := range service.RenderJobCards(event.Jobs) {
+				content.WriteString(segment)
+			}
+		case service.AgentEventFinal:
+			finishReason = event.FinishReason
+			verdict = event.Grounding
+		}
+	}
+
+	if err := <-errs; err != nil {
 		log.Printf("处理聊天请求失败: %v", err)
 		h.response.Error(c, http.StatusInternalServerError, "internal_error", "处理请求失败: "+err.Error())
 		return
 	}
 
-	h.response.Success(c, resp)
+	h.response.Success(c, &model.ChatCompletionResponse{
+		ID:      fmt.Sprintf("chatcmpl-%d", time.Now().Unix()),
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   service.ExposedModelName,
+		Choices: []model.Choice{
+			{
+				Index: 0,
+				Message: model.Message{
+					Role:    "assistant",
+					Content: strings.TrimSpace(content.String()),
+				},
+				FinishReason: finishReason,
+			},
+		},
+		ConversationID: req.ConversationID,
+		Grounding:      verdict,
+	})
+}
+
+// sseWriter SSE输出器，负责把内容写成 OpenAI 兼容的 chunk
+type sseWriter struct {
+	c              *gin.Context
+	firstChunkSent bool
+}
+
+// writeChunk 写入一个chunk，只有第一个chunk携带 role=assistant
+func (w *sseWriter) writeChunk(content, finishReason string) error {
+	return w.writeChunkWithVerdict(content, finishReason, nil)
+}
+
+// writeChunkWithVerdict 写入一个chunk，并附带岗位事实核验结果（仅结束chunk使用）
+func (w *sseWriter) writeChunkWithVerdict(content, finishReason string, verdict *model.GroundingVerdict) error {
+	delta := model.Message{}
+	if content != "" {
+		delta.Content = content
+	}
+	if !w.firstChunkSent {
+		delta.Role = "assistant"
+		w.firstChunkSent = true
+	}
+
+	chunk := model.ChatCompletionChunk{
+		ID:      fmt.Sprintf("chatcmpl-%d", time.Now().Unix()),
+		Object:  "chat.completion.chunk",
+		Created: time.Now().Unix(),
+		Model:   service.ExposedModelName,
+		Choices: []model.ChunkChoice{
+			{
+				Index:        0,
+				Delta:        delta,
+				FinishReason: finishReason,
+			},
+		},
+		Grounding: verdict,
+	}
+
+	chunkJSON, err := json.Marshal(chunk)
+	if err != nil {
+		return fmt.Errorf("序列化chunk失败: %w", err)
+	}
+	if _, err := fmt.Fprintf(w.c.Writer, "data: %s\n\n", string(chunkJSON)); err != nil {
+		return fmt.Errorf("写入SSE数据失败: %w", err)
+	}
+	w.c.Writer.Flush()
+
+	if finishReason != "" {
+		log.Printf("已发送finish_reason=%s的chunk", finishReason)
+	}
+	return nil
+}
+
+// writeDone 写入[DONE]标记（OpenAI标准格式）
+func (w *sseWriter) writeDone() {
+	if _, err := fmt.Fprintf(w.c.Writer, "data: [DONE]\n\n"); err != nil {
+		log.Printf("写入[DONE]标记失败: %v", err)
+	}
+	w.c.Writer.Flush()
 }
 
-// handleStreamResponse 处理流式响应
+// handleStreamResponse 处理流式响应：把智能体事件实时渲染为SSE
 func (h *ChatHandler) handleStreamResponse(c *gin.Context, req *model.ChatCompletionRequest) {
 	// 设置SSE响应头
 	c.Header("Content-Type", "text/event-stream")
@@ -81,77 +218,63 @@ func (h *ChatHandler) handleStreamResponse(c *gin.Context, req *model.ChatComple
 	c.Header("Connection", "keep-alive")
 	c.Header("Transfer-Encoding", "chunked")
 
-	// 传递context以支持取消
-	ctx := c.Request.Context()
-	chunkChan, errChan := h.chatService.ProcessChatRequestStream(ctx, req)
-
-	// 持续发送流式数据
-	for {
-		select {
-		case chunk, ok := <-chunkChan:
-			if !ok {
-				// 通道已关闭，发送[DONE]标记（OpenAI标准格式）
-				if _, err := fmt.Fprintf(c.Writer, "data: [DONE]\n\n"); err != nil {
-					log.Printf("写入[DONE]标记失败: %v", err)
-				}
-				c.Writer.Flush()
-				log.Printf("SSE流已结束，已发送[DONE]标记")
-				return
-			}
+	// 传递context以支持取消；写入客户端失败提前返回时取消智能体，
+	// 避免其阻塞在发送事件上并一直占用会话锁
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	events, errs := h.chatService.RunAgent(ctx, req)
+	w := &sseWriter{c: c}
 
-			// 发送chunk
-			chunkJSON, err := json.Marshal(chunk)
-			if err != nil {
-				log.Printf("序列化chunk失败: %v", err)
-				continue
-			}
+	for event := range events {
+		var err error
+		switch event.Type {
+		case service.AgentEventContentDelta:
+			err = w.writeChunk(event.Content, "")
+		case service.AgentEventJobCards:
+			err = h.streamJobCards(ctx, w, event.Jobs)
+		case service.AgentEventFinal:
+			err = w.writeChunkWithVerdict("", event.FinishReason, event.Grounding)
+		}
+		if err != nil {
+			log.Printf("流式输出失败: %v", err)
+			return
+		}
+	}
 
-			// 写入SSE格式
-			if _, err := fmt.Fprintf(c.Writer, "data: %s\n\n", string(chunkJSON)); err != nil {
-				log.Printf("写入SSE数据失败: %v", err)
-				return
-			}
-			c.Writer.Flush()
+	if ctx.Err() != nil {
+		// 客户端断开连接
+		log.Printf("客户端断开连接")
+		return
+	}
 
-			// 如果这个chunk包含finish_reason，记录日志
-			if len(chunk.Choices) > 0 && chunk.Choices[0].FinishReason != "" {
-				log.Printf("已发送finish_reason=%s的chunk", chunk.Choices[0].FinishReason)
-			}
+	if err := <-errs; err != nil {
+		log.Printf("流式处理错误: %v", err)
+		if werr := w.writeChunk(fmt.Sprintf("\n\n错误：%s", err.Error()), "error"); werr != nil {
+			log.Printf("发送错误信息失败: %v", werr)
+			return
+		}
+	}
 
-		case err, ok := <-errChan:
-			if ok && err != nil {
-				log.Printf("流式处理错误: %v", err)
-				// 发送错误信息
-				errChunk := model.ChatCompletionChunk{
-					ID:      fmt.Sprintf("chatcmpl-%d", time.Now().Unix()),
-					Object:  "chat.completion.chunk",
-					Created: time.Now().Unix(),
-					Model:   service.ExposedModelName,
-					Choices: []model.ChunkChoice{
-						{
-							Index: 0,
-							Delta: model.Message{
-								Role:    "assistant",
-								Content: fmt.Sprintf("\n\n错误：%s", err.Error()),
-							},
-							FinishReason: "error",
-						},
-					},
-				}
-				chunkJSON, _ := json.Marshal(errChunk)
-				fmt.Fprintf(c.Writer, "data: %s\n\n", string(chunkJSON))
-				c.Writer.Flush()
-
-				// 发送DONE
-				fmt.Fprintf(c.Writer, "data: [DONE]\n\n")
-				c.Writer.Flush()
-				return
-			}
+	w.writeDone()
+	log.Printf("SSE流已结束，已发送[DONE]标记")
+}
 
-		case <-c.Request.Context().Done():
-			// 客户端断开连接
-			log.Printf("客户端断开连接")
-			return
+// streamJobCards 逐个输出岗位卡片，卡片之间间隔 jobCardInterval
+func (h *ChatHandler) streamJobCards(ctx context.Context, w *sseWriter, jobs *model.JobResponse) error {
+	segments := service.RenderJobCards(jobs)
+	for i, segment := range segments {
+		if err := w.writeChunk(segment, ""); err != nil {
+			return err
+		}
+
+		// 引导语之后立即输出第一张卡片，之后的卡片间隔输出
+		if i > 0 && i < len(segments)-1 {
+			select {
+			case <-time.After(jobCardInterval):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
 		}
 	}
+	return nil
 }
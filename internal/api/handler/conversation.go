@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"qd-sc/internal/model"
+	"qd-sc/internal/session"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConversationHandler 会话处理器
+type ConversationHandler struct {
+	store    session.Store
+	response *Response
+}
+
+// NewConversationHandler 创建会话处理器
+func NewConversationHandler(store session.Store) *ConversationHandler {
+	return &ConversationHandler{
+		store:    store,
+		response: NewResponse(),
+	}
+}
+
+// ListConversations 列出未过期的会话（管理接口，需要 server.admin_token）
+// @Summary 会话列表
+// @Tags 会话
+// @Produce json
+// @Success 200 {object} Response
+// @Failure 401 {object} Response
+// @Router /api/conversations [get]
+func (h *ConversationHandler) ListConversations(c *gin.Context) {
+	conversations, err := h.store.List(c.Request.Context())
+	if err != nil {
+		h.response.Error(c, http.StatusInternalServerError, "internal_error", "获取会话列表失败: "+err.Error())
+		return
+	}
+
+	h.response.Success(c, gin.H{
+		"total":         len(conversations),
+		"conversations": conversations,
+	})
+}
+
+// GetConversation 获取会话详情（历史消息、工具结果、求职者画像；不含简历原文）
+// @Summary 会话详情
+// @Tags 会话
+// @Produce json
+// @Param id path string true "会话ID"
+// @Param X-Conversation-Token header string true "创建会话时返回的会话令牌"
+// @Success 200 {object} model.Conversation
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Router /api/conversations/{id} [get]
+func (h *ConversationHandler) GetConversation(c *gin.Context) {
+	conv, ok := h.ownedConversation(c)
+	if !ok {
+		return
+	}
+
+	h.response.Success(c, conv.ForClient())
+}
+
+// DeleteConversation 删除（立即过期）会话
+// @Summary 删除会话
+// @Tags 会话
+// @Produce json
+// @Param id path string true "会话ID"
+// @Param X-Conversation-Token header string true "创建会话时返回的会话令牌"
+// @Success 200 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Router /api/conversations/{id} [delete]
+func (h *ConversationHandler) DeleteConversation(c *gin.Context) {
+	if _, ok := h.ownedConversation(c); !ok {
+		return
+	}
+	id := c.Param("id")
+	if err := h.store.Delete(c.Request.Context(), id); err != nil {
+		h.storeError(c, err)
+		return
+	}
+
+	h.response.Success(c, gin.H{"message": "会话已删除", "id": id})
+}
+
+// ownedConversation 读取路径中的会话并校验请求头中的会话令牌，失败时已写入错误响应
+func (h *ConversationHandler) ownedConversation(c *gin.Context) (*model.Conversation, bool) {
+	conv, err := h.store.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.storeError(c, err)
+		return nil, false
+	}
+	if err := session.CheckToken(conv, c.GetHeader(ConversationTokenHeader)); err != nil {
+		h.response.Error(c, http.StatusForbidden, "forbidden", err.Error())
+		return nil, false
+	}
+	return conv, true
+}
+
+// storeError 将存储错误转换为HTTP响应
+func (h *ConversationHandler) storeError(c *gin.Context, err error) {
+	if errors.Is(err, session.ErrNotFound) {
+		h.response.Error(c, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+	h.response.Error(c, http.StatusInternalServerError, "internal_error", err.Error())
+}
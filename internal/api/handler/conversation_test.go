@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"qd-sc/internal/model"
+	"qd-sc/internal/session"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestConversation_RequiresOwnerToken(t *testing.T) {
+	store := session.NewMemoryStore(time.Hour, 0)
+	t.Cleanup(func() { store.Close() })
+	conv := &model.Conversation{
+		ID:       "conv-1",
+		Token:    "owner-token",
+		Messages: []model.Message{{Role: "user", Content: "你好"}},
+		Resume:   "张三 13800000000",
+	}
+	if err := store.Save(context.Background(), conv); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	h := NewConversationHandler(store)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/conversations/:id", h.GetConversation)
+	r.DELETE("/api/conversations/:id", h.DeleteConversation)
+	do := func(method, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/conversations/conv-1", nil)
+		if token != "" {
+			req.Header.Set(ConversationTokenHeader, token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, token := range []string{"", "wrong"} {
+		if w := do(http.MethodGet, token); w.Code != http.StatusForbidden {
+			t.Fatalf("get with token %q: status = %d", token, w.Code)
+		}
+		if w := do(http.MethodDelete, token); w.Code != http.StatusForbidden {
+			t.Fatalf("delete with token %q: status = %d", token, w.Code)
+		}
+	}
+
+	// 会话详情不返回简历原文和会话令牌
+	w := do(http.MethodGet, "owner-token")
+	if w.Code != http.StatusOK {
+		t.Fatalf("get: status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if body := w.Body.String(); strings.Contains(body, "13800000000") || strings.Contains(body, "owner-token") {
+		t.Fatalf("conversation leaked resume or token: %s", body)
+	}
+	var got model.Conversation
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || len(got.Messages) != 1 {
+		t.Fatalf("decode: %v, %+v", err, got)
+	}
+
+	if w := do(http.MethodDelete, "owner-token"); w.Code != http.StatusOK {
+		t.Fatalf("delete: status = %d", w.Code)
+	}
+	if w := do(http.MethodGet, "owner-token"); w.Code != http.StatusNotFound {
+		t.Fatalf("get after delete: status = %d", w.Code)
+	}
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"qd-sc/internal/filestore"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead multipart 表单中除文件内容外的额外字节（边界、表单字段等）
+const multipartOverhead = 1 << 20
+
+// FileHandler 文件上传处理器
+type FileHandler struct {
+	store    *filestore.Store
+	response *Response
+}
+
+// NewFileHandler 创建文件上传处理器
+func NewFileHandler(store *filestore.Store) *FileHandler {
+	return &FileHandler{
+		store:    store,
+		response: DefaultResponse,
+	}
+}
+
+// Upload 上传文件（OpenAI Files API 兼容），返回的文件ID可在聊天消息中引用
+// @Summary 上传文件
+// @Tags 文件
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "文件（PDF、图片、Word）"
+// @Param purpose formData string false "用途，默认 user_data"
+// @Success 200 {object} model.UploadedFile
+// @Failure 400 {object} Response
+// @Failure 413 {object} Response
+// @Failure 415 {object} Response
+// @Router /v1/files [post]
+func (h *FileHandler) Upload(c *gin.Context) {
+	maxSize := h.store.MaxSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			h.response.Error(c, http.StatusRequestEntityTooLarge, "file_too_large", fmt.Sprintf("文件超过大小上限 %d 字节", maxSize))
+			return
+		}
+		h.response.Error(c, http.StatusBadRequest, "invalid_request", "缺少file字段: "+err.Error())
+		return
+	}
+	if header.Size > maxSize {
+		h.response.Error(c, http.StatusRequestEntityTooLarge, "file_too_large", fmt.Sprintf("文件超过大小上限 %d 字节", maxSize))
+		return
+	}
+
+	f, err := header.Open()
+	if err != nil {
+		h.response.Error(c, http.StatusBadRequest, "invalid_request", "读取上传文件失败: "+err.Error())
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxSize+1))
+	if err != nil {
+		h.response.Error(c, http.StatusBadRequest, "invalid_request", "读取上传文件失败: "+err.Error())
+		return
+	}
+
+	file, err := h.store.Save(header.Filename, c.PostForm("purpose"), data)
+	if err != nil {
+		h.storeError(c, err)
+		return
+	}
+	h.response.Success(c, file)
+}
+
+// GetFile 获取文件信息
+// @Summary 文件信息
+// @Tags 文件
+// @Produce json
+// @Param id path string true "文件ID"
+// @Success 200 {object} model.UploadedFile
+// @Failure 404 {object} Response
+// @Router /v1/files/{id} [get]
+func (h *FileHandler) GetFile(c *gin.Context) {
+	file, err := h.store.Get(c.Param("id"))
+	if err != nil {
+		h.storeError(c, err)
+		return
+	}
+	h.response.Success(c, file)
+}
+
+// DeleteFile 删除文件
+// @Summary 删除文件
+// @Tags 文件
+// @Produce json
+// @Param id path string true "文件ID"
+// @Success 200 {object} Response
+// @Failure 404 {object} Response
+// @Router /v1/files/{id} [delete]
+func (h *FileHandler) DeleteFile(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.store.Delete(id); err != nil {
+		h.storeError(c, err)
+		return
+	}
+	h.response.Success(c, gin.H{"id": id, "object": "file", "deleted": true})
+}
+
+// storeError 将文件存储错误转换为HTTP响应
+func (h *FileHandler) storeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, filestore.ErrNotFound):
+		h.response.Error(c, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, filestore.ErrTooLarge):
+		h.response.Error(c, http.StatusRequestEntityTooLarge, "file_too_large", err.Error())
+	case errors.Is(err, filestore.ErrUnsupportedType):
+		h.response.Error(c, http.StatusUnsupportedMediaType, "unsupported_file_type", err.Error())
+	default:
+		h.response.Error(c, http.StatusBadRequest, "invalid_request", err.Error())
+	}
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"qd-sc/internal/client"
+	"qd-sc/internal/model"
+	"qd-sc/internal/service"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JobHandler 岗位处理器
+type JobHandler struct {
+	jobService      *service.JobService
+	locationService *service.LocationService
+	response        *Response
+}
+
+// NewJobHandler 创建岗位处理器
+func NewJobHandler(jobService *service.JobService, locationService *service.LocationService) *JobHandler {
+	return &JobHandler{
+		jobService:      jobService,
+		locationService: locationService,
+		response:        NewResponse(),
+	}
+}
+
+// jobSearchQuery 岗位搜索查询参数：岗位查询请求的全部字段加地标名称
+type jobSearchQuery struct {
+	model.JobQueryRequest
+	Landmark string `form:"landmark"` // 地标名称，解析为经纬度后按附近岗位搜索
+}
+
+// ambiguousLandmarkResponse 地标有歧义时的响应：错误信息加候选地点
+type ambiguousLandmarkResponse struct {
+	model.ErrorResponse
+	Candidates []model.PlaceCandidate `json:"candidates"` // 按置信度从高到低排列
+}
+
+// SearchJobs 按筛选条件直接搜索岗位，不经过对话
+// @Summary 岗位搜索
+// @Description 参数与岗位查询工具一致；提供 landmark 时先解析为经纬度再按半径搜索，地标有歧义时返回409和候选地点
+// @Tags 岗位
+// @Produce json
+// @Param jobTitle query string false "岗位名称关键字"
+// @Param current query int false "页码" default(1)
+// @Param pageSize query int false "每页数量（1-50）" default(10)
+// @Param landmark query string false "地标名称，如五四广场"
+// @Param latitude query string false "纬度"
+// @Param longitude query string false "经度"
+// @Param radius query string false "搜索半径（千米，最大50）" default(10)
+// @Param order query string false "排序：0-推荐，1-最热，2-最新"
+// @Param minSalary query string false "最低薪资（元/月）"
+// @Param maxSalary query string false "最高薪资（元/月）"
+// @Param experience query string false "经验要求代码"
+// @Param education query string false "学历要求代码"
+// @Param companyNature query string false "企业类型代码"
+// @Param jobLocationAreaCode query string false "区域代码"
+// @Success 200 {object} model.JobSearchResponse
+// @Failure 400 {object} Response
+// @Failure 409 {object} ambiguousLandmarkResponse
+// @Failure 502 {object} Response
+// @Router /api/jobs/search [get]
+func (h *JobHandler) SearchJobs(c *gin.Context) {
+	var query jobSearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.response.Error(c, http.StatusBadRequest, "invalid_request", "查询参数格式错误: "+err.Error())
+		return
+	}
+	req := &query.JobQueryRequest
+	landmark := strings.TrimSpace(query.Landmark)
+
+	if landmark != "" && (req.Latitude != "" || req.Longitude != "") {
+		h.response.Error(c, http.StatusBadRequest, "invalid_request", "landmark 与 latitude、longitude 不能同时提供")
+		return
+	}
+
+	// 先把地标解析为经纬度再校验，radius、通勤等参数依赖出发地坐标
+	var location *model.SearchLocation
+	if landmark != "" {
+		place, err := h.locationService.SearchCandidates(landmark)
+		if err != nil {
+			if errors.Is(err, client.ErrPlaceNotFound) {
+				h.response.Error(c, http.StatusBadRequest, "invalid_request", err.Error())
+				return
+			}
+			h.response.Error(c, http.StatusBadGateway, "upstream_error", err.Error())
+			return
+		}
+		// 地标有多个相距较远的候选时不替用户选择，返回候选由调用方改用所选候选的经纬度查询
+		if place.Ambiguous {
+			c.JSON(http.StatusConflict, ambiguousLandmarkResponse{
+				ErrorResponse: model.ErrorResponse{Error: model.ErrorDetail{
+					Message: place.Message + "，请改用所选候选的 latitude、longitude 查询",
+					Type:    "ambiguous_landmark",
+				}},
+				Candidates: place.Candidates,
+			})
+			return
+		}
+		req.Latitude, req.Longitude = place.Latitude, place.Longitude
+		location = &model.SearchLocation{Landmark: landmark, Latitude: place.Latitude, Longitude: place.Longitude}
+	}
+	if err := h.jobService.ValidateJobQuery(req); err != nil {
+		h.jobError(c, err)
+		return
+	}
+
+	result, err := h.jobService.SearchJobs(c.Request.Context(), req)
+	if err != nil {
+		h.jobError(c, err)
+		return
+	}
+	if location != nil {
+		location.Radius = req.Radius
+		result.Location = location
+	}
+
+	h.response.Success(c, result)
+}
+
+// GetJobDetail 获取单个岗位详情（岗位职责、任职要求、福利待遇、联系方式）
+// @Summary 岗位详情
+// @Tags 岗位
+// @Produce json
+// @Param id path string true "岗位ID"
+// @Success 200 {object} model.FormattedJobDetail
+// @Failure 404 {object} Response
+// @Failure 502 {object} Response
+// @Router /api/jobs/{id} [get]
+func (h *JobHandler) GetJobDetail(c *gin.Context) {
+	detail, err := h.jobService.GetJobDetail(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.jobError(c, err)
+		return
+	}
+
+	h.response.Success(c, detail)
+}
+
+// PurgeCache 清空岗位查询缓存（岗位数据更新后使用；管理接口，需要 server.admin_token）
+// @Summary 清空岗位缓存
+// @Tags 岗位
+// @Produce json
+// @Success 200 {object} Response
+// @Failure 401 {object} Response
+// @Failure 500 {object} Response
+// @Router /api/jobs/cache [delete]
+func (h *JobHandler) PurgeCache(c *gin.Context) {
+	n, err := h.jobService.PurgeCache(c.Request.Context())
+	if err != nil {
+		h.response.Error(c, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	h.response.Success(c, gin.H{"message": "岗位缓存已清空", "purged": n})
+}
+
+// jobError 将岗位服务错误转换为HTTP响应
+func (h *JobHandler) jobError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidJobQuery):
+		h.response.Error(c, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, service.ErrJobNotFound):
+		h.response.Error(c, http.StatusNotFound, "not_found", err.Error())
+	default:
+		h.response.Error(c, http.StatusBadGateway, "upstream_error", err.Error())
+	}
+}
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"qd-sc/internal/api/middleware"
+	"qd-sc/internal/client"
+	"qd-sc/internal/config"
+	"qd-sc/internal/jobcache"
+	"qd-sc/internal/model"
+	"qd-sc/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestJobRouter 创建岗位搜索路由，高德地点查询返回 pois，岗位API返回固定岗位并记录查询参数
+func newTestJobRouter(t *testing.T, pois []model.AmapPlace, router client.RoutingProvider) (*gin.Engine, func() []url.Values) {
+	t.Helper()
+
+	amap := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(model.AmapPlaceResponse{Status: "1", Pois: pois})
+	}))
+	t.Cleanup(amap.Close)
+
+	var mu sync.Mutex
+	var queries []url.Values
+	jobServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.Query())
+		mu.Unlock()
+		_ = json.NewEncoder(w).Encode(model.JobAPIResponse{Code: 200, Rows: []model.JobListing{
+			{JobID: "j1", JobTitle: "销售顾问", CompanyName: "市南商贸", AppJobURL: "u1", Latitude: 36.07, Longitude: 120.39},
+		}})
+	}))
+	t.Cleanup(jobServer.Close)
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	configYAML := fmt.Sprintf("amap:\n  base_url: %q\njob_api:\n  base_url: %q\n", amap.URL, jobServer.URL)
+	if err := os.WriteFile(configPath, []byte(configYAML), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+
+	h := NewJobHandler(
+		service.NewJobService(cfg, client.NewJobClient(cfg), nil, nil, router),
+		service.NewLocationService(cfg, client.NewAmapClient(cfg)),
+	)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/jobs/search", h.SearchJobs)
+
+	return r, func() []url.Values {
+		mu.Lock()
+		defer mu.Unlock()
+		return queries
+	}
+}
+
+func TestSearchJobs_LandmarkWithRadius(t *testing.T) {
+	pois := []model.AmapPlace{{Name: "五四广场", Location: "120.3844,36.0622", Address: "东海西路", AdName: "市南区"}}
+	r, queries := newTestJobRouter(t, pois, nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/jobs/search?landmark="+url.QueryEscape("五四广场")+"&radius=5", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	var resp model.JobSearchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := model.SearchLocation{Landmark: "五四广场", Latitude: "36.0622", Longitude: "120.3844", Radius: "5"}
+	if resp.Location == nil || *resp.Location != want {
+		t.Fatalf("location = %+v, want %+v", resp.Location, want)
+	}
+	got := queries()
+	if len(got) != 1 || got[0].Get("latitude") != "36.0622" || got[0].Get("radius") != "5" {
+		t.Fatalf("job API queries = %v", got)
+	}
+
+	// 没有地标和坐标时 radius 仍然无效
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/jobs/search?radius=5", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("radius without origin: status = %d, body = %s", w.Code, w.Body.String())
+	}
+}
+
+// fakeRouter 返回固定通勤时间并记录出发地
+type fakeRouter struct {
+	mu      sync.Mutex
+	origins []client.Coordinate
+}
+
+func (f *fakeRouter) Route(ctx context.Context, mode client.RouteMode, origin, destination client.Coordinate) (*client.Route, error) {
+	f.mu.Lock()
+	f.origins = append(f.origins, origin)
+	f.mu.Unlock()
+	return &client.Route{Duration: 30 * time.Minute, Distance: 9000}, nil
+}
+
+func (f *fakeRouter) Geocode(ctx context.Context, address string) (client.Coordinate, error) {
+	return client.Coordinate{}, client.ErrPlaceNotFound
+}
+
+func TestSearchJobs_LandmarkWithCommute(t *testing.T) {
+	pois := []model.AmapPlace{{Name: "五四广场", Location: "120.3844,36.0622", Address: "东海西路", AdName: "市南区"}}
+	router := &fakeRouter{}
+	r, _ := newTestJobRouter(t, pois, router)
+
+	w := httptest.NewRecorder()
+	target := "/api/jobs/search?landmark=" + url.QueryEscape("五四广场") + "&commuteMode=driving&maxCommuteMinutes=40&sortByCommute=true"
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	var resp model.JobSearchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.JobListings) != 1 || resp.JobListings[0].Commute == nil || resp.JobListings[0].Commute.Minutes != 30 {
+		t.Fatalf("listings = %+v", resp.JobListings)
+	}
+	// 通勤以地标解析出的坐标为出发地
+	if want := (client.Coordinate{Latitude: 36.0622, Longitude: 120.3844}); len(router.origins) != 1 || router.origins[0] != want {
+		t.Fatalf("origins = %v, want %v", router.origins, want)
+	}
+}
+
+func TestSearchJobs_AmbiguousLandmarkReturnsCandidates(t *testing.T) {
+	pois := []model.AmapPlace{
+		{Name: "万达广场(CBD店)", Location: "120.3801,36.0921", Address: "延吉路116号", AdName: "市北区"},
+		{Name: "万达广场(黄岛店)", Location: "120.1951,35.9602", Address: "长江中路216号", AdName: "黄岛区"},
+	}
+	r, queries := newTestJobRouter(t, pois, nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/jobs/search?landmark="+url.QueryEscape("万达广场"), nil))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	var resp ambiguousLandmarkResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Error.Type != "ambiguous_landmark" || len(resp.Candidates) != 2 || resp.Candidates[1].District != "黄岛区" {
+		t.Fatalf("response = %+v", resp)
+	}
+	if got := queries(); len(got) != 0 {
+		t.Fatalf("ambiguous landmark should not query jobs, got %v", got)
+	}
+}
+
+func TestPurgeCache_RequiresAdminToken(t *testing.T) {
+	cache := jobcache.NewMemoryCache(time.Minute, 10)
+	if err := cache.Set(context.Background(), "k", &model.JobAPIResponse{Code: 200}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	cfg := &config.Config{}
+	h := NewJobHandler(service.NewJobService(cfg, client.NewJobClient(cfg), nil, cache, nil), nil)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.DELETE("/api/jobs/cache", middleware.AdminAuth("admin-secret"), h.PurgeCache)
+
+	// 匿名请求不能清空缓存
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/jobs/cache", nil))
+	if w.Code != http.StatusUnauthorized || cache.Len() != 1 {
+		t.Fatalf("anonymous purge: status = %d, entries = %d", w.Code, cache.Len())
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/jobs/cache", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || cache.Len() != 0 {
+		t.Fatalf("admin purge: status = %d, entries = %d", w.Code, cache.Len())
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"qd-sc/internal/model"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuth 管理接口鉴权中间件：请求须携带 Authorization: Bearer <server.admin_token>
+// 未配置令牌时管理接口一律拒绝访问
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			adminError(c, http.StatusForbidden, "forbidden", "未配置 server.admin_token，管理接口已禁用")
+			return
+		}
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(provided)), []byte(token)) != 1 {
+			adminError(c, http.StatusUnauthorized, "unauthorized", "管理接口需要有效的管理令牌")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// adminError 返回鉴权失败响应并终止请求
+func adminError(c *gin.Context, statusCode int, errorType, message string) {
+	c.JSON(statusCode, model.ErrorResponse{
+		Error: model.ErrorDetail{
+			Message: message,
+			Type:    errorType,
+		},
+	})
+	c.Abort()
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func(token string) *gin.Engine {
+		r := gin.New()
+		r.GET("/admin", AdminAuth(token), func(c *gin.Context) {
+			c.String(http.StatusOK, "ok")
+		})
+		return r
+	}
+
+	cases := []struct {
+		token, header string
+		want          int
+	}{
+		{"secret", "Bearer secret", http.StatusOK},
+		{"secret", "Bearer wrong", http.StatusUnauthorized},
+		{"secret", "secret", http.StatusUnauthorized},
+		{"secret", "", http.StatusUnauthorized},
+		{"", "Bearer ", http.StatusForbidden}, // 未配置令牌时禁用
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+		w := httptest.NewRecorder()
+		newRouter(tc.token).ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("token %q, header %q: status = %d, want %d", tc.token, tc.header, w.Code, tc.want)
+		}
+	}
+}
@@ -1,16 +1,23 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"qd-sc/internal/config"
 	"qd-sc/internal/model"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// ErrPlaceNotFound 高德地图没有匹配的地点
+var ErrPlaceNotFound = errors.New("未找到地点")
+
 // AmapClient 高德地图客户端
 type AmapClient struct {
 	baseURL    string
@@ -64,27 +71,101 @@ func (c *AmapClient) SearchPlace(keywords string) (*model.AmapPlaceResponse, err
 	return &result, nil
 }
 
-// GetLocationCoordinates 获取地点的经纬度坐标
-func (c *AmapClient) GetLocationCoordinates(keywords string) (latitude, longitude string, err error) {
-	result, err := c.SearchPlace(keywords)
-	if err != nil {
-		return "", "", err
+// ParseLocation 解析高德地图 "经度,纬度" 格式的坐标
+func ParseLocation(location string) (Coordinate, error) {
+	parts := strings.Split(location, ",")
+	if len(parts) != 2 {
+		return Coordinate{}, fmt.Errorf("解析坐标失败: %s", location)
 	}
+	return ParseCoordinate(parts[1], parts[0])
+}
 
-	if len(result.Pois) == 0 {
-		return "", "", fmt.Errorf("未找到地点: %s", keywords)
+// Route 规划路线，返回第一个方案的耗时和距离，实现 RoutingProvider
+// 公交路线限定在配置的城市内；起终点之间没有方案时返回 ErrNoRoute
+func (c *AmapClient) Route(ctx context.Context, mode RouteMode, origin, destination Coordinate) (*Route, error) {
+	params := url.Values{}
+	params.Set("origin", origin.String())
+	params.Set("destination", destination.String())
+
+	var path string
+	switch mode {
+	case RouteTransit:
+		path = "/direction/transit/integrated"
+		params.Set("city", c.cityName)
+	case RouteDriving:
+		path = "/direction/driving"
+	case RouteWalking:
+		path = "/direction/walking"
+	default:
+		return nil, fmt.Errorf("不支持的出行方式: %s", mode)
 	}
 
-	// 取第一个结果
-	location := result.Pois[0].Location
-	parts := strings.Split(location, ",")
-	if len(parts) != 2 {
-		return "", "", fmt.Errorf("解析坐标失败: %s", location)
+	var result model.AmapRouteResponse
+	if err := c.getJSON(ctx, path, params, &result); err != nil {
+		return nil, err
 	}
+	if result.Status != "1" {
+		return nil, fmt.Errorf("高德API返回错误: %s", result.Info)
+	}
+
+	paths := result.Route.Paths
+	if mode == RouteTransit {
+		paths = result.Route.Transits
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrNoRoute, origin, destination)
+	}
+
+	seconds, err := strconv.Atoi(paths[0].Duration)
+	if err != nil {
+		return nil, fmt.Errorf("解析路线耗时失败: %s", paths[0].Duration)
+	}
+	distance, _ := strconv.Atoi(paths[0].Distance)
+	return &Route{Duration: time.Duration(seconds) * time.Second, Distance: distance}, nil
+}
 
-	// 高德返回的格式是"经度,纬度"
-	longitude = parts[0]
-	latitude = parts[1]
+// Geocode 将地址解析为坐标，实现 RoutingProvider
+func (c *AmapClient) Geocode(ctx context.Context, address string) (Coordinate, error) {
+	params := url.Values{}
+	params.Set("address", address)
+	params.Set("city", c.cityName)
 
-	return latitude, longitude, nil
+	var result model.AmapGeocodeResponse
+	if err := c.getJSON(ctx, "/geocode/geo", params, &result); err != nil {
+		return Coordinate{}, err
+	}
+	if result.Status != "1" {
+		return Coordinate{}, fmt.Errorf("高德API返回错误: %s", result.Info)
+	}
+	if len(result.Geocodes) == 0 {
+		return Coordinate{}, fmt.Errorf("%w: %s", ErrPlaceNotFound, address)
+	}
+
+	return ParseLocation(result.Geocodes[0].Location)
+}
+
+// getJSON 请求高德接口并解析JSON响应，自动附加API密钥
+func (c *AmapClient) getJSON(ctx context.Context, path string, params url.Values, out interface{}) error {
+	params.Set("key", c.apiKey)
+	params.Set("output", "JSON")
+	reqURL := fmt.Sprintf("%s%s?%s", c.baseURL, path, params.Encode())
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("创建HTTP请求失败: %w", err)
+	}
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("HTTP请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API返回错误状态码 %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	return nil
 }
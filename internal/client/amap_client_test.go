@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"qd-sc/internal/config"
+)
+
+func TestAmapClient_RouteAndGeocode(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("key") != "test-key" {
+			t.Errorf("missing api key: %s", r.URL)
+		}
+		switch r.URL.Path {
+		case "/direction/transit/integrated":
+			if q.Get("city") != "青岛" || q.Get("origin") != "120.380000,36.060000" {
+				t.Errorf("unexpected transit query: %s", r.URL.RawQuery)
+			}
+			if q.Get("destination") == "120.000000,36.000000" {
+				_, _ = w.Write([]byte(`{"status":"1","info":"OK","route":{"transits":[]}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"status":"1","info":"OK","route":{"transits":[{"distance":"8200","duration":"2450"}]}}`))
+		case "/direction/driving":
+			_, _ = w.Write([]byte(`{"status":"1","info":"OK","route":{"paths":[{"distance":"9000","duration":"900"}]}}`))
+		case "/geocode/geo":
+			_, _ = w.Write([]byte(`{"status":"1","info":"OK","geocodes":[{"location":"120.470000,36.100000"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewAmapClient(&config.Config{
+		City: config.CityConfig{Name: "青岛"},
+		Amap: config.AmapConfig{APIKey: "test-key", BaseURL: srv.URL, Timeout: time.Second},
+	})
+	ctx := context.Background()
+	origin := Coordinate{Latitude: 36.06, Longitude: 120.38}
+
+	route, err := c.Route(ctx, RouteTransit, origin, Coordinate{Latitude: 36.1, Longitude: 120.47})
+	if err != nil || route.Duration != 2450*time.Second || route.Distance != 8200 {
+		t.Fatalf("transit route = %+v, %v", route, err)
+	}
+	if route, err := c.Route(ctx, RouteDriving, origin, Coordinate{Latitude: 36.1, Longitude: 120.47}); err != nil || route.Duration != 15*time.Minute {
+		t.Fatalf("driving route = %+v, %v", route, err)
+	}
+	if _, err := c.Route(ctx, RouteTransit, origin, Coordinate{Latitude: 36, Longitude: 120}); !errors.Is(err, ErrNoRoute) {
+		t.Fatalf("expected ErrNoRoute, got %v", err)
+	}
+
+	coord, err := c.Geocode(ctx, "崂山区科苑纬一路1号")
+	if err != nil || coord.Latitude != 36.1 || coord.Longitude != 120.47 {
+		t.Fatalf("geocode = %+v, %v", coord, err)
+	}
+}
+
+func TestParseRouteMode(t *testing.T) {
+	for in, want := range map[string]RouteMode{"": RouteDriving, "地铁": RouteTransit, "开车": RouteDriving, "walking": RouteWalking} {
+		if got, err := ParseRouteMode(in, RouteDriving); err != nil || got != want {
+			t.Errorf("ParseRouteMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseRouteMode("骑车", RouteTransit); err == nil {
+		t.Error("unsupported mode should fail")
+	}
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -29,6 +30,11 @@ func NewEmbeddingClient(cfg *config.EmbeddingConfig) *EmbeddingClient {
 
 // GetEmbedding 获取文本的向量表示
 func (c *EmbeddingClient) GetEmbedding(text string) ([]float32, error) {
+	return c.GetEmbeddingContext(context.Background(), text)
+}
+
+// GetEmbeddingContext 获取文本的向量表示，ctx 取消时中止请求
+func (c *EmbeddingClient) GetEmbeddingContext(ctx context.Context, text string) ([]float32, error) {
 	reqBody := model.EmbeddingRequest{
 		Inputs: text,
 	}
@@ -38,7 +44,7 @@ func (c *EmbeddingClient) GetEmbedding(text string) ([]float32, error) {
 		return nil, fmt.Errorf("序列化请求失败: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", c.baseURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("创建请求失败: %w", err)
 	}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,15 +10,19 @@ import (
 	"net/url"
 	"qd-sc/internal/config"
 	"qd-sc/internal/model"
+	"qd-sc/internal/redact"
 	"strconv"
+	"strings"
 )
 
 // JobClient 岗位API客户端
 type JobClient struct {
 	baseURL     string
+	detailURL   string // 岗位详情地址模板，{id} 为岗位ID占位符
 	httpClient  *http.Client
 	logLevel    string
 	locationMap map[string]string // 区域代码到名称的映射
+	redactor    *redact.Redactor  // 日志中的响应体先脱敏
 }
 
 // NewJobClient 创建岗位API客户端
@@ -30,9 +35,11 @@ func NewJobClient(cfg *config.Config) *JobClient {
 
 	return &JobClient{
 		baseURL:     cfg.JobAPI.BaseURL,
+		detailURL:   cfg.JobAPI.DetailURL,
 		httpClient:  NewHTTPClient(HTTPClientConfig{Timeout: cfg.JobAPI.Timeout, MaxIdleConns: 100, MaxIdleConnsPerHost: 50, MaxConnsPerHost: 0}),
 		logLevel:    cfg.Logging.Level,
 		locationMap: locationMap,
+		redactor:    redact.New(&cfg.Redaction),
 	}
 }
 
@@ -79,7 +86,46 @@ func (c *JobClient) QueryJobs(req *model.JobQueryRequest) (*model.JobAPIResponse
 
 	reqURL := fmt.Sprintf("%s?%s", c.baseURL, params.Encode())
 
-	httpReq, err := http.NewRequest("GET", reqURL, nil)
+	body, err := c.get(context.Background(), reqURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var result model.JobAPIResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w, 原始响应: %s", err, string(body))
+	}
+
+	log.Printf("岗位API解析结果: Code=%d, Msg=%s, Rows数量=%d", result.Code, result.Msg, len(result.Rows))
+
+	return &result, nil
+}
+
+// GetJobDetail 查询单个岗位详情
+func (c *JobClient) GetJobDetail(ctx context.Context, jobID string) (*model.JobDetailAPIResponse, error) {
+	if c.detailURL == "" {
+		return nil, fmt.Errorf("未配置岗位详情地址")
+	}
+	reqURL := strings.ReplaceAll(c.detailURL, "{id}", url.PathEscape(jobID))
+
+	body, err := c.get(ctx, reqURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var result model.JobDetailAPIResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w, 原始响应: %s", err, string(body))
+	}
+
+	log.Printf("岗位详情解析结果: Code=%d, Msg=%s, 岗位ID=%s", result.Code, result.Msg, jobID)
+
+	return &result, nil
+}
+
+// get 发送GET请求并返回响应体
+func (c *JobClient) get(ctx context.Context, reqURL string) ([]byte, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("创建HTTP请求失败: %w", err)
 	}
@@ -103,25 +149,18 @@ func (c *JobClient) QueryJobs(req *model.JobQueryRequest) (*model.JobAPIResponse
 	}
 
 	// 打印原始响应（仅在debug级别时显示完整响应，否则显示摘要）
+	logBody := c.redactor.String(string(body))
 	if c.logLevel == "debug" {
-		log.Printf("岗位API原始响应: %s", string(body))
+		log.Printf("岗位API原始响应: %s", logBody)
 	} else {
 		// 非debug模式下显示响应摘要
-		if len(body) > 200 {
-			log.Printf("岗位API响应摘要: %s... (共%d字节)", string(body[:200]), len(body))
+		if len(logBody) > 200 {
+			log.Printf("岗位API响应摘要: %s... (共%d字节)", logBody[:200], len(body))
 		} else {
-			log.Printf("岗位API响应: %s", string(body))
+			log.Printf("岗位API响应: %s", logBody)
 		}
 	}
-
-	var result model.JobAPIResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w, 原始响应: %s", err, string(body))
-	}
-
-	log.Printf("岗位API解析结果: Code=%d, Msg=%s, Rows数量=%d", result.Code, result.Msg, len(result.Rows))
-
-	return &result, nil
+	return body, nil
 }
 
 // FormatJobResponse 格式化岗位响应
@@ -129,39 +168,7 @@ func (c *JobClient) FormatJobResponse(apiResp *model.JobAPIResponse) *model.JobR
 	formattedJobs := make([]model.FormattedJob, 0, len(apiResp.Rows))
 
 	for _, job := range apiResp.Rows {
-		// 格式化薪资
-		salary := "薪资面议"
-		if job.MinSalary > 0 || job.MaxSalary > 0 {
-			salary = fmt.Sprintf("%d-%d元/月", job.MinSalary, job.MaxSalary)
-		}
-
-		// 转换学历代码
-		education := model.EducationMap[job.Education]
-		if education == "" {
-			education = "学历不限"
-		}
-
-		// 转换经验代码
-		experience := model.ExperienceMap[job.Experience]
-		if experience == "" {
-			experience = "经验不限"
-		}
-
-		// 转换区域代码
-		location := c.locationMap[strconv.Itoa(job.JobLocationAreaCode)]
-		if location == "" {
-			location = "未知地区"
-		}
-
-		formattedJobs = append(formattedJobs, model.FormattedJob{
-			JobTitle:    job.JobTitle,
-			CompanyName: job.CompanyName,
-			Salary:      salary,
-			Location:    location,
-			Education:   education,
-			Experience:  experience,
-			AppJobURL:   job.AppJobURL,
-		})
+		formattedJobs = append(formattedJobs, c.formatJob(job))
 	}
 
 	// 如果有data字段，在最后一条job中添加
@@ -174,3 +181,74 @@ func (c *JobClient) FormatJobResponse(apiResp *model.JobAPIResponse) *model.JobR
 		Data:        apiResp.Data,
 	}
 }
+
+// FormatJobDetail 格式化岗位详情，代码字段转换为可读名称
+func (c *JobClient) FormatJobDetail(detail *model.JobDetail) *model.FormattedJobDetail {
+	formatted := &model.FormattedJobDetail{
+		FormattedJob:  c.formatJob(detail.JobListing),
+		Description:   strings.TrimSpace(detail.JobDescription),
+		Requirements:  strings.TrimSpace(detail.JobRequirement),
+		Address:       strings.TrimSpace(detail.WorkAddress),
+		Headcount:     detail.RecruitNum,
+		CompanyNature: model.CompanyNatureMap[detail.CompanyNature],
+		CompanyScale:  detail.CompanyScale,
+		Industry:      detail.Industry,
+		PublishTime:   detail.PublishTime,
+	}
+
+	// 福利待遇兼容中英文逗号、顿号分隔
+	for _, b := range strings.FieldsFunc(detail.Welfare, func(r rune) bool {
+		return r == ',' || r == '，' || r == '、' || r == ';' || r == '；'
+	}) {
+		if b = strings.TrimSpace(b); b != "" {
+			formatted.Benefits = append(formatted.Benefits, b)
+		}
+	}
+
+	if detail.ContactPerson != "" || detail.ContactPhone != "" || detail.ContactEmail != "" {
+		formatted.Contact = &model.JobContact{
+			Name:  detail.ContactPerson,
+			Phone: detail.ContactPhone,
+			Email: detail.ContactEmail,
+		}
+	}
+	return formatted
+}
+
+// formatJob 将岗位API原始字段转换为可读格式
+func (c *JobClient) formatJob(job model.JobListing) model.FormattedJob {
+	// 格式化薪资
+	salary := "薪资面议"
+	if job.MinSalary > 0 || job.MaxSalary > 0 {
+		salary = fmt.Sprintf("%d-%d元/月", job.MinSalary, job.MaxSalary)
+	}
+
+	// 转换学历代码
+	education := model.EducationMap[job.Education]
+	if education == "" {
+		education = "学历不限"
+	}
+
+	// 转换经验代码
+	experience := model.ExperienceMap[job.Experience]
+	if experience == "" {
+		experience = "经验不限"
+	}
+
+	// 转换区域代码
+	location := c.locationMap[strconv.Itoa(job.JobLocationAreaCode)]
+	if location == "" {
+		location = "未知地区"
+	}
+
+	return model.FormattedJob{
+		JobID:       job.JobID,
+		JobTitle:    job.JobTitle,
+		CompanyName: job.CompanyName,
+		Salary:      salary,
+		Location:    location,
+		Education:   education,
+		Experience:  experience,
+		AppJobURL:   job.AppJobURL,
+	}
+}
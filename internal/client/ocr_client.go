@@ -2,19 +2,25 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
 	"qd-sc/internal/config"
+	"qd-sc/internal/redact"
+	"strings"
 )
 
 // OCRClient OCR服务客户端
 type OCRClient struct {
-	baseURL    string
-	httpClient *http.Client
-	logLevel   string
+	baseURL      string
+	httpClient   *http.Client
+	logLevel     string
+	fileEndpoint string           // 上传文件内容解析接口路径
+	redactor     *redact.Redactor // 日志中的解析内容先脱敏
 }
 
 // OCRResponse OCR服务响应结构
@@ -23,20 +29,38 @@ type OCRResponse struct {
 	Data       string  `json:"data"`
 	CostTimeMs float64 `json:"cost_time_ms"`
 	Msg        string  `json:"msg,omitempty"`
+	Pages      int     `json:"pages,omitempty"` // 文档页数（OCR服务返回时）
+}
+
+// OCRResult 文件解析结果
+type OCRResult struct {
+	Text  string
+	Pages int // 页数；OCR服务未返回时按分页符估算，无法判断时为0
 }
 
 // NewOCRClient 创建OCR客户端
 func NewOCRClient(cfg *config.Config) *OCRClient {
 	return &OCRClient{
-		baseURL:    cfg.OCR.BaseURL,
-		httpClient: NewHTTPClient(HTTPClientConfig{Timeout: cfg.OCR.Timeout, MaxIdleConns: 100, MaxIdleConnsPerHost: 50, MaxConnsPerHost: 0}),
-		logLevel:   cfg.Logging.Level,
+		baseURL:      cfg.OCR.BaseURL,
+		httpClient:   NewHTTPClient(HTTPClientConfig{Timeout: cfg.OCR.Timeout, MaxIdleConns: 100, MaxIdleConnsPerHost: 50, MaxConnsPerHost: 0}),
+		logLevel:     cfg.Logging.Level,
+		fileEndpoint: cfg.OCR.FileEndpoint,
+		redactor:     redact.New(&cfg.Redaction),
 	}
 }
 
 // ParseURL 通过URL解析远程文件内容
 // 支持图片、PDF、Excel、PPT等格式
 func (c *OCRClient) ParseURL(fileURL string) (string, error) {
+	result, err := c.ParseURLDetail(context.Background(), fileURL)
+	if err != nil {
+		return "", err
+	}
+	return result.Text, nil
+}
+
+// ParseURLDetail 通过URL解析远程文件内容，同时返回页数
+func (c *OCRClient) ParseURLDetail(ctx context.Context, fileURL string) (*OCRResult, error) {
 	// 构建请求体
 	reqBody := map[string]string{
 		"url": fileURL,
@@ -44,59 +68,91 @@ func (c *OCRClient) ParseURL(fileURL string) (string, error) {
 
 	reqData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("序列化请求失败: %w", err)
+		return nil, fmt.Errorf("序列化请求失败: %w", err)
 	}
 
 	// 打印文件解析请求体
 	log.Printf("OCR文件解析请求: URL=%s, 请求体=%s", c.baseURL+"/ocr/url", string(reqData))
 
 	// 创建HTTP请求
-	httpReq, err := http.NewRequest("POST", c.baseURL+"/ocr/url", bytes.NewReader(reqData))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/ocr/url", bytes.NewReader(reqData))
 	if err != nil {
-		return "", fmt.Errorf("创建HTTP请求失败: %w", err)
+		return nil, fmt.Errorf("创建HTTP请求失败: %w", err)
 	}
-
 	httpReq.Header.Set("Content-Type", "application/json")
 
+	return c.do(httpReq)
+}
+
+// ParseFileDetail 上传文件内容解析（用于 /v1/files 上传的文件），同时返回页数
+func (c *OCRClient) ParseFileDetail(ctx context.Context, filename string, data []byte) (*OCRResult, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, fmt.Errorf("构建上传请求失败: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return nil, fmt.Errorf("构建上传请求失败: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("构建上传请求失败: %w", err)
+	}
+
+	log.Printf("OCR文件内容解析请求: URL=%s, 文件名=%s, 大小=%d字节", c.baseURL+c.fileEndpoint, filename, len(data))
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+c.fileEndpoint, &body)
+	if err != nil {
+		return nil, fmt.Errorf("创建HTTP请求失败: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return c.do(httpReq)
+}
+
+// do 发送OCR请求并解析响应
+func (c *OCRClient) do(httpReq *http.Request) (*OCRResult, error) {
 	// 发送请求
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return "", fmt.Errorf("HTTP请求失败: %w", err)
+		return nil, fmt.Errorf("HTTP请求失败: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// 读取响应
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
+		return nil, fmt.Errorf("读取响应失败: %w", err)
 	}
 
-	// 打印原始响应（仅在debug级别时显示完整响应，否则显示摘要）
+	// 打印原始响应（仅在debug级别时显示完整响应，否则显示摘要），解析内容可能包含简历中的个人信息，先脱敏
+	logBody := c.redactor.String(string(respBody))
 	if c.logLevel == "debug" {
-		log.Printf("OCR原始响应: %s", string(respBody))
+		log.Printf("OCR原始响应: %s", logBody)
 	} else {
 		// 非debug模式下显示响应摘要
-		if len(respBody) > 200 {
-			log.Printf("OCR响应摘要: %s... (共%d字节)", string(respBody[:200]), len(respBody))
+		if len(logBody) > 200 {
+			log.Printf("OCR响应摘要: %s... (共%d字节)", logBody[:200], len(respBody))
 		} else {
-			log.Printf("OCR响应: %s", string(respBody))
+			log.Printf("OCR响应: %s", logBody)
 		}
 	}
 
 	// 解析响应
 	var ocrResp OCRResponse
 	if err := json.Unmarshal(respBody, &ocrResp); err != nil {
-		return "", fmt.Errorf("解析响应失败: %w", err)
+		return nil, fmt.Errorf("解析响应失败: %w", err)
 	}
 
 	// 打印解析结果
 	log.Printf("OCR解析结果: Code=%d, CostTimeMs=%.2f, 数据长度=%d字节", ocrResp.Code, ocrResp.CostTimeMs, len(ocrResp.Data))
 	if c.logLevel == "debug" && ocrResp.Data != "" {
 		// debug模式下打印解析出的数据内容
-		if len(ocrResp.Data) > 500 {
-			log.Printf("OCR解析数据(前500字符): %s...", ocrResp.Data[:500])
+		logData := c.redactor.String(ocrResp.Data)
+		if len(logData) > 500 {
+			log.Printf("OCR解析数据(前500字符): %s...", logData[:500])
 		} else {
-			log.Printf("OCR解析数据: %s", ocrResp.Data)
+			log.Printf("OCR解析数据: %s", logData)
 		}
 	}
 
@@ -106,8 +162,13 @@ func (c *OCRClient) ParseURL(fileURL string) (string, error) {
 		if errMsg == "" {
 			errMsg = "OCR解析失败"
 		}
-		return "", fmt.Errorf("OCR服务返回错误: %s", errMsg)
+		return nil, fmt.Errorf("OCR服务返回错误: %s", errMsg)
 	}
 
-	return ocrResp.Data, nil
+	pages := ocrResp.Pages
+	if pages == 0 && strings.Contains(ocrResp.Data, "\f") {
+		// 按分页符估算页数
+		pages = strings.Count(strings.TrimRight(ocrResp.Data, "\f"), "\f") + 1
+	}
+	return &OCRResult{Text: ocrResp.Data, Pages: pages}, nil
 }
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoRoute 起终点之间没有可用路线（如公交无法到达）
+var ErrNoRoute = errors.New("没有可用的路线")
+
+// RouteMode 出行方式
+type RouteMode string
+
+const (
+	RouteTransit RouteMode = "transit" // 公交/地铁
+	RouteDriving RouteMode = "driving" // 驾车
+	RouteWalking RouteMode = "walking" // 步行
+)
+
+// ParseRouteMode 解析出行方式，支持英文代码和常见中文说法，空字符串返回 def
+func ParseRouteMode(value string, def RouteMode) (RouteMode, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "":
+		return def, nil
+	case "transit", "bus", "metro", "subway", "公交", "地铁", "公交地铁", "公共交通":
+		return RouteTransit, nil
+	case "driving", "drive", "car", "驾车", "开车", "自驾":
+		return RouteDriving, nil
+	case "walking", "walk", "步行", "走路":
+		return RouteWalking, nil
+	}
+	return "", fmt.Errorf("不支持的通勤方式 %q（可选值: transit 公交地铁, driving 驾车, walking 步行）", value)
+}
+
+// Label 出行方式的中文名称
+func (m RouteMode) Label() string {
+	switch m {
+	case RouteTransit:
+		return "公交地铁"
+	case RouteDriving:
+		return "驾车"
+	case RouteWalking:
+		return "步行"
+	}
+	return string(m)
+}
+
+// Coordinate 经纬度坐标
+type Coordinate struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// ParseCoordinate 解析字符串形式的纬度和经度
+func ParseCoordinate(latitude, longitude string) (Coordinate, error) {
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latitude), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return Coordinate{}, fmt.Errorf("纬度无效: %s", latitude)
+	}
+	lng, err := strconv.ParseFloat(strings.TrimSpace(longitude), 64)
+	if err != nil || lng < -180 || lng > 180 {
+		return Coordinate{}, fmt.Errorf("经度无效: %s", longitude)
+	}
+	return Coordinate{Latitude: lat, Longitude: lng}, nil
+}
+
+// IsZero 是否为空坐标
+func (c Coordinate) IsZero() bool {
+	return c.Latitude == 0 && c.Longitude == 0
+}
+
+// DistanceKm 两点之间的球面距离（千米）
+func (c Coordinate) DistanceKm(other Coordinate) float64 {
+	const earthRadiusKm = 6371.0
+	lat1, lat2 := c.Latitude*math.Pi/180, other.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (other.Longitude - c.Longitude) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// String 高德地图坐标格式 "经度,纬度"
+func (c Coordinate) String() string {
+	return strconv.FormatFloat(c.Longitude, 'f', 6, 64) + "," + strconv.FormatFloat(c.Latitude, 'f', 6, 64)
+}
+
+// Route 路线规划结果
+type Route struct {
+	Duration time.Duration // 预计耗时
+	Distance int           // 路线距离（米）
+}
+
+// RoutingProvider 路线规划服务
+// 岗位服务通过该接口计算通勤时间，测试中可以替换为假实现
+type RoutingProvider interface {
+	// Route 规划从起点到终点的路线，没有可用路线时返回 ErrNoRoute
+	Route(ctx context.Context, mode RouteMode, origin, destination Coordinate) (*Route, error)
+	// Geocode 将地址解析为坐标，用于岗位没有坐标时按工作地址计算通勤
+	Geocode(ctx context.Context, address string) (Coordinate, error)
+}
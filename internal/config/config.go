@@ -3,6 +3,10 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -21,23 +25,52 @@ type Config struct {
 	Milvus      MilvusConfig      `yaml:"milvus"`
 	Logging     LoggingConfig     `yaml:"logging"`
 	Performance PerformanceConfig `yaml:"performance"`
+	Session     SessionConfig     `yaml:"session"`
+	Intent      IntentConfig      `yaml:"intent"`
+	Grounding   GroundingConfig   `yaml:"grounding"`
+	Resume      ResumeConfig      `yaml:"resume"`
+	Match       MatchConfig       `yaml:"match"`
+	Redaction   RedactionConfig   `yaml:"redaction"`
+	Files       FilesConfig       `yaml:"files"`
+	Extraction  ExtractionConfig  `yaml:"extraction"`
+	Attachments AttachmentsConfig `yaml:"attachments"`
+	JobCache    JobCacheConfig    `yaml:"job_cache"`
+	Commute     CommuteConfig     `yaml:"commute"`
 }
 
 // CityConfig 城市配置
 type CityConfig struct {
 	Name          string            `yaml:"name"`          // 城市名称，如：青岛
 	SystemName    string            `yaml:"system_name"`   // 系统名称，如：青岛岗位匹配系统
-	AreaCodes     map[string]string `yaml:"area_codes"`    // 区域代码映射，如：市南区:0, 市北区:1
+	AreaCodes     map[string]string `yaml:"area_codes"`    // 区域代码映射，如：市南区:0, 市北区:1（未配置 districts 时使用）
+	Districts     []DistrictConfig  `yaml:"districts"`     // 区县（含别名、拼音和下辖街道），配置后优先于 area_codes
 	Landmarks     []string          `yaml:"landmarks"`     // 地标示例，如：五四广场、青岛啤酒博物馆
 	Abbreviations map[string]string `yaml:"abbreviations"` // 简称映射，如：青啤:青岛啤酒
 }
 
+// DistrictConfig 区县配置
+type DistrictConfig struct {
+	Name    string         `yaml:"name"`    // 名称，如：黄岛区
+	Code    string         `yaml:"code"`    // 岗位API中的区域代码
+	Aliases []string       `yaml:"aliases"` // 别名，如：西海岸新区
+	Pinyin  string         `yaml:"pinyin"`  // 拼音（不含“区”“县”等后缀），如：huangdao
+	Streets []StreetConfig `yaml:"streets"` // 下辖街道、镇，按所属区县查询岗位
+}
+
+// StreetConfig 街道、镇配置
+type StreetConfig struct {
+	Name    string   `yaml:"name"`    // 名称，如：辛安街道
+	Aliases []string `yaml:"aliases"` // 别名
+	Pinyin  string   `yaml:"pinyin"`  // 拼音
+}
+
 // ServerConfig 服务器配置
 type ServerConfig struct {
 	Port         int           `yaml:"port"`
 	Host         string        `yaml:"host"`
 	ReadTimeout  time.Duration `yaml:"read_timeout"`
 	WriteTimeout time.Duration `yaml:"write_timeout"`
+	AdminToken   string        `yaml:"admin_token"` // 管理接口令牌（Authorization: Bearer），为空时禁用管理接口
 }
 
 // LLMConfig LLM配置
@@ -47,6 +80,27 @@ type LLMConfig struct {
 	Model      string        `yaml:"model"`
 	Timeout    time.Duration `yaml:"timeout"`
 	MaxRetries int           `yaml:"max_retries"`
+	// ReasoningTags 模型输出中需要过滤的思维链标签名称，如 think、reasoning
+	ReasoningTags []string `yaml:"reasoning_tags"`
+	// Context 上下文窗口预算与历史压缩配置
+	Context ContextConfig `yaml:"context"`
+}
+
+// ContextConfig 上下文窗口预算配置
+type ContextConfig struct {
+	DefaultTokens       int            `yaml:"default_tokens"`         // 未单独配置的模型的上下文窗口大小
+	ModelTokens         map[string]int `yaml:"model_tokens"`           // 按模型名称配置的上下文窗口大小
+	ReservedOutput      int            `yaml:"reserved_output"`        // 为模型输出预留的token数（请求指定max_tokens时以其为准）
+	KeepRecentTurns     int            `yaml:"keep_recent_turns"`      // 压缩时完整保留的最近对话轮数
+	ToolResultMaxTokens int            `yaml:"tool_result_max_tokens"` // 过期工具结果截断后的最大token数
+}
+
+// WindowFor 获取指定模型的上下文窗口大小
+func (c *ContextConfig) WindowFor(model string) int {
+	if tokens, ok := c.ModelTokens[model]; ok && tokens > 0 {
+		return tokens
+	}
+	return c.DefaultTokens
 }
 
 // AmapConfig 高德地图配置
@@ -54,18 +108,25 @@ type AmapConfig struct {
 	APIKey  string        `yaml:"api_key"`
 	BaseURL string        `yaml:"base_url"`
 	Timeout time.Duration `yaml:"timeout"`
+
+	MaxCandidates       int     `yaml:"max_candidates"`        // 地点查询返回的最多候选数
+	AmbiguousDistanceKm float64 `yaml:"ambiguous_distance_km"` // 置信度相近的候选相距超过该距离（千米）时请用户选择
 }
 
 // JobAPIConfig 岗位API配置
 type JobAPIConfig struct {
-	BaseURL string        `yaml:"base_url"`
-	Timeout time.Duration `yaml:"timeout"`
+	BaseURL   string        `yaml:"base_url"`
+	DetailURL string        `yaml:"detail_url"` // 岗位详情地址，{id} 替换为岗位ID，默认 base_url 去掉末尾 /list 后拼接 /{id}
+	Timeout   time.Duration `yaml:"timeout"`
 }
 
 // OCRConfig OCR服务配置
 type OCRConfig struct {
-	BaseURL string        `yaml:"base_url"`
-	Timeout time.Duration `yaml:"timeout"`
+	BaseURL        string        `yaml:"base_url"`
+	Timeout        time.Duration `yaml:"timeout"`
+	AllowedSchemes []string      `yaml:"allowed_schemes"` // 允许解析的文件URL协议，默认 http、https
+	AllowedHosts   []string      `yaml:"allowed_hosts"`   // 允许解析的文件URL主机，支持 "*.example.com"，"*" 表示所有主机；为空时解析工具不允许解析远程URL，用户消息附件不限制主机
+	FileEndpoint   string        `yaml:"file_endpoint"`   // 上传文件内容解析接口路径（multipart），默认 /ocr/file
 }
 
 // PolicyConfig 政策API配置
@@ -103,6 +164,97 @@ type PerformanceConfig struct {
 	EnablePprof       *bool `yaml:"enable_pprof"`
 	EnableMetrics     *bool `yaml:"enable_metrics"`
 	GCPercent         int   `yaml:"gc_percent"`
+	// ToolTimeout 单个工具调用的超时时间，超时不影响同一轮的其他工具调用
+	ToolTimeout time.Duration `yaml:"tool_timeout"`
+}
+
+// SessionConfig 会话存储配置
+type SessionConfig struct {
+	Backend         string        `yaml:"backend"`          // 存储后端，目前支持 memory
+	TTL             time.Duration `yaml:"ttl"`              // 会话空闲过期时间
+	CleanupInterval time.Duration `yaml:"cleanup_interval"` // 过期会话清理间隔
+	MaxMessages     int           `yaml:"max_messages"`     // 单个会话保留的最大历史消息数
+	MaxToolResults  int           `yaml:"max_tool_results"` // 单个会话保留的最近工具调用记录数
+}
+
+// IntentConfig 意图识别配置
+type IntentConfig struct {
+	LLMEnabled   bool          `yaml:"llm_enabled"`    // 规则置信度不足时是否调用LLM判断
+	LLMThreshold *float64      `yaml:"llm_threshold"`  // 规则置信度低于该值时调用LLM（默认0.7，0 表示从不调用）
+	LLMTimeout   time.Duration `yaml:"llm_timeout"`    // LLM意图识别超时时间
+	LLMMaxTokens int           `yaml:"llm_max_tokens"` // LLM意图识别最大输出token数
+	Model        string        `yaml:"model"`          // LLM意图识别使用的模型，为空时使用 llm.model
+	DecisionLog  string        `yaml:"decision_log"`   // 意图决策日志文件（JSONL，用于离线评估），为空时只写标准日志
+}
+
+// GroundingConfig 岗位事实核验配置
+type GroundingConfig struct {
+	Enabled       *bool  `yaml:"enabled"`        // 是否核验回复中的岗位事实（默认启用）
+	Mode          string `yaml:"mode"`           // 不可信内容的处理方式：strip / flag / regenerate
+	MaxRegenerate *int   `yaml:"max_regenerate"` // regenerate 模式下最多重新生成次数，超出后按 strip 处理（默认1，0 表示不重新生成）
+	ExposeVerdict bool   `yaml:"expose_verdict"` // 是否在响应的 grounding 字段中返回核验结果
+}
+
+// ResumeConfig 简历画像提取配置
+type ResumeConfig struct {
+	LLMEnabled   *bool         `yaml:"llm_enabled"`    // 规则提取后是否再用LLM结构化输出补全（默认启用）
+	LLMTimeout   time.Duration `yaml:"llm_timeout"`    // LLM提取超时时间
+	LLMMaxTokens int           `yaml:"llm_max_tokens"` // LLM提取最大输出token数
+	Model        string        `yaml:"model"`          // LLM提取使用的模型，为空时使用 llm.model
+}
+
+// MatchConfig 简历与岗位匹配评分配置
+type MatchConfig struct {
+	Enabled          *bool         `yaml:"enabled"`           // 有求职者画像时是否按匹配度重排岗位（默认启用）
+	EmbeddingEnabled *bool         `yaml:"embedding_enabled"` // 岗位名称相似度是否使用Embedding服务（默认启用，失败时退化为字符相似度）
+	EmbeddingTimeout time.Duration `yaml:"embedding_timeout"` // 单次排序获取向量的总超时时间
+}
+
+// ExtractionConfig 本地文档文本提取配置（PDF文本层、DOCX），无法提取时回退到OCR
+type ExtractionConfig struct {
+	Enabled         *bool         `yaml:"enabled"`            // 是否先尝试本地提取（默认启用）
+	MinCharsPerPage int           `yaml:"min_chars_per_page"` // PDF每页至少需要的有效字符数，低于该值视为扫描件
+	DownloadTimeout time.Duration `yaml:"download_timeout"`   // 下载远程PDF/DOCX的超时时间
+}
+
+// AttachmentsConfig 消息附件解析配置
+type AttachmentsConfig struct {
+	Concurrency int           `yaml:"concurrency"` // 单条消息中附件的最大并发解析数
+	CacheTTL    time.Duration `yaml:"cache_ttl"`   // 解析结果缓存有效期（按最近使用时间计算），默认与 session.ttl 相同
+	CacheSize   int           `yaml:"cache_size"`  // 解析结果缓存的最大条目数
+}
+
+// JobCacheConfig 岗位查询结果缓存配置
+type JobCacheConfig struct {
+	Enabled *bool         `yaml:"enabled"` // 是否启用，默认启用
+	Backend string        `yaml:"backend"` // 缓存后端，目前支持 memory
+	TTL     time.Duration `yaml:"ttl"`     // 查询结果有效期
+	Size    int           `yaml:"size"`    // 内存缓存最多保存的查询数，超出时淘汰最久未使用的
+}
+
+// CommuteConfig 岗位通勤时间计算配置
+type CommuteConfig struct {
+	Enabled     *bool         `yaml:"enabled"`      // 是否支持按通勤时间筛选和排序岗位，默认启用（使用高德路线规划）
+	DefaultMode string        `yaml:"default_mode"` // 未指定通勤方式时使用：transit / driving / walking
+	Concurrency int           `yaml:"concurrency"`  // 同时计算路线的岗位数
+	Timeout     time.Duration `yaml:"timeout"`      // 一次查询计算全部通勤时间的总超时，超时的岗位视为无法计算
+}
+
+// FilesConfig 文件上传配置
+type FilesConfig struct {
+	Dir             string        `yaml:"dir"`              // 上传文件存放目录，默认为系统临时目录下的 qd-sc-files
+	MaxSize         int64         `yaml:"max_size"`         // 单个文件大小上限（字节）
+	AllowedTypes    []string      `yaml:"allowed_types"`    // 允许上传的文件类型（按内容识别的MIME类型）
+	TTL             time.Duration `yaml:"ttl"`              // 文件保留时间
+	CleanupInterval time.Duration `yaml:"cleanup_interval"` // 过期文件清理间隔
+}
+
+// RedactionConfig 敏感信息脱敏配置
+type RedactionConfig struct {
+	Enabled *bool    `yaml:"enabled"` // 文件内容发送给LLM和写入日志前是否脱敏（默认启用）
+	Mode    string   `yaml:"mode"`    // 脱敏方式：mask（可还原的占位符）/ hash（加盐哈希）/ drop（直接删除）
+	Salt    string   `yaml:"salt"`    // hash 模式使用的盐值
+	Kinds   []string `yaml:"kinds"`   // 启用的识别器：phone / id_card / bank_card / email / address，为空时全部启用
 }
 
 var globalConfig *Config
@@ -155,6 +307,21 @@ func Load(configPath string) (*Config, error) {
 	if cfg.LLM.MaxRetries == 0 {
 		cfg.LLM.MaxRetries = 3
 	}
+	if len(cfg.LLM.ReasoningTags) == 0 {
+		cfg.LLM.ReasoningTags = []string{"think"}
+	}
+	if cfg.LLM.Context.DefaultTokens == 0 {
+		cfg.LLM.Context.DefaultTokens = 32768
+	}
+	if cfg.LLM.Context.ReservedOutput == 0 {
+		cfg.LLM.Context.ReservedOutput = 4096
+	}
+	if cfg.LLM.Context.KeepRecentTurns == 0 {
+		cfg.LLM.Context.KeepRecentTurns = 4
+	}
+	if cfg.LLM.Context.ToolResultMaxTokens == 0 {
+		cfg.LLM.Context.ToolResultMaxTokens = 1500
+	}
 	if cfg.Server.ReadTimeout == 0 {
 		cfg.Server.ReadTimeout = 30 * time.Second
 	}
@@ -169,12 +336,31 @@ func Load(configPath string) (*Config, error) {
 	if cfg.City.SystemName == "" {
 		cfg.City.SystemName = cfg.City.Name + "岗位匹配系统"
 	}
-	if len(cfg.City.AreaCodes) == 0 {
-		cfg.City.AreaCodes = map[string]string{
-			"市南区": "0", "市北区": "1", "李沧区": "2", "崂山区": "3", "黄岛区": "4",
-			"城阳区": "5", "即墨区": "6", "胶州市": "7", "平度市": "8", "莱西市": "9",
+	if len(cfg.City.Districts) == 0 && len(cfg.City.AreaCodes) == 0 {
+		cfg.City.Districts = []DistrictConfig{
+			{Name: "市南区", Code: "0", Pinyin: "shinan"},
+			{Name: "市北区", Code: "1", Pinyin: "shibei"},
+			{Name: "李沧区", Code: "2", Pinyin: "licang"},
+			{Name: "崂山区", Code: "3", Pinyin: "laoshan"},
+			{Name: "黄岛区", Code: "4", Pinyin: "huangdao", Aliases: []string{"西海岸新区", "西海岸", "开发区"}},
+			{Name: "城阳区", Code: "5", Pinyin: "chengyang"},
+			{Name: "即墨区", Code: "6", Pinyin: "jimo"},
+			{Name: "胶州市", Code: "7", Pinyin: "jiaozhou"},
+			{Name: "平度市", Code: "8", Pinyin: "pingdu"},
+			{Name: "莱西市", Code: "9", Pinyin: "laixi"},
 		}
 	}
+	// 只配置 area_codes 时转换为 districts；area_codes 始终与 districts 一致，供按名称->代码读取
+	if len(cfg.City.Districts) == 0 {
+		for name, code := range cfg.City.AreaCodes {
+			cfg.City.Districts = append(cfg.City.Districts, DistrictConfig{Name: name, Code: code})
+		}
+	}
+	sortDistricts(cfg.City.Districts)
+	cfg.City.AreaCodes = make(map[string]string, len(cfg.City.Districts))
+	for _, d := range cfg.City.Districts {
+		cfg.City.AreaCodes[d.Name] = d.Code
+	}
 	if len(cfg.City.Landmarks) == 0 {
 		cfg.City.Landmarks = []string{"五四广场", "青岛啤酒博物馆"}
 	}
@@ -182,6 +368,11 @@ func Load(configPath string) (*Config, error) {
 		cfg.City.Abbreviations = map[string]string{"青啤": "青岛啤酒"}
 	}
 
+	// 岗位详情地址默认与列表地址同级
+	if cfg.JobAPI.DetailURL == "" && cfg.JobAPI.BaseURL != "" {
+		cfg.JobAPI.DetailURL = strings.TrimSuffix(strings.TrimSuffix(cfg.JobAPI.BaseURL, "/"), "/list") + "/{id}"
+	}
+
 	// 性能配置默认值
 	if cfg.Performance.MaxGoroutines == 0 {
 		cfg.Performance.MaxGoroutines = 10000
@@ -195,6 +386,175 @@ func Load(configPath string) (*Config, error) {
 	if cfg.Performance.GCPercent == 0 {
 		cfg.Performance.GCPercent = 100
 	}
+	if cfg.Performance.ToolTimeout == 0 {
+		cfg.Performance.ToolTimeout = 120 * time.Second
+	}
+	if len(cfg.OCR.AllowedSchemes) == 0 {
+		cfg.OCR.AllowedSchemes = []string{"http", "https"}
+	}
+	if cfg.OCR.FileEndpoint == "" {
+		cfg.OCR.FileEndpoint = "/ocr/file"
+	}
+
+	// 会话配置默认值
+	if cfg.Session.Backend == "" {
+		cfg.Session.Backend = "memory"
+	}
+	if cfg.Session.TTL == 0 {
+		cfg.Session.TTL = 24 * time.Hour
+	}
+	if cfg.Session.CleanupInterval == 0 {
+		cfg.Session.CleanupInterval = 10 * time.Minute
+	}
+	if cfg.Session.MaxMessages == 0 {
+		cfg.Session.MaxMessages = 100
+	}
+	if cfg.Session.MaxToolResults == 0 {
+		cfg.Session.MaxToolResults = 20
+	}
+
+	// 意图识别配置默认值
+	if cfg.Intent.LLMThreshold == nil {
+		v := 0.7
+		cfg.Intent.LLMThreshold = &v
+	}
+	if cfg.Intent.LLMTimeout == 0 {
+		cfg.Intent.LLMTimeout = 5 * time.Second
+	}
+	if cfg.Intent.LLMMaxTokens == 0 {
+		cfg.Intent.LLMMaxTokens = 16
+	}
+
+	// 岗位事实核验默认值
+	if cfg.Grounding.Enabled == nil {
+		v := true
+		cfg.Grounding.Enabled = &v
+	}
+	if cfg.Grounding.Mode == "" {
+		cfg.Grounding.Mode = "strip"
+	}
+	if cfg.Grounding.MaxRegenerate == nil {
+		v := 1
+		cfg.Grounding.MaxRegenerate = &v
+	}
+
+	// 简历画像提取默认值
+	if cfg.Resume.LLMEnabled == nil {
+		v := true
+		cfg.Resume.LLMEnabled = &v
+	}
+	if cfg.Resume.LLMTimeout == 0 {
+		cfg.Resume.LLMTimeout = 15 * time.Second
+	}
+	if cfg.Resume.LLMMaxTokens == 0 {
+		cfg.Resume.LLMMaxTokens = 512
+	}
+
+	// 匹配评分默认启用
+	if cfg.Match.Enabled == nil {
+		v := true
+		cfg.Match.Enabled = &v
+	}
+	if cfg.Match.EmbeddingEnabled == nil {
+		v := true
+		cfg.Match.EmbeddingEnabled = &v
+	}
+	if cfg.Match.EmbeddingTimeout == 0 {
+		cfg.Match.EmbeddingTimeout = 3 * time.Second
+	}
+
+	// 脱敏默认启用，使用占位符方式
+	if cfg.Redaction.Enabled == nil {
+		v := true
+		cfg.Redaction.Enabled = &v
+	}
+	if cfg.Redaction.Mode == "" {
+		cfg.Redaction.Mode = "mask"
+	}
+
+	// 文件上传默认值
+	if cfg.Files.Dir == "" {
+		cfg.Files.Dir = filepath.Join(os.TempDir(), "qd-sc-files")
+	}
+	if cfg.Files.MaxSize == 0 {
+		cfg.Files.MaxSize = 10 << 20
+	}
+	if len(cfg.Files.AllowedTypes) == 0 {
+		cfg.Files.AllowedTypes = []string{
+			"application/pdf",
+			"image/png", "image/jpeg", "image/bmp", "image/webp",
+			"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+			"application/msword",
+		}
+	}
+	if cfg.Files.TTL == 0 {
+		cfg.Files.TTL = 24 * time.Hour
+	}
+	if cfg.Files.CleanupInterval == 0 {
+		cfg.Files.CleanupInterval = 10 * time.Minute
+	}
+
+	// 本地文档提取默认启用
+	if cfg.Extraction.Enabled == nil {
+		v := true
+		cfg.Extraction.Enabled = &v
+	}
+	if cfg.Extraction.MinCharsPerPage == 0 {
+		cfg.Extraction.MinCharsPerPage = 30
+	}
+	if cfg.Extraction.DownloadTimeout == 0 {
+		cfg.Extraction.DownloadTimeout = 30 * time.Second
+	}
+
+	// 附件解析配置默认值
+	if cfg.Attachments.Concurrency <= 0 {
+		cfg.Attachments.Concurrency = 4
+	}
+	if cfg.Attachments.CacheTTL == 0 {
+		cfg.Attachments.CacheTTL = cfg.Session.TTL
+	}
+	if cfg.Attachments.CacheSize == 0 {
+		cfg.Attachments.CacheSize = 1000
+	}
+
+	// 岗位查询缓存默认启用
+	if cfg.JobCache.Enabled == nil {
+		v := true
+		cfg.JobCache.Enabled = &v
+	}
+	if cfg.JobCache.Backend == "" {
+		cfg.JobCache.Backend = "memory"
+	}
+	if cfg.JobCache.TTL == 0 {
+		cfg.JobCache.TTL = 5 * time.Minute
+	}
+	if cfg.JobCache.Size == 0 {
+		cfg.JobCache.Size = 500
+	}
+
+	// 地点候选默认返回5个，置信度相近的候选相距3千米以上视为有歧义
+	if cfg.Amap.MaxCandidates == 0 {
+		cfg.Amap.MaxCandidates = 5
+	}
+	if cfg.Amap.AmbiguousDistanceKm == 0 {
+		cfg.Amap.AmbiguousDistanceKm = 3
+	}
+
+	// 通勤时间计算默认启用，默认按公交地铁计算
+	if cfg.Commute.Enabled == nil {
+		v := true
+		cfg.Commute.Enabled = &v
+	}
+	if cfg.Commute.DefaultMode == "" {
+		cfg.Commute.DefaultMode = "transit"
+	}
+	if cfg.Commute.Concurrency == 0 {
+		cfg.Commute.Concurrency = 5
+	}
+	if cfg.Commute.Timeout == 0 {
+		cfg.Commute.Timeout = 10 * time.Second
+	}
+
 	// pprof和metrics默认启用（允许在配置文件中显式关闭）
 	if cfg.Performance.EnablePprof == nil {
 		v := true
@@ -214,25 +574,37 @@ func Get() *Config {
 	return globalConfig
 }
 
-// GetAreaCodesDescription 获取区域代码描述字符串
+// GetAreaCodesDescription 获取区域代码描述字符串，按代码排序输出全部区域
 func (c *CityConfig) GetAreaCodesDescription() string {
-	if len(c.AreaCodes) == 0 {
-		return ""
-	}
-	// 按代码排序输出
-	result := ""
-	for i := 0; i <= 9; i++ {
+	districts := c.Districts
+	if len(districts) == 0 {
 		for name, code := range c.AreaCodes {
-			if code == fmt.Sprintf("%d", i) {
-				if result != "" {
-					result += ", "
-				}
-				result += fmt.Sprintf("%s(%s)", name, code)
-				break
-			}
+			districts = append(districts, DistrictConfig{Name: name, Code: code})
 		}
+		sortDistricts(districts)
 	}
-	return result
+
+	parts := make([]string, 0, len(districts))
+	for _, d := range districts {
+		parts = append(parts, fmt.Sprintf("%s(%s)", d.Name, d.Code))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sortDistricts 按区域代码排序：数字代码按数值，其余按字符串排在数字之后
+func sortDistricts(districts []DistrictConfig) {
+	sort.SliceStable(districts, func(i, j int) bool {
+		a, errA := strconv.Atoi(districts[i].Code)
+		b, errB := strconv.Atoi(districts[j].Code)
+		switch {
+		case errA == nil && errB == nil:
+			return a < b
+		case errA == nil || errB == nil:
+			return errA == nil
+		default:
+			return districts[i].Code < districts[j].Code
+		}
+	})
 }
 
 // GetLandmarksExample 获取地标示例字符串
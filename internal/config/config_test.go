@@ -0,0 +1,86 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad_CityDistricts(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	yaml := `city:
+  name: "石河子"
+  area_codes:
+    石河子市: "0"
+    巴楚县: "10"
+    疏附县: "1"
+    塔什库尔干塔吉克自治县: "11"
+    疏勒县: "2"
+`
+	if err := os.WriteFile(configPath, []byte(yaml), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+
+	want := "石河子市(0), 疏附县(1), 疏勒县(2), 巴楚县(10), 塔什库尔干塔吉克自治县(11)"
+	if got := cfg.City.GetAreaCodesDescription(); got != want {
+		t.Fatalf("GetAreaCodesDescription() = %q, want %q", got, want)
+	}
+	if len(cfg.City.Districts) != 5 || cfg.City.Districts[4].Code != "11" {
+		t.Fatalf("districts not derived from area_codes: %+v", cfg.City.Districts)
+	}
+}
+
+func TestLoad_DistrictsOverrideAreaCodes(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	yaml := `city:
+  area_codes:
+    旧区: "0"
+  districts:
+    - name: "黄岛区"
+      code: "4"
+      aliases: ["西海岸新区"]
+      streets:
+        - name: "辛安街道"
+`
+	if err := os.WriteFile(configPath, []byte(yaml), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if len(cfg.City.AreaCodes) != 1 || cfg.City.AreaCodes["黄岛区"] != "4" {
+		t.Fatalf("area_codes should mirror districts: %+v", cfg.City.AreaCodes)
+	}
+	if len(cfg.City.Districts[0].Streets) != 1 {
+		t.Fatalf("streets not loaded: %+v", cfg.City.Districts)
+	}
+}
+
+func TestLoad_ExplicitZeroKeepsZero(t *testing.T) {
+	load := func(yaml string) *Config {
+		configPath := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(configPath, []byte(yaml), 0o644); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+		cfg, err := Load(configPath)
+		if err != nil {
+			t.Fatalf("load config: %v", err)
+		}
+		return cfg
+	}
+
+	cfg := load("intent:\n  llm_threshold: 0\ngrounding:\n  max_regenerate: 0\n")
+	if *cfg.Intent.LLMThreshold != 0 || *cfg.Grounding.MaxRegenerate != 0 {
+		t.Fatalf("explicit zero overridden: threshold=%v max_regenerate=%v", *cfg.Intent.LLMThreshold, *cfg.Grounding.MaxRegenerate)
+	}
+
+	cfg = load("server:\n  port: 8080\n")
+	if *cfg.Intent.LLMThreshold != 0.7 || *cfg.Grounding.MaxRegenerate != 1 {
+		t.Fatalf("defaults not applied: threshold=%v max_regenerate=%v", *cfg.Intent.LLMThreshold, *cfg.Grounding.MaxRegenerate)
+	}
+}
@@ -0,0 +1,142 @@
+package docextract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrNotDOCX 内容不是有效的 DOCX 文件
+var ErrNotDOCX = errors.New("不是有效的DOCX文件")
+
+// maxDocumentXML word/document.xml 解压后的最大字节数
+const maxDocumentXML = 32 << 20
+
+// extractDOCX 提取 DOCX 正文文本（段落换行、制表符和换行符），返回文本和页数（取自 docProps/app.xml，缺失时为 1）
+func extractDOCX(data []byte) (string, int, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %v", ErrNotDOCX, err)
+	}
+
+	var document, app *zip.File
+	for _, f := range zr.File {
+		switch f.Name {
+		case "word/document.xml":
+			document = f
+		case "docProps/app.xml":
+			app = f
+		}
+	}
+	if document == nil {
+		return "", 0, fmt.Errorf("%w: 缺少 word/document.xml", ErrNotDOCX)
+	}
+
+	body, err := readZipFile(document)
+	if err != nil {
+		return "", 0, err
+	}
+	text, err := documentText(body)
+	if err != nil {
+		return "", 0, err
+	}
+
+	pages := 1
+	if app != nil {
+		if meta, err := readZipFile(app); err == nil {
+			if n := appPages(meta); n > 0 {
+				pages = n
+			}
+		}
+	}
+	return text, pages, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("打开 %s 失败: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxDocumentXML+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", f.Name, err)
+	}
+	if len(data) > maxDocumentXML {
+		return nil, fmt.Errorf("%s 解压后过大", f.Name)
+	}
+	return data, nil
+}
+
+// documentText 从 WordprocessingML 中提取文本
+func documentText(body []byte) (string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	var buf []byte
+	inText := false
+	runDepth := 0 // 段落属性中的 <w:tabs><w:tab/> 是制表位定义，只处理文本块 <w:r> 内的元素
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("解析 word/document.xml 失败: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "r":
+				runDepth++
+			case "t":
+				inText = true
+			case "tab":
+				if runDepth > 0 {
+					buf = append(buf, '\t')
+				}
+			case "br", "cr":
+				if runDepth > 0 {
+					buf = append(buf, '\n')
+				}
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "r":
+				runDepth--
+			case "t":
+				inText = false
+			case "p":
+				buf = append(buf, '\n')
+			case "tc":
+				// 表格单元格之间用制表符分隔，每行一行
+				buf = append(bytes.TrimRight(buf, "\n"), '\t')
+			case "tr":
+				buf = append(bytes.TrimRight(buf, "\t"), '\n')
+			}
+		case xml.CharData:
+			if inText {
+				buf = append(buf, t...)
+			}
+		}
+	}
+	return strings.TrimSpace(string(buf)), nil
+}
+
+// appPages 读取 docProps/app.xml 中的 <Pages>
+func appPages(meta []byte) int {
+	var props struct {
+		Pages string `xml:"Pages"`
+	}
+	if err := xml.Unmarshal(meta, &props); err != nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(props.Pages))
+	return n
+}
@@ -0,0 +1,121 @@
+// Package docextract 本地文档文本提取（PDF文本层、DOCX），纯Go实现，不依赖外部服务
+package docextract
+
+import (
+	"errors"
+	"fmt"
+	"qd-sc/internal/config"
+	"strings"
+	"unicode"
+)
+
+// 提取方式
+const (
+	MethodPDFText = "pdf_text" // 读取PDF文本层
+	MethodDOCX    = "docx"     // 读取DOCX正文XML
+)
+
+// MIME类型
+const (
+	MimePDF  = "application/pdf"
+	MimeDOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+)
+
+var (
+	// ErrUnsupported 该类型无法本地提取（图片、.doc 等）
+	ErrUnsupported = errors.New("不支持本地提取的文件类型")
+	// ErrNoTextLayer 没有可用的文本层（扫描件或字体无法还原文本）
+	ErrNoTextLayer = errors.New("没有可用的文本层")
+)
+
+// maxGarbageRatio 乱码字符（替换符、私有区、控制字符）占比上限
+const maxGarbageRatio = 0.1
+
+// Result 提取结果
+type Result struct {
+	Text   string
+	Pages  int
+	Method string
+}
+
+// Extractor 本地文档提取器
+type Extractor struct {
+	enabled         bool
+	minCharsPerPage int
+}
+
+// New 创建本地文档提取器
+func New(cfg *config.ExtractionConfig) *Extractor {
+	return &Extractor{
+		enabled:         cfg.Enabled == nil || *cfg.Enabled,
+		minCharsPerPage: cfg.MinCharsPerPage,
+	}
+}
+
+// Supports 判断该MIME类型是否可以尝试本地提取（nil 或未启用时返回 false）
+func (e *Extractor) Supports(mimeType string) bool {
+	if e == nil || !e.enabled {
+		return false
+	}
+	return mimeType == MimePDF || mimeType == MimeDOCX
+}
+
+// Extract 提取文档文本
+// PDF 没有可用文本层时返回 ErrNoTextLayer，调用方应回退到OCR
+func (e *Extractor) Extract(data []byte, mimeType string) (*Result, error) {
+	if !e.Supports(mimeType) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, mimeType)
+	}
+
+	if mimeType == MimeDOCX {
+		text, pages, err := extractDOCX(data)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(text) == "" {
+			return nil, fmt.Errorf("%w: DOCX正文为空", ErrNoTextLayer)
+		}
+		return &Result{Text: text, Pages: pages, Method: MethodDOCX}, nil
+	}
+
+	text, pages, err := extractPDF(data)
+	if err != nil {
+		return nil, err
+	}
+	if !HasTextLayer(text, pages, e.minCharsPerPage) {
+		return nil, fmt.Errorf("%w: %d页，提取到%d个有效字符", ErrNoTextLayer, pages, meaningfulChars(text))
+	}
+	return &Result{Text: text, Pages: pages, Method: MethodPDFText}, nil
+}
+
+// HasTextLayer 判断提取出的PDF文本是否可用：平均每页有效字符数不低于 minCharsPerPage，且乱码占比不高
+func HasTextLayer(text string, pages, minCharsPerPage int) bool {
+	if pages < 1 {
+		pages = 1
+	}
+	good, bad := 0, 0
+	for _, r := range text {
+		switch {
+		case r == unicode.ReplacementChar, r >= 0xE000 && r <= 0xF8FF,
+			unicode.IsControl(r) && r != '\n' && r != '\t' && r != '\f' && r != '\r':
+			bad++
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			good++
+		}
+	}
+	if good == 0 || good < minCharsPerPage*pages {
+		return false
+	}
+	return float64(bad)/float64(good+bad) <= maxGarbageRatio
+}
+
+// meaningfulChars 统计字母、数字和汉字数量
+func meaningfulChars(text string) int {
+	n := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			n++
+		}
+	}
+	return n
+}
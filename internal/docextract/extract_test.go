@@ -0,0 +1,224 @@
+package docextract
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"qd-sc/internal/config"
+)
+
+// buildPDF 按顺序拼装PDF对象（对象编号从1开始，1号为Catalog）
+func buildPDF(objects ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	for i, obj := range objects {
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	buf.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return buf.Bytes()
+}
+
+func stream(dict, data string) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+func flateStream(dict, data string) string {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write([]byte(data))
+	w.Close()
+	return stream(dict+" /Filter /FlateDecode", buf.String())
+}
+
+// utf16Hex 将文本编码为 UTF-16BE 十六进制
+func utf16Hex(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		fmt.Fprintf(&sb, "%04X", r)
+	}
+	return sb.String()
+}
+
+// chineseResumePDF 两页简历：Type0 字体 + ToUnicode（字形ID按出现顺序编号），第二页正文在 Form XObject 中
+func chineseResumePDF() []byte {
+	page1 := "姓名：张伟 求职意向：Java开发工程师 工作经验五年 熟悉微服务架构和分布式系统设计"
+	page2 := "教育背景：青岛大学 计算机科学与技术 本科 期望薪资八千到一万二 期望工作地点市南区"
+
+	glyphs := map[rune]int{}
+	var bfchar strings.Builder
+	encode := func(s string) string {
+		var sb strings.Builder
+		for _, r := range s {
+			gid, ok := glyphs[r]
+			if !ok {
+				gid = len(glyphs) + 1
+				glyphs[r] = gid
+				fmt.Fprintf(&bfchar, "<%04X> <%s>\n", gid, utf16Hex(string(r)))
+			}
+			fmt.Fprintf(&sb, "%04X", gid)
+		}
+		return sb.String()
+	}
+	half := len([]rune(page1)) / 2
+	line1, line2 := encode(string([]rune(page1)[:half])), encode(string([]rune(page1)[half:]))
+	form := encode(page2)
+
+	cmap := fmt.Sprintf("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n"+
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n"+
+		"%d beginbfchar\n%sendbfchar\nendcmap\nend\nend", len(glyphs), bfchar.String())
+
+	content1 := fmt.Sprintf("BT /F1 12 Tf 72 720 Td <%s> Tj 0 -18 Td [<%s>] TJ ET", line1, line2)
+	content2 := "q /Fm1 Do Q"
+	formContent := fmt.Sprintf("BT /F1 12 Tf 1 0 0 1 72 700 Tm <%s> Tj ET", form)
+
+	return buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /Resources << /Font << /F1 5 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 6 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 7 0 R /Resources << /Font << /F1 5 0 R >> /XObject << /Fm1 9 0 R >> >> >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /SimSun /Encoding /Identity-H /ToUnicode 8 0 R >>",
+		flateStream("", content1),
+		flateStream("", content2),
+		flateStream("", cmap),
+		flateStream("/Type /XObject /Subtype /Form /BBox [0 0 612 792]", formContent),
+	)
+}
+
+func newTestExtractor() *Extractor {
+	return New(&config.ExtractionConfig{MinCharsPerPage: 20})
+}
+
+func TestExtract_PDFTextLayer(t *testing.T) {
+	res, err := newTestExtractor().Extract(chineseResumePDF(), MimePDF)
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if res.Method != MethodPDFText || res.Pages != 2 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	pages := strings.Split(res.Text, "\f")
+	if len(pages) != 2 {
+		t.Fatalf("pages = %q", res.Text)
+	}
+	if pages[0] != "姓名：张伟 求职意向：Java开发工程师 \n工作经验五年 熟悉微服务架构和分布式系统设计" {
+		t.Errorf("page 1 = %q", pages[0])
+	}
+	if !strings.Contains(pages[1], "青岛大学 计算机科学与技术 本科") {
+		t.Errorf("page 2 (form xobject) = %q", pages[1])
+	}
+}
+
+func TestExtract_SimpleFontAndOperators(t *testing.T) {
+	content := "BT /F1 10 Tf 14 TL 50 700 Td (Zhang Wei) Tj T* [(Java)-300(Developer)] TJ " +
+		"(Skills: Go\\, SQL \\(MySQL\\)) ' 200 0 Td (Qingdao) Tj ET " +
+		"BI /W 1 /H 1 /CS /G /BPC 8 ID \x00\xffEI\x00 EI Q"
+	pdf := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		stream("", content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding << /Differences [74 /J] >> >>",
+	)
+	text, pages, err := extractPDF(pdf)
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	want := "Zhang Wei\nJava Developer\nSkills: Go, SQL (MySQL) Qingdao"
+	if pages != 1 || text != want {
+		t.Fatalf("got %d pages %q, want %q", pages, text, want)
+	}
+}
+
+func TestExtract_ScannedPDFFallsBack(t *testing.T) {
+	// 只有一张图片的扫描件
+	scanned := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /XObject << /Im0 5 0 R >> >> >>",
+		stream("", "q 612 0 0 792 0 0 cm /Im0 Do Q"),
+		stream("/Type /XObject /Subtype /Image /Width 1 /Height 1 /BitsPerComponent 8 /ColorSpace /DeviceGray", "\x80"),
+	)
+	if _, err := newTestExtractor().Extract(scanned, MimePDF); !errors.Is(err, ErrNoTextLayer) {
+		t.Fatalf("scanned = %v, want ErrNoTextLayer", err)
+	}
+
+	// Identity-H 字体没有 ToUnicode，无法还原文本
+	content := "BT /F1 12 Tf 72 720 Td <" + strings.Repeat("0102", 40) + "> Tj ET"
+	noCMap := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		stream("", content),
+		"<< /Type /Font /Subtype /Type0 /Encoding /Identity-H >>",
+	)
+	if _, err := newTestExtractor().Extract(noCMap, MimePDF); !errors.Is(err, ErrNoTextLayer) {
+		t.Fatalf("identity-h = %v, want ErrNoTextLayer", err)
+	}
+
+	encrypted := append(buildPDF("<< /Type /Catalog >>"), []byte("trailer\n<< /Encrypt << /Filter /Standard >> >>\n")...)
+	if _, err := newTestExtractor().Extract(encrypted, MimePDF); !errors.Is(err, ErrEncrypted) {
+		t.Fatalf("encrypted = %v, want ErrEncrypted", err)
+	}
+	if _, err := newTestExtractor().Extract([]byte("\x89PNG"), MimePDF); !errors.Is(err, ErrNotPDF) {
+		t.Fatalf("not pdf = %v", err)
+	}
+}
+
+func docx(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, _ := w.Create(name)
+		f.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtract_DOCX(t *testing.T) {
+	data := docx(t, map[string]string{
+		"word/document.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>姓名：</w:t></w:r><w:r><w:t xml:space="preserve">李娜</w:t></w:r></w:p>
+<w:p><w:pPr><w:tabs><w:tab w:val="left" w:pos="2100"/></w:tabs></w:pPr><w:r><w:t>电话</w:t><w:tab/><w:t>138&amp;</w:t><w:br/><w:t>求职意向：会计</w:t></w:r></w:p>
+<w:tbl><w:tr><w:tc><w:p><w:r><w:t>学历</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>本科</w:t></w:r></w:p></w:tc></w:tr></w:tbl>
+</w:body></w:document>`,
+		"docProps/app.xml": `<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties"><Pages>2</Pages></Properties>`,
+	})
+
+	res, err := newTestExtractor().Extract(data, MimeDOCX)
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	want := "姓名：李娜\n电话\t138&\n求职意向：会计\n学历\t本科"
+	if res.Method != MethodDOCX || res.Pages != 2 || res.Text != want {
+		t.Fatalf("got %+v, want text %q", res, want)
+	}
+
+	if _, err := newTestExtractor().Extract(docx(t, map[string]string{"word/other.xml": "<x/>"}), MimeDOCX); !errors.Is(err, ErrNotDOCX) {
+		t.Fatalf("missing document.xml = %v", err)
+	}
+}
+
+func TestExtractor_DisabledAndUnsupported(t *testing.T) {
+	disabled := false
+	e := New(&config.ExtractionConfig{Enabled: &disabled})
+	if e.Supports(MimePDF) {
+		t.Fatal("disabled extractor should not support pdf")
+	}
+	var nilExtractor *Extractor
+	if nilExtractor.Supports(MimeDOCX) {
+		t.Fatal("nil extractor should not support docx")
+	}
+	if _, err := newTestExtractor().Extract([]byte("x"), "image/png"); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("image = %v", err)
+	}
+}
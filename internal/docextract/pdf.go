@@ -0,0 +1,386 @@
+package docextract
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/ascii85"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+)
+
+var (
+	// ErrEncrypted PDF 已加密，无法直接读取文本
+	ErrEncrypted = errors.New("PDF已加密")
+	// ErrNotPDF 内容不是PDF
+	ErrNotPDF = errors.New("不是有效的PDF文件")
+)
+
+const (
+	// maxDecodedStream 单个流解码后的最大字节数，防止压缩炸弹
+	maxDecodedStream = 32 << 20
+	// maxPages 最多提取的页数
+	maxPages = 200
+)
+
+// objHeader 匹配间接对象头 "n g obj"
+var objHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// pdfDoc 已加载的PDF文档
+type pdfDoc struct {
+	objects map[int]interface{}
+	trailer pdfDict
+}
+
+// loadPDF 加载PDF文档
+// 不依赖 xref 表，而是扫描全文中的对象头，因此也能处理 xref 损坏或增量更新的文件（后出现的对象覆盖先出现的）
+func loadPDF(data []byte) (*pdfDoc, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \r\n\t"), []byte("%PDF-")) {
+		return nil, ErrNotPDF
+	}
+
+	doc := &pdfDoc{objects: make(map[int]interface{}), trailer: pdfDict{}}
+	for _, m := range objHeader.FindAllSubmatchIndex(data, -1) {
+		if m[0] > 0 && !isWhitespace(data[m[0]-1]) && !isDelimiter(data[m[0]-1]) {
+			continue
+		}
+		num := atoi(data[m[2]:m[3]])
+		obj, ok := parseIndirect(data, m[1])
+		if ok {
+			doc.objects[num] = obj
+		}
+	}
+	if len(doc.objects) == 0 {
+		return nil, ErrNotPDF
+	}
+
+	doc.loadObjectStreams()
+	doc.loadTrailer(data)
+
+	if _, ok := doc.trailer["Encrypt"]; ok {
+		return nil, ErrEncrypted
+	}
+	return doc, nil
+}
+
+func atoi(b []byte) int {
+	n := 0
+	for _, c := range b {
+		n = n*10 + int(c-'0')
+		if n > 1<<30 {
+			return -1
+		}
+	}
+	return n
+}
+
+// parseIndirect 解析 "obj" 关键字之后的对象，若为流则一并读取流数据
+func parseIndirect(data []byte, pos int) (interface{}, bool) {
+	l := newLexer(data)
+	l.pos = pos
+	obj, err := l.object()
+	if err != nil {
+		return nil, false
+	}
+
+	dict, ok := obj.(pdfDict)
+	if !ok || !l.hasPrefixAt("stream") {
+		return obj, true
+	}
+
+	start := l.pos + len("stream")
+	if start < len(data) && data[start] == '\r' {
+		start++
+	}
+	if start < len(data) && data[start] == '\n' {
+		start++
+	}
+
+	// 优先使用直接给出的 /Length，校验失败时搜索 endstream
+	if n, ok := dict["Length"].(int64); ok && n >= 0 && start+int(n) <= len(data) {
+		end := start + int(n)
+		rest := bytes.TrimLeft(data[end:min(end+32, len(data))], " \r\n\t")
+		if bytes.HasPrefix(rest, []byte("endstream")) {
+			return &pdfStream{dict: dict, data: data[start:end]}, true
+		}
+	}
+	idx := bytes.Index(data[start:], []byte("endstream"))
+	if idx < 0 {
+		return nil, false
+	}
+	end := start + idx
+	for end > start && (data[end-1] == '\n' || data[end-1] == '\r') {
+		end--
+	}
+	return &pdfStream{dict: dict, data: data[start:end]}, true
+}
+
+// loadObjectStreams 展开对象流（/Type /ObjStm）中的压缩对象
+func (d *pdfDoc) loadObjectStreams() {
+	for _, obj := range d.objects {
+		s, ok := obj.(*pdfStream)
+		if !ok || s.dict["Type"] != pdfName("ObjStm") {
+			continue
+		}
+		n, _ := s.dict["N"].(int64)
+		first, _ := s.dict["First"].(int64)
+		body, err := d.decodeStream(s)
+		if err != nil || first < 0 || int(first) > len(body) {
+			continue
+		}
+
+		header := newLexer(body[:first])
+		for i := int64(0); i < n; i++ {
+			numTok, err1 := header.token()
+			offTok, err2 := header.token()
+			if err1 != nil || err2 != nil {
+				break
+			}
+			num, ok1 := numTok.(int64)
+			off, ok2 := offTok.(int64)
+			if !ok1 || !ok2 || int(first+off) >= len(body) {
+				continue
+			}
+			if _, exists := d.objects[int(num)]; exists {
+				continue
+			}
+			l := newLexer(body)
+			l.pos = int(first + off)
+			if v, err := l.object(); err == nil {
+				d.objects[int(num)] = v
+			}
+		}
+	}
+}
+
+// loadTrailer 读取 trailer 字典（传统 trailer 或 xref 流）
+func (d *pdfDoc) loadTrailer(data []byte) {
+	// 传统 trailer，按出现顺序合并，后出现的优先
+	rest := data
+	offset := 0
+	for {
+		idx := bytes.Index(rest, []byte("trailer"))
+		if idx < 0 {
+			break
+		}
+		l := newLexer(data)
+		l.pos = offset + idx + len("trailer")
+		if obj, err := l.object(); err == nil {
+			if dict, ok := obj.(pdfDict); ok {
+				for k, v := range dict {
+					d.trailer[k] = v
+				}
+			}
+		}
+		offset += idx + len("trailer")
+		rest = data[offset:]
+	}
+
+	// xref 流
+	for _, obj := range d.objects {
+		s, ok := obj.(*pdfStream)
+		if !ok || s.dict["Type"] != pdfName("XRef") {
+			continue
+		}
+		for _, key := range []pdfName{"Root", "Encrypt", "Info"} {
+			if v, ok := s.dict[key]; ok {
+				if _, exists := d.trailer[key]; !exists {
+					d.trailer[key] = v
+				}
+			}
+		}
+	}
+}
+
+// resolve 解析间接引用，带深度限制防止循环引用
+func (d *pdfDoc) resolve(v interface{}) interface{} {
+	for i := 0; i < 16; i++ {
+		ref, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		v = d.objects[ref.num]
+	}
+	return nil
+}
+
+func (d *pdfDoc) dict(v interface{}) pdfDict {
+	switch t := d.resolve(v).(type) {
+	case pdfDict:
+		return t
+	case *pdfStream:
+		return t.dict
+	}
+	return nil
+}
+
+func (d *pdfDoc) array(v interface{}) pdfArray {
+	a, _ := d.resolve(v).(pdfArray)
+	return a
+}
+
+func (d *pdfDoc) name(v interface{}) pdfName {
+	n, _ := d.resolve(v).(pdfName)
+	return n
+}
+
+// decodeStream 按 /Filter 解码流数据
+func (d *pdfDoc) decodeStream(s *pdfStream) ([]byte, error) {
+	var filters []pdfName
+	switch f := d.resolve(s.dict["Filter"]).(type) {
+	case pdfName:
+		filters = []pdfName{f}
+	case pdfArray:
+		for _, v := range f {
+			filters = append(filters, d.name(v))
+		}
+	}
+
+	data := s.data
+	for _, f := range filters {
+		var err error
+		switch f {
+		case "FlateDecode", "Fl":
+			data, err = inflate(data)
+		case "ASCIIHexDecode", "AHx":
+			data = asciiHexDecode(data)
+		case "ASCII85Decode", "A85":
+			data, err = ascii85Decode(data)
+		default:
+			return nil, fmt.Errorf("不支持的流过滤器: %s", f)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// inflate 解压 FlateDecode 数据；部分生成器输出的流缺少 zlib 头或校验和，退化为原始 deflate 并接受已解出的部分
+func inflate(data []byte) ([]byte, error) {
+	var r io.Reader
+	if zr, err := zlib.NewReader(bytes.NewReader(data)); err == nil {
+		r = zr
+	} else {
+		r = flate.NewReader(bytes.NewReader(data))
+	}
+	out, err := io.ReadAll(io.LimitReader(r, maxDecodedStream+1))
+	if len(out) > maxDecodedStream {
+		return nil, errors.New("PDF流解压后过大")
+	}
+	if err != nil && len(out) == 0 {
+		return nil, fmt.Errorf("解压PDF流失败: %w", err)
+	}
+	return out, nil
+}
+
+func asciiHexDecode(data []byte) []byte {
+	l := newLexer(append(append([]byte{'<'}, data...), '>'))
+	return l.hexString()
+}
+
+func ascii85Decode(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	data = bytes.TrimPrefix(data, []byte("<~"))
+	if i := bytes.Index(data, []byte("~>")); i >= 0 {
+		data = data[:i]
+	}
+	out := make([]byte, 4*len(data)/5+4)
+	n, _, err := ascii85.Decode(out, data, true)
+	if err != nil {
+		return nil, fmt.Errorf("ASCII85解码失败: %w", err)
+	}
+	return out[:n], nil
+}
+
+// pdfPage 页面及其（含继承的）资源字典
+type pdfPage struct {
+	dict      pdfDict
+	resources pdfDict
+}
+
+// pages 按文档顺序返回页面；页面树损坏时退化为按对象编号排列的所有 /Type /Page 对象
+func (d *pdfDoc) pages() []pdfPage {
+	var pages []pdfPage
+	if root := d.dict(d.trailer["Root"]); root != nil {
+		visited := make(map[int]bool)
+		d.walkPages(root["Pages"], nil, visited, &pages, 0)
+	}
+	if len(pages) > 0 {
+		return pages
+	}
+
+	nums := make([]int, 0, len(d.objects))
+	for num, obj := range d.objects {
+		if dict, ok := obj.(pdfDict); ok && dict["Type"] == pdfName("Page") {
+			nums = append(nums, num)
+		}
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		dict := d.objects[num].(pdfDict)
+		pages = append(pages, pdfPage{dict: dict, resources: d.dict(dict["Resources"])})
+		if len(pages) >= maxPages {
+			break
+		}
+	}
+	return pages
+}
+
+func (d *pdfDoc) walkPages(node interface{}, inherited pdfDict, visited map[int]bool, pages *[]pdfPage, depth int) {
+	if depth > maxNesting || len(*pages) >= maxPages {
+		return
+	}
+	if ref, ok := node.(pdfRef); ok {
+		if visited[ref.num] {
+			return
+		}
+		visited[ref.num] = true
+	}
+	dict := d.dict(node)
+	if dict == nil {
+		return
+	}
+
+	resources := inherited
+	if r := d.dict(dict["Resources"]); r != nil {
+		resources = r
+	}
+
+	if kids := d.array(dict["Kids"]); kids != nil && dict["Type"] != pdfName("Page") {
+		for _, kid := range kids {
+			d.walkPages(kid, resources, visited, pages, depth+1)
+		}
+		return
+	}
+	*pages = append(*pages, pdfPage{dict: dict, resources: resources})
+}
+
+// contents 返回页面内容流（/Contents 可能是单个流或流数组）解码后拼接的数据
+func (d *pdfDoc) contents(page pdfDict) []byte {
+	var streams []interface{}
+	switch c := d.resolve(page["Contents"]).(type) {
+	case *pdfStream:
+		streams = []interface{}{c}
+	case pdfArray:
+		streams = c
+	}
+
+	var buf bytes.Buffer
+	for _, v := range streams {
+		s, ok := d.resolve(v).(*pdfStream)
+		if !ok {
+			continue
+		}
+		data, err := d.decodeStream(s)
+		if err != nil {
+			continue
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
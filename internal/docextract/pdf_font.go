@@ -0,0 +1,313 @@
+package docextract
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// cmapRange ToUnicode 中的 bfrange 映射
+type cmapRange struct {
+	lo, hi uint32
+	size   int
+	dst    []byte   // 起始目标（UTF-16BE），按偏移递增
+	list   []string // 数组形式的目标，逐个对应
+}
+
+// codespace 编码空间范围，决定每个字符编码的字节数
+type codespace struct {
+	lo, hi uint32
+	size   int
+}
+
+// toUnicode 解析后的 ToUnicode CMap
+type toUnicode struct {
+	spaces []codespace
+	chars  map[string]string // 原始编码字节 -> 文本
+	ranges []cmapRange
+}
+
+// parseToUnicode 解析 ToUnicode CMap 流
+func parseToUnicode(data []byte) *toUnicode {
+	cm := &toUnicode{chars: make(map[string]string)}
+	l := newLexer(data)
+
+	var operands []interface{}
+	for {
+		obj, err := l.object()
+		if err != nil {
+			break
+		}
+		kw, ok := obj.(pdfKeyword)
+		if !ok {
+			operands = append(operands, obj)
+			continue
+		}
+		switch kw {
+		case "endcodespacerange":
+			for i := 0; i+1 < len(operands); i += 2 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 && len(lo) == len(hi) && len(lo) > 0 && len(lo) <= 4 {
+					cm.spaces = append(cm.spaces, codespace{lo: codeValue(lo), hi: codeValue(hi), size: len(lo)})
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(pdfString)
+				if !ok1 {
+					continue
+				}
+				switch dst := operands[i+1].(type) {
+				case pdfString:
+					cm.chars[string(src)] = decodeUTF16BE(dst)
+				case pdfName:
+					cm.chars[string(src)] = glyphText(string(dst))
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if !ok1 || !ok2 || len(lo) != len(hi) || len(lo) == 0 || len(lo) > 4 {
+					continue
+				}
+				r := cmapRange{lo: codeValue(lo), hi: codeValue(hi), size: len(lo)}
+				switch dst := operands[i+2].(type) {
+				case pdfString:
+					r.dst = dst
+				case pdfArray:
+					for _, v := range dst {
+						s, _ := v.(pdfString)
+						r.list = append(r.list, decodeUTF16BE(s))
+					}
+				default:
+					continue
+				}
+				if r.hi >= r.lo {
+					cm.ranges = append(cm.ranges, r)
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+	return cm
+}
+
+// lookup 查找单个编码对应的文本
+func (cm *toUnicode) lookup(code []byte) (string, bool) {
+	if s, ok := cm.chars[string(code)]; ok {
+		return s, true
+	}
+	v := codeValue(code)
+	for _, r := range cm.ranges {
+		if r.size != len(code) || v < r.lo || v > r.hi {
+			continue
+		}
+		offset := v - r.lo
+		if r.list != nil {
+			if int(offset) < len(r.list) {
+				return r.list[offset], true
+			}
+			return "", false
+		}
+		// 目标的最后一个字节随编码递增
+		dst := append([]byte(nil), r.dst...)
+		if len(dst) == 0 {
+			return "", false
+		}
+		carry := offset
+		for i := len(dst) - 1; i >= 0 && carry > 0; i-- {
+			sum := uint32(dst[i]) + carry
+			dst[i] = byte(sum)
+			carry = sum >> 8
+		}
+		return decodeUTF16BE(dst), true
+	}
+	return "", false
+}
+
+// codeLength 根据编码空间确定 data 开头的编码长度，没有匹配时返回 0
+func (cm *toUnicode) codeLength(data []byte) int {
+	for n := 1; n <= 4 && n <= len(data); n++ {
+		v := codeValue(data[:n])
+		for _, cs := range cm.spaces {
+			if cs.size == n && v >= cs.lo && v <= cs.hi {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+func codeValue(b []byte) uint32 {
+	var v uint32
+	for _, c := range b {
+		v = v<<8 | uint32(c)
+	}
+	return v
+}
+
+// decodeUTF16BE 解码 UTF-16BE 字节
+func decodeUTF16BE(b []byte) string {
+	if len(b)%2 == 1 {
+		b = append(b, 0)
+	}
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// pdfFont 文本提取所需的字体信息
+type pdfFont struct {
+	cmap      *toUnicode
+	twoByte   bool          // Type0 复合字体，默认双字节编码
+	ucs2      bool          // 预定义的 Unicode CMap（如 UniGB-UCS2-H），编码本身即 UTF-16BE
+	encoding  map[byte]rune // /Differences 覆盖的单字节编码
+	identityH bool          // Identity-H 且无 ToUnicode，编码为字形ID，无法还原文本
+}
+
+// loadFont 读取字体字典
+func (d *pdfDoc) loadFont(v interface{}) *pdfFont {
+	font := &pdfFont{}
+	dict := d.dict(v)
+	if dict == nil {
+		return font
+	}
+
+	if s, ok := d.resolve(dict["ToUnicode"]).(*pdfStream); ok {
+		if data, err := d.decodeStream(s); err == nil {
+			font.cmap = parseToUnicode(data)
+		}
+	}
+
+	if d.name(dict["Subtype"]) == "Type0" {
+		font.twoByte = true
+		enc := string(d.name(dict["Encoding"]))
+		switch {
+		case strings.Contains(enc, "UCS2") || strings.Contains(enc, "UTF16"):
+			font.ucs2 = true
+		case font.cmap == nil:
+			font.identityH = true
+		}
+		return font
+	}
+
+	if enc := d.dict(dict["Encoding"]); enc != nil {
+		if diffs := d.array(enc["Differences"]); diffs != nil {
+			font.encoding = make(map[byte]rune)
+			code := 0
+			for _, item := range diffs {
+				switch t := d.resolve(item).(type) {
+				case int64:
+					code = int(t)
+				case pdfName:
+					if code >= 0 && code < 256 {
+						if s := glyphText(string(t)); s != "" {
+							r, _ := utf8.DecodeRuneInString(s)
+							font.encoding[byte(code)] = r
+						}
+					}
+					code++
+				}
+			}
+		}
+	}
+	return font
+}
+
+// decode 将字符串操作数转换为文本
+func (f *pdfFont) decode(s pdfString) string {
+	if f.identityH {
+		return ""
+	}
+	if f.ucs2 && f.cmap == nil {
+		return decodeUTF16BE(s)
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		n := 1
+		if f.twoByte {
+			n = 2
+		}
+		if f.cmap != nil {
+			if l := f.cmap.codeLength(s[i:]); l > 0 {
+				n = l
+			}
+		}
+		if i+n > len(s) {
+			n = len(s) - i
+		}
+		code := s[i : i+n]
+		i += n
+
+		if f.cmap != nil {
+			if text, ok := f.cmap.lookup(code); ok {
+				sb.WriteString(text)
+				continue
+			}
+			if f.twoByte {
+				sb.WriteRune(utf8.RuneError)
+				continue
+			}
+		}
+		if f.twoByte {
+			sb.WriteString(decodeUTF16BE(code))
+			continue
+		}
+		if r, ok := f.encoding[code[0]]; ok {
+			sb.WriteRune(r)
+			continue
+		}
+		sb.WriteRune(latinRune(code[0]))
+	}
+	return sb.String()
+}
+
+// winAnsiHigh WinAnsiEncoding 中 0x80-0x9F 的字符
+var winAnsiHigh = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+	0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž', 0x91: '‘', 0x92: '’',
+	0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—', 0x99: '™', 0x9A: 'š',
+	0x9B: '›', 0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+}
+
+// latinRune 单字节字体无 ToUnicode 时按 WinAnsi/Latin-1 近似解码
+func latinRune(b byte) rune {
+	if r, ok := winAnsiHigh[b]; ok {
+		return r
+	}
+	return rune(b)
+}
+
+// glyphNames 常用字形名到文本的映射
+var glyphNames = map[string]string{
+	"space": " ", "period": ".", "comma": ",", "colon": ":", "semicolon": ";",
+	"hyphen": "-", "endash": "–", "emdash": "—", "parenleft": "(", "parenright": ")",
+	"slash": "/", "at": "@", "ampersand": "&", "percent": "%", "plus": "+",
+	"quoteright": "’", "quoteleft": "‘", "quotedbl": "\"", "quotesingle": "'",
+	"bullet": "•", "underscore": "_", "numbersign": "#", "question": "?", "exclam": "!",
+	"zero": "0", "one": "1", "two": "2", "three": "3", "four": "4",
+	"five": "5", "six": "6", "seven": "7", "eight": "8", "nine": "9",
+	"fi": "fi", "fl": "fl",
+}
+
+// glyphText 将字形名转换为文本（单字母名、uniXXXX 和常用符号名）
+func glyphText(name string) string {
+	if s, ok := glyphNames[name]; ok {
+		return s
+	}
+	if len(name) == 1 {
+		return name
+	}
+	if strings.HasPrefix(name, "uni") && len(name) >= 7 {
+		if v, err := strconv.ParseUint(name[3:7], 16, 32); err == nil {
+			return string(rune(v))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,341 @@
+package docextract
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+)
+
+// PDF 对象类型
+type (
+	pdfName   string
+	pdfArray  []interface{}
+	pdfDict   map[pdfName]interface{}
+	pdfString []byte
+	pdfRef    struct{ num, gen int }
+	// pdfKeyword 裸关键字（obj、stream、R 以及内容流中的操作符）
+	pdfKeyword string
+	// pdfStream 流对象，data 为未解码的原始数据
+	pdfStream struct {
+		dict pdfDict
+		data []byte
+	}
+)
+
+// errEOF 输入结束
+var errEOF = errors.New("unexpected end of PDF data")
+
+// maxNesting 数组/字典最大嵌套深度，防止恶意文件导致栈溢出
+const maxNesting = 64
+
+// lexer PDF 词法/语法分析器
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+func newLexer(data []byte) *lexer {
+	return &lexer{data: data}
+}
+
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// skipSpace 跳过空白和注释
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isWhitespace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		return
+	}
+}
+
+// token 读取一个词法单元：分隔符以字符串形式返回，其余为对应的 PDF 对象
+func (l *lexer) token() (interface{}, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, errEOF
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '[' || c == ']' || c == '{' || c == '}':
+		l.pos++
+		return string(c), nil
+	case c == '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return "<<", nil
+		}
+		return l.hexString(), nil
+	case c == '>':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '>' {
+			l.pos += 2
+			return ">>", nil
+		}
+		l.pos++
+		return ">", nil
+	case c == '(':
+		return l.literalString(), nil
+	case c == '/':
+		return l.name(), nil
+	case c == ')':
+		l.pos++
+		return ")", nil
+	}
+
+	start := l.pos
+	for l.pos < len(l.data) && !isWhitespace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	word := string(l.data[start:l.pos])
+	if word == "" {
+		// 无法识别的字符，跳过
+		l.pos++
+		return pdfKeyword(""), nil
+	}
+	if n, ok := parseNumber(word); ok {
+		return n, nil
+	}
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	return pdfKeyword(word), nil
+}
+
+// parseNumber 解析整数或实数
+func parseNumber(word string) (interface{}, bool) {
+	c := word[0]
+	if !(c >= '0' && c <= '9') && c != '-' && c != '+' && c != '.' {
+		return nil, false
+	}
+	if i, err := strconv.ParseInt(word, 10, 64); err == nil {
+		return i, true
+	}
+	if f, err := strconv.ParseFloat(word, 64); err == nil {
+		return f, true
+	}
+	return nil, false
+}
+
+// name 读取名称对象（处理 #xx 转义）
+func (l *lexer) name() pdfName {
+	l.pos++ // '/'
+	var b []byte
+	for l.pos < len(l.data) && !isWhitespace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		c := l.data[l.pos]
+		if c == '#' && l.pos+2 < len(l.data) {
+			if v, err := strconv.ParseUint(string(l.data[l.pos+1:l.pos+3]), 16, 8); err == nil {
+				b = append(b, byte(v))
+				l.pos += 3
+				continue
+			}
+		}
+		b = append(b, c)
+		l.pos++
+	}
+	return pdfName(b)
+}
+
+// hexString 读取十六进制字符串 <...>
+func (l *lexer) hexString() pdfString {
+	l.pos++ // '<'
+	var out []byte
+	var hi byte
+	half := false
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		if c == '>' {
+			break
+		}
+		v, ok := hexValue(c)
+		if !ok {
+			continue
+		}
+		if half {
+			out = append(out, hi<<4|v)
+		} else {
+			hi = v
+		}
+		half = !half
+	}
+	if half {
+		out = append(out, hi<<4)
+	}
+	return pdfString(out)
+}
+
+func hexValue(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// literalString 读取字面字符串 (...)，处理嵌套括号和转义
+func (l *lexer) literalString() pdfString {
+	l.pos++ // '('
+	var out []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return pdfString(out)
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return pdfString(out)
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				// 续行
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+			continue
+		}
+		out = append(out, c)
+	}
+	return pdfString(out)
+}
+
+// object 读取一个完整对象（数组、字典或间接引用 "n g R"）
+func (l *lexer) object() (interface{}, error) {
+	return l.objectDepth(0)
+}
+
+func (l *lexer) objectDepth(depth int) (interface{}, error) {
+	if depth > maxNesting {
+		return nil, errors.New("PDF object nesting too deep")
+	}
+	tok, err := l.token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case string:
+		switch t {
+		case "[":
+			var arr pdfArray
+			for {
+				l.skipSpace()
+				if l.pos < len(l.data) && l.data[l.pos] == ']' {
+					l.pos++
+					return arr, nil
+				}
+				v, err := l.objectDepth(depth + 1)
+				if err != nil {
+					return arr, err
+				}
+				if s, ok := v.(string); ok && (s == "]" || s == ">>") {
+					return arr, nil
+				}
+				arr = append(arr, v)
+			}
+		case "<<":
+			dict := pdfDict{}
+			for {
+				key, err := l.token()
+				if err != nil {
+					return dict, err
+				}
+				if key == ">>" {
+					return dict, nil
+				}
+				name, ok := key.(pdfName)
+				if !ok {
+					continue
+				}
+				v, err := l.objectDepth(depth + 1)
+				if err != nil {
+					return dict, err
+				}
+				if v == ">>" {
+					return dict, nil
+				}
+				dict[name] = v
+			}
+		}
+		return t, nil
+	case int64:
+		// 可能是间接引用 "num gen R"
+		save := l.pos
+		if gen, err := l.token(); err == nil {
+			if g, ok := gen.(int64); ok {
+				if kw, err := l.token(); err == nil && kw == pdfKeyword("R") {
+					return pdfRef{num: int(t), gen: int(g)}, nil
+				}
+			}
+		}
+		l.pos = save
+		return t, nil
+	}
+	return tok, nil
+}
+
+// hasPrefixAt 判断当前位置（跳过空白后）是否以指定关键字开头
+func (l *lexer) hasPrefixAt(keyword string) bool {
+	l.skipSpace()
+	return bytes.HasPrefix(l.data[l.pos:], []byte(keyword))
+}
@@ -0,0 +1,267 @@
+package docextract
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxFormDepth Form XObject 最大嵌套深度
+const maxFormDepth = 5
+
+// extractPDF 提取PDF文本层，页与页之间以换页符分隔，返回文本和页数
+func extractPDF(data []byte) (string, int, error) {
+	doc, err := loadPDF(data)
+	if err != nil {
+		return "", 0, err
+	}
+
+	pages := doc.pages()
+	texts := make([]string, 0, len(pages))
+	for _, page := range pages {
+		w := &textWriter{doc: doc, fonts: make(map[pdfName]*pdfFont)}
+		w.run(doc.contents(page.dict), page.resources, 0)
+		texts = append(texts, strings.TrimSpace(w.sb.String()))
+	}
+	return strings.Join(texts, "\f"), len(pages), nil
+}
+
+// textWriter 解释内容流中的文本操作符并输出文本
+// 只跟踪判断换行/空格所需的文本位置，不计算字形宽度
+type textWriter struct {
+	doc   *pdfDoc
+	fonts map[pdfName]*pdfFont
+	sb    strings.Builder
+
+	font    *pdfFont
+	leading float64
+	// 文本行矩阵的缩放和平移分量
+	lineA, lineD float64
+	lineX, lineY float64
+	lastY        float64
+	hasText      bool
+	moved        bool // 自上次输出后文本位置在同一行内右移
+}
+
+func (w *textWriter) run(content []byte, resources pdfDict, depth int) {
+	fontRes := w.doc.dict(resources["Font"])
+	xobjects := w.doc.dict(resources["XObject"])
+
+	l := newLexer(content)
+	var operands []interface{}
+	for {
+		obj, err := l.object()
+		if err != nil {
+			return
+		}
+		op, ok := obj.(pdfKeyword)
+		if !ok {
+			if len(operands) < 64 {
+				operands = append(operands, obj)
+			}
+			continue
+		}
+
+		switch op {
+		case "BT":
+			w.lineA, w.lineD, w.lineX, w.lineY = 1, 1, 0, 0
+		case "Tf":
+			if len(operands) >= 1 {
+				if name, ok := operands[0].(pdfName); ok {
+					w.font = w.loadFont(fontRes, name)
+				}
+			}
+		case "TL":
+			if len(operands) >= 1 {
+				w.leading = number(operands[0])
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				tx, ty := number(operands[0]), number(operands[1])
+				if op == "TD" {
+					w.leading = -ty
+				}
+				w.moveTo(w.lineX+tx*w.lineA, w.lineY+ty*w.lineD)
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				w.lineA, w.lineD = number(operands[0]), number(operands[3])
+				if w.lineA == 0 {
+					w.lineA = 1
+				}
+				if w.lineD == 0 {
+					w.lineD = 1
+				}
+				w.moveTo(number(operands[4]), number(operands[5]))
+			}
+		case "T*":
+			w.nextLine()
+		case "Tj":
+			if len(operands) >= 1 {
+				w.show(operands[0])
+			}
+		case "'":
+			w.nextLine()
+			if len(operands) >= 1 {
+				w.show(operands[0])
+			}
+		case "\"":
+			w.nextLine()
+			if len(operands) >= 3 {
+				w.show(operands[2])
+			}
+		case "TJ":
+			if len(operands) >= 1 {
+				if arr, ok := operands[0].(pdfArray); ok {
+					for _, item := range arr {
+						if n, ok := item.(int64); ok && n < -200 {
+							w.space()
+						} else if f, ok := item.(float64); ok && f < -200 {
+							w.space()
+						}
+						w.show(item)
+					}
+				}
+			}
+		case "Do":
+			if len(operands) >= 1 && depth < maxFormDepth {
+				if name, ok := operands[0].(pdfName); ok {
+					w.form(xobjects[name], resources, depth)
+				}
+			}
+		case "BI":
+			skipInlineImage(l)
+		}
+		operands = operands[:0]
+	}
+}
+
+// form 处理 Form XObject（部分生成器把正文放在表单中）
+func (w *textWriter) form(v interface{}, parentRes pdfDict, depth int) {
+	s, ok := w.doc.resolve(v).(*pdfStream)
+	if !ok || w.doc.name(s.dict["Subtype"]) != "Form" {
+		return
+	}
+	data, err := w.doc.decodeStream(s)
+	if err != nil {
+		return
+	}
+	resources := parentRes
+	if r := w.doc.dict(s.dict["Resources"]); r != nil {
+		resources = r
+	}
+	// 表单有独立的字体命名空间
+	saved := w.fonts
+	w.fonts = make(map[pdfName]*pdfFont)
+	w.run(data, resources, depth+1)
+	w.fonts = saved
+}
+
+func (w *textWriter) loadFont(fontRes pdfDict, name pdfName) *pdfFont {
+	if f, ok := w.fonts[name]; ok {
+		return f
+	}
+	var f *pdfFont
+	if fontRes != nil {
+		f = w.doc.loadFont(fontRes[name])
+	} else {
+		f = &pdfFont{}
+	}
+	w.fonts[name] = f
+	return f
+}
+
+func (w *textWriter) nextLine() {
+	leading := w.leading
+	if leading == 0 {
+		leading = 12
+	}
+	w.moveTo(w.lineX, w.lineY-leading*w.lineD)
+}
+
+// moveTo 移动文本行起点；纵向变化视为换行，同一行内右移视为词间隔
+func (w *textWriter) moveTo(x, y float64) {
+	if w.hasText && math.Abs(y-w.lastY) > 1 {
+		w.newline()
+	} else if x > w.lineX {
+		w.moved = true
+	}
+	w.lineX, w.lineY = x, y
+}
+
+func (w *textWriter) newline() {
+	text := w.sb.String()
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		w.sb.WriteByte('\n')
+	}
+	w.moved = false
+}
+
+// space 在拉丁文字之间插入空格（中文之间不需要）
+func (w *textWriter) space() {
+	text := w.sb.String()
+	if text == "" {
+		return
+	}
+	if r, _ := utf8.DecodeLastRuneInString(text); unicode.IsSpace(r) || unicode.Is(unicode.Han, r) {
+		return
+	}
+	w.sb.WriteByte(' ')
+}
+
+func (w *textWriter) show(v interface{}) {
+	s, ok := v.(pdfString)
+	if !ok || len(s) == 0 {
+		return
+	}
+	font := w.font
+	if font == nil {
+		font = &pdfFont{}
+	}
+	text := font.decode(s)
+	if text == "" {
+		return
+	}
+
+	if w.hasText && w.moved {
+		w.space()
+	}
+	w.moved = false
+	w.sb.WriteString(text)
+	w.hasText = true
+	w.lastY = w.lineY
+}
+
+func number(v interface{}) float64 {
+	switch n := v.(type) {
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
+
+// skipInlineImage 跳过内联图像数据（BI ... ID <二进制> EI）
+func skipInlineImage(l *lexer) {
+	idx := bytes.Index(l.data[l.pos:], []byte("ID"))
+	if idx < 0 {
+		l.pos = len(l.data)
+		return
+	}
+	l.pos += idx + 2
+	for l.pos < len(l.data) {
+		idx := bytes.Index(l.data[l.pos:], []byte("EI"))
+		if idx < 0 {
+			l.pos = len(l.data)
+			return
+		}
+		end := l.pos + idx
+		l.pos = end + 2
+		if end > 0 && isWhitespace(l.data[end-1]) && (l.pos >= len(l.data) || isWhitespace(l.data[l.pos])) {
+			return
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package filestore
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// IsDataURL 判断引用是否为 data: URL
+func IsDataURL(ref string) bool {
+	return len(ref) > 5 && strings.EqualFold(ref[:5], "data:")
+}
+
+// DecodeDataURL 解码 base64 编码的 data: URL（如 data:image/png;base64,...），返回声明的类型和内容
+// maxSize 大于0时，内容超过该大小返回 ErrTooLarge（解码前按编码长度预先判断）
+func DecodeDataURL(ref string, maxSize int64) (string, []byte, error) {
+	if !IsDataURL(ref) {
+		return "", nil, fmt.Errorf("不是data URL")
+	}
+	meta, payload, ok := strings.Cut(ref[5:], ",")
+	if !ok {
+		return "", nil, fmt.Errorf("data URL格式不正确：缺少逗号分隔的内容")
+	}
+
+	params := strings.Split(meta, ";")
+	declared := strings.ToLower(strings.TrimSpace(params[0]))
+	base64Encoded := false
+	for _, p := range params[1:] {
+		if strings.EqualFold(strings.TrimSpace(p), "base64") {
+			base64Encoded = true
+		}
+	}
+	if !base64Encoded {
+		return "", nil, fmt.Errorf("data URL只支持base64编码")
+	}
+
+	// 去除换行等空白字符（部分客户端会按行折叠base64）
+	payload = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\n' || r == '\r' || r == '\t' {
+			return -1
+		}
+		return r
+	}, payload)
+	if maxSize > 0 && int64(base64.StdEncoding.DecodedLen(len(payload))) > maxSize+2 {
+		return "", nil, fmt.Errorf("%w: 约 %d 字节，上限 %d 字节", ErrTooLarge, base64.StdEncoding.DecodedLen(len(payload)), maxSize)
+	}
+
+	data, err := decodeBase64(payload)
+	if err != nil {
+		return "", nil, fmt.Errorf("data URL的base64内容无效: %w", err)
+	}
+	if len(data) == 0 {
+		return "", nil, fmt.Errorf("data URL内容为空")
+	}
+	if maxSize > 0 && int64(len(data)) > maxSize {
+		return "", nil, fmt.Errorf("%w: %d 字节，上限 %d 字节", ErrTooLarge, len(data), maxSize)
+	}
+	return declared, data, nil
+}
+
+// decodeBase64 依次尝试标准和URL安全的base64编码（带或不带填充）
+func decodeBase64(payload string) ([]byte, error) {
+	var firstErr error
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		data, err := enc.DecodeString(payload)
+		if err == nil {
+			return data, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
@@ -0,0 +1,46 @@
+package filestore
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodeDataURL(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString(testPDF)
+	// 折行的base64和大小写不同的前缀也能解析
+	folded := encoded[:10] + "\n" + encoded[10:]
+	declared, data, err := DecodeDataURL("DATA:application/pdf;base64,"+folded, 1024)
+	if err != nil || declared != "application/pdf" || !bytes.Equal(data, testPDF) {
+		t.Fatalf("decode = %q %q %v", declared, data, err)
+	}
+
+	raw := base64.RawURLEncoding.EncodeToString([]byte{0xfb, 0xff, 0x01})
+	if _, data, err := DecodeDataURL("data:;base64,"+raw, 0); err != nil || len(data) != 3 {
+		t.Fatalf("raw url encoding = %v %v", data, err)
+	}
+}
+
+func TestDecodeDataURL_Rejects(t *testing.T) {
+	big := "data:image/png;base64," + base64.StdEncoding.EncodeToString(make([]byte, 2048))
+	if _, _, err := DecodeDataURL(big, 1024); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("too large = %v", err)
+	}
+
+	for _, ref := range []string{
+		"data:text/plain,hello",      // 非base64
+		"data:image/png;base64",      // 缺少内容
+		"data:image/png;base64,!!!",  // 无效base64
+		"data:image/png;base64,",     // 空内容
+		"https://example.com/cv.pdf", // 不是data URL
+	} {
+		if _, _, err := DecodeDataURL(ref, 1024); err == nil {
+			t.Errorf("DecodeDataURL(%q) = nil error", ref)
+		}
+	}
+	if !IsDataURL("data:image/png;base64,AAAA") || IsDataURL("file-abc") || IsDataURL(strings.Repeat("d", 3)) {
+		t.Fatalf("IsDataURL mismatch")
+	}
+}
@@ -0,0 +1,49 @@
+package filestore
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// 需要额外识别的文档类型
+const (
+	MimeDOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	MimeDOC  = "application/msword"
+)
+
+// oleMagic 旧版 Office 复合文档（.doc/.xls/.ppt）文件头
+var oleMagic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// DetectType 按文件内容识别MIME类型
+// 在 http.DetectContentType 基础上识别 Word 文档：docx 检查压缩包中的 word/document.xml，doc 按复合文档文件头和扩展名判断
+func DetectType(data []byte, filename string) string {
+	mimeType := http.DetectContentType(data)
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+
+	switch {
+	case mimeType == "application/zip" && isDOCX(data):
+		return MimeDOCX
+	case bytes.HasPrefix(data, oleMagic) && strings.EqualFold(filepath.Ext(filename), ".doc"):
+		return MimeDOC
+	}
+	return mimeType
+}
+
+// isDOCX 判断 zip 内容是否为 Word 文档
+func isDOCX(data []byte) bool {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return false
+	}
+	for _, f := range r.File {
+		if f.Name == "word/document.xml" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,272 @@
+package filestore
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"qd-sc/internal/config"
+	"qd-sc/internal/model"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IDPrefix 文件ID前缀
+const IDPrefix = "file-"
+
+// DefaultPurpose 未指定用途时的默认值
+const DefaultPurpose = "user_data"
+
+var (
+	// ErrNotFound 文件不存在或已过期
+	ErrNotFound = errors.New("文件不存在或已过期")
+	// ErrTooLarge 文件超过大小上限
+	ErrTooLarge = errors.New("文件超过大小上限")
+	// ErrUnsupportedType 文件类型不在允许范围内
+	ErrUnsupportedType = errors.New("不支持的文件类型")
+)
+
+// IsFileID 判断引用是否为上传文件ID
+func IsFileID(ref string) bool {
+	return strings.HasPrefix(ref, IDPrefix) && len(ref) > len(IDPrefix) && !strings.ContainsAny(ref, "/\\.")
+}
+
+// Store 本地上传文件存储（带TTL过期和后台清理）
+// 文件内容保存在本地目录，元数据保存在内存中；服务重启后未过期的文件在清理时按修改时间删除
+type Store struct {
+	dir          string
+	maxSize      int64
+	allowedTypes map[string]bool
+	ttl          time.Duration
+
+	mu       sync.RWMutex
+	files    map[string]*model.UploadedFile
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	now func() time.Time // 便于测试注入
+}
+
+// New 创建本地文件存储
+// cleanupInterval 大于0时启动后台清理过期文件
+func New(cfg *config.FilesConfig) (*Store, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("创建文件存储目录失败: %w", err)
+	}
+
+	types := make(map[string]bool, len(cfg.AllowedTypes))
+	for _, t := range cfg.AllowedTypes {
+		types[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+
+	s := &Store{
+		dir:          cfg.Dir,
+		maxSize:      cfg.MaxSize,
+		allowedTypes: types,
+		ttl:          cfg.TTL,
+		files:        make(map[string]*model.UploadedFile),
+		stop:         make(chan struct{}),
+		now:          time.Now,
+	}
+	if cfg.CleanupInterval > 0 {
+		go s.cleanupLoop(cfg.CleanupInterval)
+	}
+	return s, nil
+}
+
+// MaxSize 单个文件大小上限（字节）
+func (s *Store) MaxSize() int64 {
+	return s.maxSize
+}
+
+// Save 校验并保存文件，返回文件信息
+// 文件类型按内容识别，不信任客户端声明的类型
+func (s *Store) Save(filename, purpose string, data []byte) (*model.UploadedFile, error) {
+	mimeType, err := s.Validate(filename, data)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(s.path(id), data, 0o600); err != nil {
+		return nil, fmt.Errorf("保存文件失败: %w", err)
+	}
+
+	if purpose == "" {
+		purpose = DefaultPurpose
+	}
+	now := s.now()
+	file := &model.UploadedFile{
+		ID:        id,
+		Object:    "file",
+		Bytes:     int64(len(data)),
+		CreatedAt: now.Unix(),
+		ExpiresAt: now.Add(s.ttl).Unix(),
+		Filename:  filepath.Base(filename),
+		Purpose:   purpose,
+		MimeType:  mimeType,
+	}
+
+	s.mu.Lock()
+	s.files[id] = file
+	s.mu.Unlock()
+
+	log.Printf("文件已上传: id=%s 类型=%s 大小=%d字节", id, mimeType, len(data))
+	copied := *file
+	return &copied, nil
+}
+
+// Validate 校验文件大小和类型（按内容识别），返回识别出的MIME类型
+func (s *Store) Validate(filename string, data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", fmt.Errorf("文件内容为空")
+	}
+	if s.maxSize > 0 && int64(len(data)) > s.maxSize {
+		return "", fmt.Errorf("%w: %d 字节，上限 %d 字节", ErrTooLarge, len(data), s.maxSize)
+	}
+	mimeType := DetectType(data, filename)
+	if !s.allowedTypes[mimeType] {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedType, mimeType)
+	}
+	return mimeType, nil
+}
+
+// Get 获取文件信息，不存在或已过期时返回 ErrNotFound
+func (s *Store) Get(id string) (*model.UploadedFile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	file, ok := s.files[id]
+	if !ok || s.expired(file) {
+		return nil, ErrNotFound
+	}
+	copied := *file
+	return &copied, nil
+}
+
+// Read 读取文件信息和内容
+func (s *Store) Read(id string) (*model.UploadedFile, []byte, error) {
+	file, err := s.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	return file, data, nil
+}
+
+// Delete 删除文件，不存在时返回 ErrNotFound
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	file, ok := s.files[id]
+	delete(s.files, id)
+	s.mu.Unlock()
+
+	if !ok {
+		return ErrNotFound
+	}
+	if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("删除文件失败: %w", err)
+	}
+	if s.expired(file) {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Close 停止后台清理
+func (s *Store) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	return nil
+}
+
+// path 文件在本地目录中的路径
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id)
+}
+
+// expired 判断文件是否过期
+func (s *Store) expired(file *model.UploadedFile) bool {
+	return s.ttl > 0 && s.now().Unix() >= file.ExpiresAt
+}
+
+// cleanupLoop 定期清理过期文件
+func (s *Store) cleanupLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.cleanup()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// cleanup 删除过期文件，以及目录中没有元数据且超过保留时间的文件（服务重启前上传的）
+func (s *Store) cleanup() {
+	s.mu.Lock()
+	var expired []string
+	for id, file := range s.files {
+		if s.expired(file) {
+			expired = append(expired, id)
+			delete(s.files, id)
+		}
+	}
+	known := make(map[string]bool, len(s.files))
+	for id := range s.files {
+		known[id] = true
+	}
+	s.mu.Unlock()
+
+	for _, id := range expired {
+		if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("删除过期文件失败 [%s]: %v", id, err)
+		}
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		log.Printf("读取文件存储目录失败: %v", err)
+		return
+	}
+	orphans := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !IsFileID(entry.Name()) || known[entry.Name()] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || s.now().Sub(info.ModTime()) < s.ttl {
+			continue
+		}
+		if err := os.Remove(s.path(entry.Name())); err == nil {
+			orphans++
+		}
+	}
+	if len(expired) > 0 || orphans > 0 {
+		log.Printf("已清理过期上传文件: %d 个，遗留文件: %d 个", len(expired), orphans)
+	}
+}
+
+// newID 生成随机文件ID
+func newID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成文件ID失败: %w", err)
+	}
+	return IDPrefix + hex.EncodeToString(buf), nil
+}
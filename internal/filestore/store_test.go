@@ -0,0 +1,124 @@
+package filestore
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"qd-sc/internal/config"
+)
+
+var testPDF = []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\n")
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := New(&config.FilesConfig{
+		Dir:          t.TempDir(),
+		MaxSize:      1024,
+		AllowedTypes: []string{"application/pdf", "image/png", MimeDOCX},
+		TTL:          time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func docxBytes(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, _ := w.Create("word/document.xml")
+	f.Write([]byte("<w:document/>"))
+	if err := w.Close(); err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestStore_SaveAndRead(t *testing.T) {
+	s := newTestStore(t)
+
+	// 客户端声明的文件名扩展名不影响识别结果
+	file, err := s.Save("../resume.png", "", testPDF)
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if !IsFileID(file.ID) || file.MimeType != "application/pdf" || file.Filename != "resume.png" || file.Purpose != DefaultPurpose || file.Bytes != int64(len(testPDF)) {
+		t.Fatalf("unexpected file: %+v", file)
+	}
+
+	meta, data, err := s.Read(file.ID)
+	if err != nil || !bytes.Equal(data, testPDF) || meta.ID != file.ID {
+		t.Fatalf("read: %v %+v", err, meta)
+	}
+
+	if err := s.Delete(file.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := s.Get(file.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("get after delete = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(s.dir, file.ID)); !os.IsNotExist(err) {
+		t.Fatalf("file not removed from disk: %v", err)
+	}
+}
+
+func TestStore_RejectsTooLargeAndUnsupported(t *testing.T) {
+	s := newTestStore(t)
+
+	if _, err := s.Save("big.pdf", "", append(testPDF, make([]byte, 1024)...)); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("too large = %v", err)
+	}
+	if _, err := s.Save("resume.pdf", "", []byte("#!/bin/sh\nrm -rf /\n")); !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("unsupported = %v", err)
+	}
+	if file, err := s.Save("resume.docx", "", docxBytes(t)); err != nil || file.MimeType != MimeDOCX {
+		t.Fatalf("docx = %v %+v", err, file)
+	}
+}
+
+func TestStore_ExpiresAndCleansUp(t *testing.T) {
+	s := newTestStore(t)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	file, err := s.Save("cv.pdf", "", testPDF)
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	// 服务重启前遗留的文件（没有元数据）
+	orphan := filepath.Join(s.dir, IDPrefix+"orphan")
+	if err := os.WriteFile(orphan, testPDF, 0o600); err != nil {
+		t.Fatalf("write orphan: %v", err)
+	}
+
+	s.now = func() time.Time { return now.Add(2 * time.Hour) }
+	if _, err := s.Get(file.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expired file still readable: %v", err)
+	}
+	s.cleanup()
+
+	entries, _ := os.ReadDir(s.dir)
+	if len(entries) != 0 {
+		t.Fatalf("cleanup left %d files", len(entries))
+	}
+}
+
+func TestIsFileID(t *testing.T) {
+	for ref, want := range map[string]bool{
+		"file-3f2a9c1b":             true,
+		"file-":                     false,
+		"file-../../etc/passwd":     false,
+		"https://example.com/a.pdf": false,
+	} {
+		if got := IsFileID(ref); got != want {
+			t.Errorf("IsFileID(%q) = %v, want %v", ref, got, want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package grounding
+
+import (
+	"qd-sc/internal/model"
+	"regexp"
+	"strings"
+)
+
+// sentenceEndPattern 中文句末标点（用于单行文本按句删除）
+var sentenceEndPattern = regexp.MustCompile(`[^。！？!?]*[。！？!?]?`)
+
+// unverifiedMark 标注未核实内容的后缀
+const unverifiedMark = "（未核实）"
+
+// FlagNotice flag 模式下追加在回复末尾的说明
+const FlagNotice = "\n\n> 注：标注“未核实”的信息未出现在本次岗位查询结果中，请以岗位卡片中的信息为准。"
+
+// StrippedFallback strip 模式下删除后没有剩余内容时使用的回复
+const StrippedFallback = "抱歉，相关岗位信息未能在查询结果中核实，请以岗位卡片中的信息为准。"
+
+// Strip 删除包含不可信事实的句子（逐行处理，整行都不可信时删除整行）
+func Strip(text string, unsupported []model.GroundingClaim) string {
+	if len(unsupported) == 0 {
+		return text
+	}
+
+	containsUnsupported := func(s string) bool {
+		for _, claim := range unsupported {
+			if strings.Contains(s, claim.Text) {
+				return true
+			}
+		}
+		return false
+	}
+
+	lines := strings.Split(text, "\n")
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if !containsUnsupported(line) {
+			kept = append(kept, line)
+			continue
+		}
+		// 同一行中不含不可信事实的句子仍然保留
+		var sentences []string
+		for _, sentence := range sentenceEndPattern.FindAllString(line, -1) {
+			if sentence != "" && !containsUnsupported(sentence) {
+				sentences = append(sentences, sentence)
+			}
+		}
+		if rest := strings.TrimSpace(strings.Join(sentences, "")); rest != "" {
+			kept = append(kept, rest)
+		}
+	}
+
+	result := strings.TrimSpace(strings.Join(kept, "\n"))
+	if result == "" {
+		return StrippedFallback
+	}
+	return result
+}
+
+// Flag 在不可信事实后标注“未核实”，并在末尾追加说明
+func Flag(text string, unsupported []model.GroundingClaim) string {
+	if len(unsupported) == 0 {
+		return text
+	}
+	for _, claim := range unsupported {
+		text = strings.ReplaceAll(text, claim.Text, claim.Text+unverifiedMark)
+	}
+	return text + FlagNotice
+}
+
+// ClaimTexts 拼接事实文本，用于日志和重新生成提示
+func ClaimTexts(claims []model.GroundingClaim) string {
+	texts := make([]string, 0, len(claims))
+	for _, claim := range claims {
+		texts = append(texts, claim.Text)
+	}
+	return strings.Join(texts, "、")
+}
@@ -0,0 +1,234 @@
+package grounding
+
+import (
+	"qd-sc/internal/model"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 事实类型
+const (
+	KindCompany = "company"
+	KindTitle   = "title"
+	KindSalary  = "salary"
+	KindURL     = "url"
+)
+
+// 处理方式
+const (
+	ModeStrip      = "strip"      // 删除包含不可信事实的行/句
+	ModeFlag       = "flag"       // 标注“未核实”
+	ModeRegenerate = "regenerate" // 要求模型依据工具结果重新回答
+)
+
+var (
+	urlPattern = regexp.MustCompile(`https?://[^\s<>"'()（）\[\]「」]+`)
+	// salaryPattern 薪资范围，如 8000-12000元/月、8k-12k、1.2万~1.5万
+	salaryPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*([kK千万]?)\s*[-~～到至]\s*(\d+(?:\.\d+)?)\s*([kK千万]?)(\s*元)?`)
+	// companyFieldPattern 带字段名的公司名称
+	companyFieldPattern = regexp.MustCompile(`(?:公司名称|招聘单位|公司|企业|单位)\s*[：:]\s*\**([^\s，,。；;|｜*]+)`)
+	// companyNamePattern 带法定后缀的公司全称
+	companyNamePattern = regexp.MustCompile(`[\p{Han}A-Za-z0-9（）()·]{2,30}?(?:有限责任公司|股份有限公司|有限公司)`)
+	// titleFieldPattern 带字段名的岗位名称
+	titleFieldPattern = regexp.MustCompile(`(?:岗位名称|职位名称|岗位|职位)\s*[：:]\s*\**([^\s，,。；;|｜*]+)`)
+)
+
+// trailingPunct URL末尾常见的标点
+const trailingPunct = ".,;:!?。，；：！？、"
+
+// companyLeadWords 公司全称前常见的连接词，匹配时会被一并带入，需要去掉
+var companyLeadWords = []string{"另外", "此外", "还有", "以及", "其中", "例如", "比如", "包括", "推荐", "和", "与", "及", "在", "是", "由", "如"}
+
+// trimLeadWords 去掉公司名称前的连接词
+func trimLeadWords(name string) string {
+	for trimmed := true; trimmed; {
+		trimmed = false
+		for _, w := range companyLeadWords {
+			if rest := strings.TrimPrefix(name, w); rest != name && len([]rune(rest)) >= 4 {
+				name, trimmed = rest, true
+			}
+		}
+	}
+	return name
+}
+
+// Verifier 岗位事实核验器
+// 保存本次请求中工具返回的岗位记录，核验回复中的公司、岗位、薪资和链接是否来自这些记录
+type Verifier struct {
+	jobs []model.FormattedJob
+}
+
+// NewVerifier 创建核验器
+func NewVerifier(jobs ...model.FormattedJob) *Verifier {
+	return &Verifier{jobs: append([]model.FormattedJob(nil), jobs...)}
+}
+
+// Add 添加工具返回的岗位记录
+func (v *Verifier) Add(jobs ...model.FormattedJob) {
+	v.jobs = append(v.jobs, jobs...)
+}
+
+// HasRecords 是否已有可用于核验的岗位记录
+func (v *Verifier) HasRecords() bool {
+	return len(v.jobs) > 0
+}
+
+// Verify 核验文本中的岗位事实
+func (v *Verifier) Verify(text string) model.GroundingVerdict {
+	claims := ExtractClaims(text)
+	verdict := model.GroundingVerdict{Checked: len(claims)}
+	for _, claim := range claims {
+		if !v.supported(claim) {
+			verdict.Unsupported = append(verdict.Unsupported, claim)
+		}
+	}
+	verdict.Grounded = len(verdict.Unsupported) == 0
+	return verdict
+}
+
+// supported 判断事实能否在岗位记录中找到
+func (v *Verifier) supported(claim model.GroundingClaim) bool {
+	for _, job := range v.jobs {
+		switch claim.Kind {
+		case KindURL:
+			if job.AppJobURL != "" && strings.TrimRight(job.AppJobURL, "/") == strings.TrimRight(claim.Text, "/") {
+				return true
+			}
+		case KindSalary:
+			if sameSalary(claim.Text, job.Salary) {
+				return true
+			}
+		case KindCompany:
+			if fuzzyMatch(claim.Text, job.CompanyName) {
+				return true
+			}
+		case KindTitle:
+			if fuzzyMatch(claim.Text, job.JobTitle) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ExtractClaims 提取文本中的岗位事实（去重）
+func ExtractClaims(text string) []model.GroundingClaim {
+	var claims []model.GroundingClaim
+	seen := make(map[string]bool)
+	add := func(kind, value string) {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[kind+"\x00"+value] {
+			return
+		}
+		seen[kind+"\x00"+value] = true
+		claims = append(claims, model.GroundingClaim{Kind: kind, Text: value})
+	}
+
+	for _, u := range urlPattern.FindAllString(text, -1) {
+		add(KindURL, strings.TrimRight(u, trailingPunct))
+	}
+	// 移除URL，避免其中的数字被当作薪资
+	withoutURLs := urlPattern.ReplaceAllString(text, " ")
+
+	for _, m := range salaryPattern.FindAllStringSubmatch(withoutURLs, -1) {
+		// 必须带单位（k/千/万 或 元），排除“2-3年”这类数字范围
+		if m[2] == "" && m[4] == "" && m[5] == "" {
+			continue
+		}
+		add(KindSalary, m[0])
+	}
+	for _, m := range companyFieldPattern.FindAllStringSubmatch(withoutURLs, -1) {
+		add(KindCompany, m[1])
+	}
+	for _, name := range companyNamePattern.FindAllString(withoutURLs, -1) {
+		name = trimLeadWords(name)
+		if !seen[KindCompany+"\x00"+name] && !containsClaim(claims, KindCompany, name) {
+			add(KindCompany, name)
+		}
+	}
+	for _, m := range titleFieldPattern.FindAllStringSubmatch(withoutURLs, -1) {
+		add(KindTitle, m[1])
+	}
+	return claims
+}
+
+// containsClaim 是否已有包含该文本的同类事实
+func containsClaim(claims []model.GroundingClaim, kind, text string) bool {
+	for _, c := range claims {
+		if c.Kind == kind && (strings.Contains(c.Text, text) || strings.Contains(text, c.Text)) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize 统一大小写并去除空白和标点，用于名称比较
+func normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// fuzzyMatch 名称互相包含即视为同一实体（如“青岛啤酒”与“青岛啤酒股份有限公司”）
+func fuzzyMatch(claim, record string) bool {
+	c, r := normalize(claim), normalize(record)
+	if len([]rune(c)) < 2 || r == "" {
+		return false
+	}
+	return strings.Contains(r, c) || strings.Contains(c, r)
+}
+
+// parseSalary 解析薪资范围为元/月的上下限
+func parseSalary(text string) (float64, float64, bool) {
+	m := salaryPattern.FindStringSubmatch(text)
+	if m == nil {
+		return 0, 0, false
+	}
+	low, err1 := strconv.ParseFloat(m[1], 64)
+	high, err2 := strconv.ParseFloat(m[3], 64)
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	// “8-12k”：单位只写在上限时同样适用于下限
+	lowUnit, highUnit := m[2], m[4]
+	if lowUnit == "" {
+		lowUnit = highUnit
+	}
+	return low * unitScale(lowUnit), high * unitScale(highUnit), true
+}
+
+// unitScale 薪资单位换算
+func unitScale(unit string) float64 {
+	switch unit {
+	case "k", "K", "千":
+		return 1000
+	case "万":
+		return 10000
+	default:
+		return 1
+	}
+}
+
+// sameSalary 比较两个薪资范围是否一致
+func sameSalary(claim, record string) bool {
+	cl, ch, ok1 := parseSalary(claim)
+	rl, rh, ok2 := parseSalary(record)
+	if !ok1 || !ok2 {
+		return false
+	}
+	const tolerance = 1
+	return abs(cl-rl) <= tolerance && abs(ch-rh) <= tolerance
+}
+
+func abs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
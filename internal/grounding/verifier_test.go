@@ -0,0 +1,68 @@
+package grounding
+
+import (
+	"strings"
+	"testing"
+
+	"qd-sc/internal/model"
+)
+
+var testJobs = []model.FormattedJob{
+	{JobTitle: "Java开发工程师", CompanyName: "青岛软件园发展有限公司", Salary: "9000-15000元/月", AppJobURL: "https://jobs.example/1"},
+	{JobTitle: "Java实习生", CompanyName: "海洋科技", Salary: "薪资面议", AppJobURL: "https://jobs.example/2"},
+}
+
+func TestExtractClaims(t *testing.T) {
+	text := "岗位名称：Java开发工程师\n公司名称：青岛软件园\n薪资：9k-15k，要求2-3年经验\n另外海尔智家股份有限公司也在招聘，详见 https://jobs.example/1。"
+
+	got := make(map[string]string)
+	for _, c := range ExtractClaims(text) {
+		got[c.Text] = c.Kind
+	}
+	want := map[string]string{
+		"Java开发工程师":              KindTitle,
+		"青岛软件园":                  KindCompany,
+		"9k-15k":                 KindSalary,
+		"海尔智家股份有限公司":             KindCompany,
+		"https://jobs.example/1": KindURL,
+	}
+	for text, kind := range want {
+		if got[text] != kind {
+			t.Errorf("claim %q: kind = %q, want %q (all: %v)", text, got[text], kind, got)
+		}
+	}
+	if _, ok := got["2-3"]; ok {
+		t.Errorf("range without salary unit should not be a claim: %v", got)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	v := NewVerifier(testJobs...)
+
+	grounded := v.Verify("推荐青岛软件园的Java开发工程师，月薪9千-1.5万，链接 https://jobs.example/1/")
+	if !grounded.Grounded || grounded.Checked != 2 {
+		t.Fatalf("expected grounded verdict, got %+v", grounded)
+	}
+
+	verdict := v.Verify("公司名称：某某科技\n薪资：20k-30k\n链接：https://jobs.example/99")
+	if verdict.Grounded || len(verdict.Unsupported) != 3 {
+		t.Fatalf("expected 3 unsupported claims, got %+v", verdict)
+	}
+}
+
+func TestStripAndFlag(t *testing.T) {
+	unsupported := []model.GroundingClaim{{Kind: KindCompany, Text: "某某科技"}}
+	text := "青岛软件园正在招聘。某某科技也在招聘！\n公司名称：某某科技"
+
+	if got := Strip(text, unsupported); got != "青岛软件园正在招聘。" {
+		t.Fatalf("Strip() = %q", got)
+	}
+	if got := Strip("公司名称：某某科技", unsupported); got != StrippedFallback {
+		t.Fatalf("Strip() should fall back when nothing is left, got %q", got)
+	}
+
+	flagged := Flag(text, unsupported)
+	if strings.Count(flagged, "某某科技"+unverifiedMark) != 2 || !strings.HasSuffix(flagged, FlagNotice) {
+		t.Fatalf("Flag() = %q", flagged)
+	}
+}
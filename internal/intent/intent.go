@@ -0,0 +1,254 @@
+package intent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"qd-sc/internal/config"
+	"qd-sc/internal/model"
+	contentutils "qd-sc/internal/pkg/utils"
+	"qd-sc/internal/redact"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Label 意图标签
+type Label string
+
+const (
+	// LabelJobSearch 找工作、岗位推荐、招聘信息
+	LabelJobSearch Label = "job_search"
+	// LabelPolicy 就业创业政策、补贴、社保等咨询
+	LabelPolicy Label = "policy"
+	// LabelResumeReview 简历修改、评价、优化
+	LabelResumeReview Label = "resume_review"
+	// LabelChitchat 寒暄、闲聊、询问助手能力
+	LabelChitchat Label = "chitchat"
+	// LabelOther 其他
+	LabelOther Label = "other"
+)
+
+// Labels 全部意图标签
+var Labels = []Label{LabelJobSearch, LabelPolicy, LabelResumeReview, LabelChitchat, LabelOther}
+
+// Source 意图判定来源
+const (
+	SourceRules = "rules"
+	SourceLLM   = "llm"
+)
+
+// Input 意图识别输入
+type Input struct {
+	Text              string // 用户本轮输入的文字（不含文件解析内容）
+	HasResume         bool   // 本轮是否上传了简历
+	HasNonResumeImage bool   // 本轮是否上传了非简历格式的图片/文件
+}
+
+// Result 意图识别结果
+type Result struct {
+	Label      Label   `json:"label"`
+	Confidence float64 `json:"confidence"`
+	Source     string  `json:"source"`
+	Reason     string  `json:"reason,omitempty"`
+}
+
+// Completer 非流式聊天补全接口（LLM层使用，*client.LLMClient 满足该接口）
+type Completer interface {
+	ChatCompletion(req *model.ChatCompletionRequest) (*model.ChatCompletionResponse, error)
+}
+
+// Classifier 意图分类器
+// 先用本地规则判定，规则置信度不足且启用LLM层时，再进行一次简短的LLM调用
+type Classifier struct {
+	cfg           *config.IntentConfig
+	model         string
+	reasoningTags []string
+	completer     Completer
+	redactor      *redact.Redactor // 决策日志中的用户文本先脱敏
+
+	logMu   sync.Mutex
+	logFile *os.File
+}
+
+// NewClassifier 创建意图分类器
+// completer 为nil时只使用规则层
+func NewClassifier(cfg *config.Config, completer Completer) (*Classifier, error) {
+	c := &Classifier{
+		cfg:           &cfg.Intent,
+		model:         cfg.Intent.Model,
+		reasoningTags: cfg.LLM.ReasoningTags,
+		completer:     completer,
+		redactor:      redact.New(&cfg.Redaction),
+	}
+	if c.model == "" {
+		c.model = cfg.LLM.Model
+	}
+
+	if cfg.Intent.DecisionLog != "" {
+		f, err := os.OpenFile(cfg.Intent.DecisionLog, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("打开意图决策日志失败: %w", err)
+		}
+		c.logFile = f
+	}
+	return c, nil
+}
+
+// Close 关闭决策日志文件
+func (c *Classifier) Close() error {
+	if c.logFile != nil {
+		return c.logFile.Close()
+	}
+	return nil
+}
+
+// belowThreshold 规则置信度是否低于 intent.llm_threshold（未配置阈值时视为0，不调用LLM）
+func (c *Classifier) belowThreshold(confidence float64) bool {
+	return c.cfg.LLMThreshold != nil && confidence < *c.cfg.LLMThreshold
+}
+
+// Classify 识别用户意图，并记录决策日志
+func (c *Classifier) Classify(ctx context.Context, in Input) Result {
+	start := time.Now()
+
+	rulesResult := classifyByRules(in)
+	result := rulesResult
+	if c.cfg.LLMEnabled && c.completer != nil && c.belowThreshold(rulesResult.Confidence) && strings.TrimSpace(in.Text) != "" {
+		if llmResult, err := c.classifyByLLM(ctx, in); err != nil {
+			log.Printf("LLM意图识别失败，使用规则结果: %v", err)
+		} else {
+			result = llmResult
+		}
+	}
+
+	c.logDecision(in, rulesResult, result, time.Since(start))
+	return result
+}
+
+// intentSystemPrompt LLM意图分类提示词
+const intentSystemPrompt = `你是就业服务平台的意图分类器。请把用户消息归入以下标签之一：
+job_search：找工作、岗位推荐、查询招聘信息或薪资
+policy：就业创业政策、补贴、社保、人才等政策咨询
+resume_review：修改、评价、优化简历
+chitchat：寒暄、闲聊、询问助手是谁或能做什么
+other：其他
+只输出标签本身，不要输出任何解释。`
+
+// maxLLMInputRunes 发送给LLM分类的用户文本最大字符数
+const maxLLMInputRunes = 500
+
+// classifyByLLM 使用LLM进行一次简短的意图分类
+func (c *Classifier) classifyByLLM(ctx context.Context, in Input) (Result, error) {
+	text := []rune(in.Text)
+	if len(text) > maxLLMInputRunes {
+		text = text[:maxLLMInputRunes]
+	}
+
+	maxTokens := c.cfg.LLMMaxTokens
+	temperature := 0.0
+	req := &model.ChatCompletionRequest{
+		Model: c.model,
+		Messages: []model.Message{
+			{Role: "system", Content: intentSystemPrompt},
+			{Role: "user", Content: string(text)},
+		},
+		Temperature: &temperature,
+		MaxTokens:   &maxTokens,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.LLMTimeout)
+	defer cancel()
+
+	type completion struct {
+		resp *model.ChatCompletionResponse
+		err  error
+	}
+	done := make(chan completion, 1)
+	go func() {
+		resp, err := c.completer.ChatCompletion(req)
+		done <- completion{resp: resp, err: err}
+	}()
+
+	var resp *model.ChatCompletionResponse
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return Result{}, r.err
+		}
+		resp = r.resp
+	case <-ctx.Done():
+		// LLM客户端不支持context，超时后不再等待其返回
+		return Result{}, fmt.Errorf("LLM意图识别超时(%v): %w", c.cfg.LLMTimeout, ctx.Err())
+	}
+
+	if len(resp.Choices) == 0 {
+		return Result{}, fmt.Errorf("LLM意图识别返回为空")
+	}
+	content, _ := resp.Choices[0].Message.Content.(string)
+	label, ok := parseLabel(contentutils.FilterReasoningTags(content, c.reasoningTags))
+	if !ok {
+		return Result{}, fmt.Errorf("无法解析LLM意图标签: %q", content)
+	}
+	return Result{Label: label, Confidence: 0.8, Source: SourceLLM}, nil
+}
+
+// parseLabel 从LLM输出中解析意图标签
+func parseLabel(output string) (Label, bool) {
+	output = strings.ToLower(strings.TrimSpace(output))
+	for _, label := range Labels {
+		if strings.Contains(output, string(label)) {
+			return label, true
+		}
+	}
+	return "", false
+}
+
+// decisionRecord 意图决策日志记录（用于离线评估）
+type decisionRecord struct {
+	Time              time.Time `json:"time"`
+	Text              string    `json:"text"`
+	HasResume         bool      `json:"hasResume,omitempty"`
+	HasNonResumeImage bool      `json:"hasNonResumeImage,omitempty"`
+	Rules             Result    `json:"rules"`
+	Final             Result    `json:"final"`
+	LatencyMs         int64     `json:"latencyMs"`
+}
+
+// maxLoggedTextRunes 决策日志中记录的用户文本最大字符数
+const maxLoggedTextRunes = 200
+
+// logDecision 记录意图决策：标准日志一行摘要，配置了决策日志文件时追加一行JSON
+func (c *Classifier) logDecision(in Input, rules, final Result, latency time.Duration) {
+	log.Printf("意图识别: label=%s confidence=%.2f source=%s reason=%s 耗时=%v", final.Label, final.Confidence, final.Source, final.Reason, latency)
+
+	if c.logFile == nil {
+		return
+	}
+
+	// 先脱敏再截断，避免截断后的号码片段无法识别
+	text := []rune(c.redactor.String(in.Text))
+	if len(text) > maxLoggedTextRunes {
+		text = text[:maxLoggedTextRunes]
+	}
+	data, err := json.Marshal(decisionRecord{
+		Time:              time.Now(),
+		Text:              string(text),
+		HasResume:         in.HasResume,
+		HasNonResumeImage: in.HasNonResumeImage,
+		Rules:             rules,
+		Final:             final,
+		LatencyMs:         latency.Milliseconds(),
+	})
+	if err != nil {
+		return
+	}
+
+	c.logMu.Lock()
+	defer c.logMu.Unlock()
+	if _, err := c.logFile.Write(append(data, '\n')); err != nil {
+		log.Printf("写入意图决策日志失败: %v", err)
+	}
+}
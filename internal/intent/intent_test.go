@@ -0,0 +1,145 @@
+package intent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"qd-sc/internal/config"
+	"qd-sc/internal/model"
+)
+
+func TestClassifyByRules(t *testing.T) {
+	tests := []struct {
+		in   Input
+		want Label
+	}{
+		{Input{Text: "帮我推荐青岛的Java岗位"}, LabelJobSearch},
+		{Input{Text: "我想找份工作，最好离家近"}, LabelJobSearch},
+		{Input{Text: "有没有周末的兼职"}, LabelJobSearch},
+		{Input{Text: "再看看更多"}, LabelJobSearch},
+		{Input{Text: "换一批吧"}, LabelJobSearch},
+		{Input{Text: "工作日几点下班"}, LabelOther},
+		{Input{Text: "测试一下"}, LabelOther},
+		{Input{Text: "大学生创业有什么补贴"}, LabelPolicy},
+		{Input{Text: "找工作的话社保怎么交"}, LabelPolicy},
+		{Input{Text: "帮我看看简历有什么问题"}, LabelResumeReview},
+		{Input{Text: "你好"}, LabelChitchat},
+		{Input{Text: "你能做什么？"}, LabelChitchat},
+		{Input{Text: "", HasResume: true}, LabelJobSearch},
+		{Input{Text: "帮我优化一下简历", HasResume: true}, LabelResumeReview},
+		{Input{Text: "", HasNonResumeImage: true}, LabelOther},
+	}
+	for _, tt := range tests {
+		if got := classifyByRules(tt.in); got.Label != tt.want {
+			t.Errorf("classifyByRules(%+v) = %s (%s), want %s", tt.in, got.Label, got.Reason, tt.want)
+		}
+	}
+
+	if got := classifyByRules(Input{Text: "Java开发"}); got.Label != LabelJobSearch || got.Confidence >= strongConfidence {
+		t.Errorf("bare job title should be a weak job_search signal, got %+v", got)
+	}
+}
+
+// fakeCompleter 返回固定内容或错误的LLM
+type fakeCompleter struct {
+	content string
+	err     error
+	delay   time.Duration
+	calls   int
+}
+
+func (f *fakeCompleter) ChatCompletion(req *model.ChatCompletionRequest) (*model.ChatCompletionResponse, error) {
+	f.calls++
+	time.Sleep(f.delay)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &model.ChatCompletionResponse{Choices: []model.Choice{{Message: model.Message{Content: f.content}}}}, nil
+}
+
+func newTestClassifier(t *testing.T, completer Completer, decisionLog string) *Classifier {
+	t.Helper()
+	threshold := 0.7
+	cfg := &config.Config{Intent: config.IntentConfig{
+		LLMEnabled:   true,
+		LLMThreshold: &threshold,
+		LLMTimeout:   200 * time.Millisecond,
+		LLMMaxTokens: 16,
+		DecisionLog:  decisionLog,
+	}}
+	c, err := NewClassifier(cfg, completer)
+	if err != nil {
+		t.Fatalf("new classifier: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestClassifier_LLMTierOnlyForLowConfidence(t *testing.T) {
+	llm := &fakeCompleter{content: "<think>用户在问岗位</think>job_search"}
+	c := newTestClassifier(t, llm, "")
+
+	// 规则明确：不调用LLM
+	if got := c.Classify(context.Background(), Input{Text: "你好"}); got.Label != LabelChitchat || got.Source != SourceRules {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+	if llm.calls != 0 {
+		t.Fatalf("LLM should not be called for confident rules")
+	}
+
+	// 规则无法判断：调用LLM
+	got := c.Classify(context.Background(), Input{Text: "搬砖的活儿有吗"})
+	if got.Label != LabelJobSearch || got.Source != SourceLLM || llm.calls != 1 {
+		t.Fatalf("expected LLM job_search, got %+v (calls=%d)", got, llm.calls)
+	}
+}
+
+func TestClassifier_FallsBackToRulesOnLLMFailure(t *testing.T) {
+	for name, llm := range map[string]*fakeCompleter{
+		"error":   {err: errors.New("boom")},
+		"garbage": {content: "我不知道"},
+		"timeout": {content: "job_search", delay: time.Second},
+	} {
+		c := newTestClassifier(t, llm, "")
+		got := c.Classify(context.Background(), Input{Text: "搬砖的活儿有吗"})
+		if got.Source != SourceRules || got.Label != LabelOther {
+			t.Errorf("%s: expected rules fallback, got %+v", name, got)
+		}
+	}
+}
+
+func TestClassifier_WritesDecisionLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "intent.jsonl")
+	c := newTestClassifier(t, &fakeCompleter{content: "policy"}, path)
+
+	c.Classify(context.Background(), Input{Text: "帮我推荐岗位"})
+	c.Classify(context.Background(), Input{Text: "这个怎么申请"})
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open decision log: %v", err)
+	}
+	defer f.Close()
+
+	var records []decisionRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r decisionRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("invalid JSONL line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, r)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 decision records, got %d", len(records))
+	}
+	if records[1].Rules.Label != LabelOther || records[1].Final.Label != LabelPolicy || records[1].Final.Source != SourceLLM {
+		t.Fatalf("decision record should keep both rules and final result: %+v", records[1])
+	}
+}
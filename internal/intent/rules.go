@@ -0,0 +1,105 @@
+package intent
+
+import (
+	"regexp"
+	"strings"
+)
+
+// 规则置信度
+const (
+	strongConfidence  = 0.9 // 命中明确的意图表达
+	weakConfidence    = 0.5 // 只命中弱信号（如单独的职位名称、薪资词）
+	noMatchConfidence = 0.3
+)
+
+// negativePhrases 含有岗位相关字眼但并非找工作的常见表达，匹配前先移除
+var negativePhrases = []*regexp.Regexp{
+	regexp.MustCompile(`工作日|工作时间|上班时间|几点(上|下)班|工作量|工作总结|工作报告|工作汇报|工作计划|工作效率|工作压力`),
+	regexp.MustCompile(`(?i)测试一下|测一下|测试测试|^test$`),
+}
+
+// labelRule 意图规则：strong 命中即为明确意图，weak 只提供弱信号
+type labelRule struct {
+	label  Label
+	strong []*regexp.Regexp
+	weak   []*regexp.Regexp
+}
+
+// labelRules 按优先级排列的意图规则（同分时靠前者优先）
+var labelRules = []labelRule{
+	{
+		label: LabelResumeReview,
+		strong: []*regexp.Regexp{
+			regexp.MustCompile(`(修改|优化|完善|润色|评价|点评|评估|诊断|检查|看看|看一下|改改|改一下)[^。？?！!]{0,6}简历`),
+			regexp.MustCompile(`简历[^。？?！!]{0,8}(怎么样|如何|怎么写|有什么问题|哪里不好|建议|修改|优化|不足)`),
+		},
+	},
+	{
+		label: LabelPolicy,
+		strong: []*regexp.Regexp{
+			regexp.MustCompile(`政策|补贴|补助|津贴|社保|医保|公积金|落户|人才引进|职称|失业金|失业保险|创业贷|担保贷款|就业见习|见习补贴|技能培训|申领|申请条件`),
+		},
+	},
+	{
+		label: LabelJobSearch,
+		strong: []*regexp.Regexp{
+			regexp.MustCompile(`找(份|个|一份|一个)?(工作|活|兼职|实习)|求职|应聘|招聘|招人|岗位|职位|就业机会|工作机会`),
+			regexp.MustCompile(`推荐[^。？?！!]{0,8}(工作|岗位|职位|兼职|实习)`),
+			regexp.MustCompile(`(附近|周边|离我近|离家近)[^。？?！!]{0,6}(工作|上班|招工)`),
+			regexp.MustCompile(`(想|打算|准备)(从事|应聘|转行|换工作|上班)`),
+			regexp.MustCompile(`(有没有|有什么|哪些|哪里有)[^。？?！!]{0,8}(工作|兼职|实习)`),
+			// 翻看已推荐岗位的后续结果
+			regexp.MustCompile(`换一批|下一页|(再|多)(看看|看|来|推荐)(一些|几个|一批)?更多|再来(几个|一些|一批)`),
+		},
+		weak: []*regexp.Regexp{
+			regexp.MustCompile(`工作|上班|薪资|薪酬|工资|待遇|月薪|年薪|五险一金`),
+			regexp.MustCompile(`(?i)工程师|产品经理|设计师|运营|销售|会计|财务|前端|后端|全栈|java|python|golang|c\+\+|测试工程师|运维|司机|厨师|保安|保洁|服务员|普工|文员|教师|护士`),
+		},
+	},
+	{
+		label: LabelChitchat,
+		strong: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)^(你好|您好|hi|hello|hey|嗨|哈喽|在吗|在不在|早上好|下午好|晚上好|谢谢|多谢|感谢|再见|拜拜|好的|好|ok|嗯|嗯嗯|收到)[!！。.~～ ]*$`),
+			regexp.MustCompile(`你是谁|你叫什么|你能做什么|你会什么|你有什么功能|介绍一下你自己`),
+		},
+	},
+}
+
+// classifyByRules 本地规则意图识别
+func classifyByRules(in Input) Result {
+	text := strings.TrimSpace(in.Text)
+	for _, p := range negativePhrases {
+		text = p.ReplaceAllString(text, " ")
+	}
+	text = strings.TrimSpace(text)
+
+	// 明确的意图表达
+	for _, rule := range labelRules {
+		for _, p := range rule.strong {
+			if m := p.FindString(text); m != "" {
+				return Result{Label: rule.label, Confidence: strongConfidence, Source: SourceRules, Reason: "命中: " + m}
+			}
+		}
+	}
+
+	// 上传了非简历图片且没有明确需求：需要先询问用户意图
+	if in.HasNonResumeImage {
+		return Result{Label: LabelOther, Confidence: strongConfidence, Source: SourceRules, Reason: "上传了非简历图片"}
+	}
+
+	// 只上传简历、没有明确说明需求：默认按简历推荐岗位
+	if in.HasResume {
+		return Result{Label: LabelJobSearch, Confidence: 0.8, Source: SourceRules, Reason: "上传了简历"}
+	}
+
+	// 弱信号
+	for _, rule := range labelRules {
+		for _, p := range rule.weak {
+			if m := p.FindString(text); m != "" {
+				return Result{Label: rule.label, Confidence: weakConfidence, Source: SourceRules, Reason: "弱信号: " + m}
+			}
+		}
+	}
+
+	return Result{Label: LabelOther, Confidence: noMatchConfidence, Source: SourceRules, Reason: "未命中规则"}
+}
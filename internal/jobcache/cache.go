@@ -0,0 +1,100 @@
+package jobcache
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"qd-sc/internal/config"
+	"qd-sc/internal/model"
+	"strconv"
+	"strings"
+)
+
+// Cache 岗位查询结果缓存
+// 内存实现之外，可按此接口增加 Redis 等共享后端
+type Cache interface {
+	// Get 读取缓存的查询结果，不存在或已过期时返回 false
+	Get(ctx context.Context, key string) (*model.JobAPIResponse, bool, error)
+	// Set 保存查询结果
+	Set(ctx context.Context, key string, resp *model.JobAPIResponse) error
+	// Purge 清空缓存，返回清除的条目数
+	Purge(ctx context.Context) (int, error)
+	// Close 释放缓存资源
+	Close() error
+}
+
+// New 根据配置创建岗位查询缓存，未启用时返回 nil
+func New(cfg *config.JobCacheConfig) (Cache, error) {
+	if cfg.Enabled != nil && !*cfg.Enabled {
+		return nil, nil
+	}
+	switch cfg.Backend {
+	case "", "memory":
+		return NewMemoryCache(cfg.TTL, cfg.Size), nil
+	default:
+		return nil, fmt.Errorf("不支持的岗位缓存后端: %s", cfg.Backend)
+	}
+}
+
+// Key 将岗位查询请求规范化为缓存键
+// 空白、数字写法和默认值不同但语义相同的请求得到相同的键
+func Key(req *model.JobQueryRequest) string {
+	current, pageSize := req.Current, req.PageSize
+	if current < 1 {
+		current = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	order := strings.TrimSpace(req.Order)
+	if order == "" {
+		order = "0"
+	}
+
+	params := url.Values{}
+	params.Set("current", strconv.Itoa(current))
+	params.Set("pageSize", strconv.Itoa(pageSize))
+	params.Set("order", order)
+	set := func(name, value string) {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+	set("jobTitle", strings.Join(strings.Fields(req.JobTitle), " "))
+	set("latitude", canonicalNumber(req.Latitude, 6))
+	set("longitude", canonicalNumber(req.Longitude, 6))
+	set("radius", canonicalNumber(req.Radius, 3))
+	set("minSalary", canonicalNumber(req.MinSalary, 0))
+	set("maxSalary", canonicalNumber(req.MaxSalary, 0))
+	set("experience", strings.TrimSpace(req.Experience))
+	set("education", strings.TrimSpace(req.Education))
+	set("companyNature", strings.TrimSpace(req.CompanyNature))
+	set("jobLocationAreaCode", strings.TrimSpace(req.JobLocationAreaCode))
+
+	// Encode 按参数名排序，结果与字段顺序无关
+	return "jobs:" + params.Encode()
+}
+
+// canonicalNumber 数字按指定小数位统一写法（"10" 与 "10.0" 相同），非数字原样去除空白
+func canonicalNumber(value string, precision int) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value
+	}
+	s := strconv.FormatFloat(v, 'f', precision, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// clone 复制查询结果，调用方重排或修改岗位列表不影响缓存内容
+func clone(resp *model.JobAPIResponse) *model.JobAPIResponse {
+	c := *resp
+	c.Rows = append([]model.JobListing(nil), resp.Rows...)
+	return &c
+}
@@ -0,0 +1,103 @@
+package jobcache
+
+import (
+	"container/list"
+	"context"
+	"qd-sc/internal/model"
+	"sync"
+	"time"
+)
+
+// MemoryCache 进程内岗位查询缓存（LRU淘汰 + TTL过期）
+type MemoryCache struct {
+	mu         sync.Mutex
+	ll         *list.List // 最近使用的在前
+	items      map[string]*list.Element
+	ttl        time.Duration
+	maxEntries int
+
+	now func() time.Time // 便于测试注入
+}
+
+type memoryEntry struct {
+	key       string
+	resp      *model.JobAPIResponse
+	expiresAt time.Time
+}
+
+// NewMemoryCache 创建内存岗位缓存
+// maxEntries 小于等于0时不限制条目数，ttl 小于等于0时不过期
+func NewMemoryCache(ttl time.Duration, maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}
+}
+
+// Get 读取缓存，命中时刷新LRU位置
+func (c *MemoryCache) Get(ctx context.Context, key string) (*model.JobAPIResponse, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := el.Value.(*memoryEntry)
+	if c.ttl > 0 && c.now().After(entry.expiresAt) {
+		c.remove(el)
+		return nil, false, nil
+	}
+	c.ll.MoveToFront(el)
+	return clone(entry.resp), true, nil
+}
+
+// Set 保存查询结果，超出容量时淘汰最久未使用的条目
+func (c *MemoryCache) Set(ctx context.Context, key string, resp *model.JobAPIResponse) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &memoryEntry{key: key, resp: clone(resp), expiresAt: c.now().Add(c.ttl)}
+	if el, ok := c.items[key]; ok {
+		el.Value = entry
+		c.ll.MoveToFront(el)
+		return nil
+	}
+	c.items[key] = c.ll.PushFront(entry)
+
+	for c.maxEntries > 0 && c.ll.Len() > c.maxEntries {
+		c.remove(c.ll.Back())
+	}
+	return nil
+}
+
+// Purge 清空缓存
+func (c *MemoryCache) Purge(ctx context.Context) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := c.ll.Len()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	return n, nil
+}
+
+// Len 当前缓存条目数（含尚未清理的过期条目）
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// Close 内存缓存无需释放资源
+func (c *MemoryCache) Close() error {
+	return nil
+}
+
+func (c *MemoryCache) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*memoryEntry).key)
+}
@@ -0,0 +1,74 @@
+package jobcache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"qd-sc/internal/config"
+	"qd-sc/internal/model"
+)
+
+func TestKey_Canonicalizes(t *testing.T) {
+	a := Key(&model.JobQueryRequest{JobTitle: " Java  开发 ", Latitude: "36.0600", Longitude: "120.38", Radius: "10.0", Order: ""})
+	b := Key(&model.JobQueryRequest{Current: 1, PageSize: 10, JobTitle: "Java 开发", Latitude: "36.06", Longitude: "120.380000", Radius: "10", Order: "0"})
+	if a != b {
+		t.Fatalf("equivalent queries produced different keys:\n%s\n%s", a, b)
+	}
+	if Key(&model.JobQueryRequest{JobTitle: "Java", Current: 2}) == Key(&model.JobQueryRequest{JobTitle: "Java"}) {
+		t.Fatal("different pages must not share a key")
+	}
+	if Key(&model.JobQueryRequest{Education: "4"}) == Key(&model.JobQueryRequest{Experience: "4"}) {
+		t.Fatal("different fields must not share a key")
+	}
+}
+
+func TestMemoryCache_LRUAndTTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	c := NewMemoryCache(time.Minute, 2)
+	c.now = func() time.Time { return now }
+
+	resp := func(title string) *model.JobAPIResponse {
+		return &model.JobAPIResponse{Code: 200, Rows: []model.JobListing{{JobTitle: title}}}
+	}
+	c.Set(ctx, "a", resp("A"))
+	c.Set(ctx, "b", resp("B"))
+	c.Get(ctx, "a") // a 最近使用，b 被淘汰
+	c.Set(ctx, "c", resp("C"))
+
+	if _, ok, _ := c.Get(ctx, "b"); ok {
+		t.Fatal("least recently used entry should be evicted")
+	}
+	got, ok, _ := c.Get(ctx, "a")
+	if !ok || got.Rows[0].JobTitle != "A" {
+		t.Fatalf("a = %+v, %v", got, ok)
+	}
+
+	// 返回副本，修改不影响缓存
+	got.Rows[0].JobTitle = "changed"
+	if again, _, _ := c.Get(ctx, "a"); again.Rows[0].JobTitle != "A" {
+		t.Fatal("cached rows were modified through returned value")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok, _ := c.Get(ctx, "a"); ok {
+		t.Fatal("expired entry should miss")
+	}
+	if n, _ := c.Purge(ctx); n != 1 || c.Len() != 0 {
+		t.Fatalf("purge removed %d, remaining %d", n, c.Len())
+	}
+}
+
+func TestNew(t *testing.T) {
+	disabled := false
+	if c, err := New(&config.JobCacheConfig{Enabled: &disabled}); c != nil || err != nil {
+		t.Fatalf("disabled cache = %v, %v", c, err)
+	}
+	if _, err := New(&config.JobCacheConfig{Backend: "redis"}); err == nil {
+		t.Fatal("unsupported backend should fail")
+	}
+	if c, err := New(&config.JobCacheConfig{Backend: "memory", TTL: time.Minute}); err != nil || c == nil {
+		t.Fatalf("memory cache = %v, %v", c, err)
+	}
+}
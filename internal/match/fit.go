@@ -0,0 +1,170 @@
+package match
+
+import (
+	"fmt"
+	"math"
+	"qd-sc/internal/model"
+	"strings"
+)
+
+// 向量余弦相似度到名称相似度的映射区间：短文本的余弦值普遍偏高，低于下限视为不相关
+const (
+	cosineFloor = 0.5
+	cosineCeil  = 0.9
+)
+
+// educationRank 学历代码对应的层级（留学、MBA按对应国内学历计算）
+var educationRank = map[string]int{
+	"0": 0, "1": 1, "2": 1, "3": 2, "4": 3, "5": 4, "6": 5,
+	"7": 4, "8": 3, "9": 4, "10": 5,
+}
+
+// experienceMinYears 经验代码对应的最低工作年限
+var experienceMinYears = map[string]float64{
+	"3": 0, "4": 1, "5": 3, "6": 5, "7": 10,
+}
+
+// titleSimilarity 岗位名称相似度（0-1），有向量时取向量相似度与字符相似度的较大值
+func titleSimilarity(profileTitle, jobTitle string, vectors map[string][]float32) float64 {
+	jobTitle = strings.TrimSpace(jobTitle)
+	sim := stringSimilarity(profileTitle, jobTitle)
+	a, okA := vectors[profileTitle]
+	b, okB := vectors[jobTitle]
+	if okA && okB {
+		scaled := (cosine(a, b) - cosineFloor) / (cosineCeil - cosineFloor)
+		sim = math.Max(sim, math.Min(math.Max(scaled, 0), 1))
+	}
+	return sim
+}
+
+// cosine 向量余弦相似度
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// stringSimilarity 字符相似度：一方包含另一方时为0.9，否则为二元组Dice系数
+func stringSimilarity(a, b string) float64 {
+	a, b = strings.ToLower(strings.TrimSpace(a)), strings.ToLower(strings.TrimSpace(b))
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+	if strings.Contains(a, b) || strings.Contains(b, a) {
+		return 0.9
+	}
+
+	ga, gb := bigrams(a), bigrams(b)
+	if len(ga) == 0 || len(gb) == 0 {
+		return 0
+	}
+	var common int
+	for g, n := range ga {
+		if m, ok := gb[g]; ok {
+			common += min(n, m)
+		}
+	}
+	var total int
+	for _, n := range ga {
+		total += n
+	}
+	for _, n := range gb {
+		total += n
+	}
+	return 2 * float64(common) / float64(total)
+}
+
+// bigrams 按字符切分的二元组计数
+func bigrams(s string) map[string]int {
+	runes := []rune(s)
+	grams := make(map[string]int)
+	for i := 0; i+1 < len(runes); i++ {
+		grams[string(runes[i:i+2])]++
+	}
+	return grams
+}
+
+// educationFit 学历匹配度：达到要求为1，低一档为0.4，否则为0
+func educationFit(profileCode, jobCode string) (float64, string) {
+	required, ok := educationRank[jobCode]
+	if !ok {
+		// 学历不限或未知代码
+		return 1, ""
+	}
+	have, ok := educationRank[profileCode]
+	if !ok {
+		return 0.5, ""
+	}
+	switch {
+	case have >= required:
+		return 1, fmt.Sprintf("学历符合（要求%s）", model.EducationMap[jobCode])
+	case have == required-1:
+		return 0.4, ""
+	default:
+		return 0, ""
+	}
+}
+
+// experienceFit 经验匹配度：达到年限要求为1，差一年以内为0.5，否则为0
+// 实习生/应届岗位对工作多年的求职者记0.5
+func experienceFit(profile *model.ResumeProfile, jobCode string) (float64, string) {
+	years := profile.ExperienceYears
+	switch jobCode {
+	case "", "0":
+		return 1, ""
+	case "1", "2":
+		if years <= 1 {
+			return 1, fmt.Sprintf("经验符合（%s）", model.ExperienceMap[jobCode])
+		}
+		return 0.5, ""
+	}
+
+	required, ok := experienceMinYears[jobCode]
+	if !ok {
+		return 1, ""
+	}
+	switch {
+	case years >= required:
+		return 1, fmt.Sprintf("经验符合（要求%s）", model.ExperienceMap[jobCode])
+	case required-years <= 1:
+		return 0.5, ""
+	default:
+		return 0, ""
+	}
+}
+
+// salaryFit 薪资匹配度：岗位薪资区间与期望区间有交集为1，否则按差距递减
+// 岗位薪资面议时不参与计算（ok 为 false）
+func salaryFit(expectMin, expectMax, jobMin, jobMax int) (float64, string, bool) {
+	if jobMin <= 0 && jobMax <= 0 {
+		return 0, "", false
+	}
+	if jobMax <= 0 {
+		jobMax = jobMin
+	}
+
+	switch {
+	case expectMin > 0 && jobMax < expectMin:
+		// 岗位薪资低于期望
+		gap := float64(expectMin-jobMax) / float64(expectMin)
+		return math.Max(0, 1-2*gap), "", true
+	case expectMax > 0 && jobMin > expectMax:
+		// 岗位薪资高于期望上限，不扣分
+		return 1, "薪资高于期望", true
+	default:
+		return 1, "薪资符合期望", true
+	}
+}
@@ -0,0 +1,260 @@
+package match
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"qd-sc/internal/config"
+	"qd-sc/internal/model"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 各维度权重（画像缺少某一维度时该维度不参与计算，其余权重按比例放大）
+const (
+	weightTitle      = 40
+	weightEducation  = 20
+	weightExperience = 15
+	weightSalary     = 15
+	weightDistrict   = 10
+)
+
+// titleReasonThreshold 岗位名称相似度达到该值时给出推荐理由
+const titleReasonThreshold = 0.6
+
+// maxProfileTitles 参与名称相似度计算的画像职位数（求职意向 + 最近的职位）
+const maxProfileTitles = 3
+
+// maxCachedEmbeddings 向量缓存上限，超出后整体清空
+const maxCachedEmbeddings = 1024
+
+// embeddingConcurrency 单次排序同时请求Embedding服务的最大数量
+const embeddingConcurrency = 4
+
+// Embedder 文本向量接口（*client.EmbeddingClient 满足该接口）
+type Embedder interface {
+	GetEmbeddingContext(ctx context.Context, text string) ([]float32, error)
+}
+
+// Result 单个岗位的匹配结果
+type Result struct {
+	Index   int      // 岗位在原列表中的下标
+	Score   int      // 匹配度（0-100）
+	Reasons []string // 推荐理由
+}
+
+// Scorer 简历与岗位匹配评分器
+// 从岗位名称相似度、学历、经验、薪资期望和期望区域五个维度为岗位打分
+type Scorer struct {
+	enabled          bool
+	embedder         Embedder
+	embeddingTimeout time.Duration
+
+	mu    sync.Mutex
+	cache map[string][]float32 // 文本 -> 向量
+}
+
+// NewScorer 创建匹配评分器
+// embedder 为nil或未启用Embedding时，岗位名称相似度使用字符相似度
+func NewScorer(cfg *config.Config, embedder Embedder) *Scorer {
+	s := &Scorer{
+		enabled:          cfg.Match.Enabled != nil && *cfg.Match.Enabled,
+		embeddingTimeout: cfg.Match.EmbeddingTimeout,
+		cache:            make(map[string][]float32),
+	}
+	if cfg.Match.EmbeddingEnabled != nil && *cfg.Match.EmbeddingEnabled {
+		s.embedder = embedder
+	}
+	return s
+}
+
+// Enabled 是否启用匹配评分
+func (s *Scorer) Enabled() bool {
+	return s != nil && s.enabled
+}
+
+// Rank 为岗位打分并按匹配度从高到低排序（同分保持原顺序）
+// 未启用或画像为空时返回nil
+func (s *Scorer) Rank(ctx context.Context, profile *model.ResumeProfile, jobs []model.JobListing) []Result {
+	if !s.Enabled() || profile.IsEmpty() || len(jobs) == 0 {
+		return nil
+	}
+
+	titles := profileTitles(profile)
+	vectors := s.embed(ctx, titles, jobs)
+
+	results := make([]Result, len(jobs))
+	for i, job := range jobs {
+		results[i] = s.score(i, profile, titles, vectors, job)
+	}
+	sort.SliceStable(results, func(a, b int) bool {
+		return results[a].Score > results[b].Score
+	})
+	return results
+}
+
+// score 计算单个岗位的匹配度
+func (s *Scorer) score(index int, profile *model.ResumeProfile, titles []string, vectors map[string][]float32, job model.JobListing) Result {
+	var total, weights float64
+	var reasons []string
+	add := func(weight int, value float64, reason string) {
+		total += float64(weight) * value
+		weights += float64(weight)
+		if reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+
+	if len(titles) > 0 {
+		best, bestTitle := 0.0, ""
+		for _, title := range titles {
+			if sim := titleSimilarity(title, job.JobTitle, vectors); sim > best {
+				best, bestTitle = sim, title
+			}
+		}
+		reason := ""
+		if best >= titleReasonThreshold {
+			reason = fmt.Sprintf("岗位与「%s」相近", bestTitle)
+		}
+		add(weightTitle, best, reason)
+	}
+	if profile.Education != "" {
+		value, reason := educationFit(profile.Education, job.Education)
+		add(weightEducation, value, reason)
+	}
+	if profile.Experience != "" {
+		value, reason := experienceFit(profile, job.Experience)
+		add(weightExperience, value, reason)
+	}
+	if profile.ExpectedSalaryMin > 0 || profile.ExpectedSalaryMax > 0 {
+		if value, reason, ok := salaryFit(profile.ExpectedSalaryMin, profile.ExpectedSalaryMax, job.MinSalary, job.MaxSalary); ok {
+			add(weightSalary, value, reason)
+		}
+	}
+	if profile.PreferredAreaCode != "" {
+		if profile.PreferredAreaCode == strconv.Itoa(job.JobLocationAreaCode) {
+			add(weightDistrict, 1, fmt.Sprintf("位于期望区域（%s）", profile.PreferredDistrict))
+		} else {
+			add(weightDistrict, 0, "")
+		}
+	}
+
+	result := Result{Index: index, Reasons: reasons}
+	if weights > 0 {
+		result.Score = int(math.Round(total / weights * 100))
+	}
+	return result
+}
+
+// profileTitles 画像中参与名称相似度计算的职位（去重）
+func profileTitles(profile *model.ResumeProfile) []string {
+	seen := make(map[string]bool)
+	var titles []string
+	for _, title := range append([]string{profile.DesiredTitle}, profile.Titles...) {
+		title = strings.TrimSpace(title)
+		if title == "" || seen[title] {
+			continue
+		}
+		seen[title] = true
+		titles = append(titles, title)
+		if len(titles) >= maxProfileTitles {
+			break
+		}
+	}
+	return titles
+}
+
+// embed 并发获取画像职位和岗位名称的向量，超时或失败的文本不返回向量
+func (s *Scorer) embed(ctx context.Context, titles []string, jobs []model.JobListing) map[string][]float32 {
+	vectors := make(map[string][]float32)
+	if s.embedder == nil || len(titles) == 0 {
+		return vectors
+	}
+
+	var pending []string
+	seen := make(map[string]bool)
+	s.mu.Lock()
+	for _, text := range titles {
+		seen[text] = true
+	}
+	for _, job := range jobs {
+		seen[strings.TrimSpace(job.JobTitle)] = true
+	}
+	delete(seen, "")
+	for text := range seen {
+		if vec, ok := s.cache[text]; ok {
+			vectors[text] = vec
+		} else {
+			pending = append(pending, text)
+		}
+	}
+	s.mu.Unlock()
+	if len(pending) == 0 {
+		return vectors
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.embeddingTimeout)
+	defer cancel()
+
+	type embedding struct {
+		text   string
+		vector []float32
+		err    error
+	}
+	// 固定数量的worker依次取文本请求向量；超时后 cancel 中止进行中的请求，worker不再取新文本
+	texts := make(chan string, len(pending))
+	for _, text := range pending {
+		texts <- text
+	}
+	close(texts)
+	done := make(chan embedding, len(pending))
+	workers := embeddingConcurrency
+	if len(pending) < workers {
+		workers = len(pending)
+	}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for text := range texts {
+				if ctx.Err() != nil {
+					return
+				}
+				vec, err := s.embedder.GetEmbeddingContext(ctx, text)
+				done <- embedding{text: text, vector: vec, err: err}
+			}
+		}()
+	}
+
+	var failed int
+	for range pending {
+		select {
+		case e := <-done:
+			if e.err != nil {
+				failed++
+				continue
+			}
+			vectors[e.text] = e.vector
+			s.remember(e.text, e.vector)
+		case <-ctx.Done():
+			log.Printf("获取岗位名称向量超时(%v)，未返回的岗位使用字符相似度", s.embeddingTimeout)
+			return vectors
+		}
+	}
+	if failed > 0 {
+		log.Printf("获取岗位名称向量失败 %d/%d 条，失败的岗位使用字符相似度", failed, len(pending))
+	}
+	return vectors
+}
+
+// remember 缓存向量
+func (s *Scorer) remember(text string, vector []float32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.cache) >= maxCachedEmbeddings {
+		s.cache = make(map[string][]float32)
+	}
+	s.cache[text] = vector
+}
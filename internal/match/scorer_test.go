@@ -0,0 +1,187 @@
+package match
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"qd-sc/internal/config"
+	"qd-sc/internal/model"
+)
+
+func testConfig(embedding bool) *config.Config {
+	enabled := true
+	return &config.Config{Match: config.MatchConfig{
+		Enabled:          &enabled,
+		EmbeddingEnabled: &embedding,
+		EmbeddingTimeout: time.Second,
+	}}
+}
+
+// fakeEmbedder 按预设表返回向量，表中没有的文本返回错误
+type fakeEmbedder struct {
+	vectors map[string][]float32
+	calls   atomic.Int32
+}
+
+func (f *fakeEmbedder) GetEmbeddingContext(ctx context.Context, text string) ([]float32, error) {
+	f.calls.Add(1)
+	if vec, ok := f.vectors[text]; ok {
+		return vec, nil
+	}
+	return nil, errors.New("unknown text")
+}
+
+var testProfile = &model.ResumeProfile{
+	Education:         "4",
+	ExperienceYears:   4,
+	Experience:        "5",
+	DesiredTitle:      "Java开发工程师",
+	ExpectedSalaryMin: 8000,
+	ExpectedSalaryMax: 12000,
+	PreferredDistrict: "崂山区",
+	PreferredAreaCode: "3",
+}
+
+var testJobs = []model.JobListing{
+	{JobTitle: "餐厅服务员", Education: "-1", Experience: "0", MinSalary: 3500, MaxSalary: 4500, JobLocationAreaCode: 0},
+	{JobTitle: "后端研发", Education: "5", Experience: "6", MinSalary: 15000, MaxSalary: 20000, JobLocationAreaCode: 4},
+	{JobTitle: "Java开发", Education: "4", Experience: "5", MinSalary: 9000, MaxSalary: 13000, JobLocationAreaCode: 3},
+}
+
+func TestRank_OrdersByFit(t *testing.T) {
+	s := NewScorer(testConfig(false), nil)
+	results := s.Rank(context.Background(), testProfile, testJobs)
+	if len(results) != 3 {
+		t.Fatalf("got %d results", len(results))
+	}
+	if results[0].Index != 2 {
+		t.Fatalf("unexpected order: %+v", results)
+	}
+	best := results[0]
+	if best.Score < 90 || results[1].Score >= 50 {
+		t.Fatalf("unexpected scores: %+v", results)
+	}
+	reasons := strings.Join(best.Reasons, "|")
+	for _, want := range []string{"Java开发工程师", "学历符合", "经验符合", "薪资符合期望", "崂山区"} {
+		if !strings.Contains(reasons, want) {
+			t.Errorf("reasons %q missing %q", reasons, want)
+		}
+	}
+}
+
+func TestRank_UsesEmbeddingAndCache(t *testing.T) {
+	embedder := &fakeEmbedder{vectors: map[string][]float32{
+		"Java开发工程师": {1, 0},
+		"后端研发":      {0.95, 0.31},
+		"餐厅服务员":     {0, 1},
+	}}
+	s := NewScorer(testConfig(true), embedder)
+	jobs := testJobs[:2]
+	results := s.Rank(context.Background(), &model.ResumeProfile{DesiredTitle: "Java开发工程师"}, jobs)
+
+	// 字符相似度下“后端研发”与求职意向无关，向量相似度使其排在前面并给出理由
+	if results[0].Index != 1 || len(results[0].Reasons) != 1 {
+		t.Fatalf("embedding not used: %+v", results)
+	}
+	calls := embedder.calls.Load()
+	s.Rank(context.Background(), &model.ResumeProfile{DesiredTitle: "Java开发工程师"}, jobs)
+	if got := embedder.calls.Load(); got != calls {
+		t.Fatalf("expected cached vectors, got %d extra calls", got-calls)
+	}
+}
+
+func TestRank_FallsBackWhenEmbeddingFails(t *testing.T) {
+	s := NewScorer(testConfig(true), &fakeEmbedder{})
+	results := s.Rank(context.Background(), testProfile, testJobs)
+	if results[0].Index != 2 {
+		t.Fatalf("fallback ranking wrong: %+v", results)
+	}
+}
+
+func TestRank_SkipsWithoutProfile(t *testing.T) {
+	s := NewScorer(testConfig(false), nil)
+	if got := s.Rank(context.Background(), nil, testJobs); got != nil {
+		t.Fatalf("expected nil without profile, got %+v", got)
+	}
+	disabled := false
+	cfg := testConfig(false)
+	cfg.Match.Enabled = &disabled
+	if got := NewScorer(cfg, nil).Rank(context.Background(), testProfile, testJobs); got != nil {
+		t.Fatalf("expected nil when disabled, got %+v", got)
+	}
+}
+
+func TestSalaryFit(t *testing.T) {
+	cases := []struct {
+		name                 string
+		expectMin, expectMax int
+		jobMin, jobMax       int
+		want                 float64
+		wantOK               bool
+	}{
+		{"overlap", 8000, 12000, 9000, 13000, 1, true},
+		{"negotiable", 8000, 12000, 0, 0, 0, false},
+		{"slightly low", 8000, 0, 5000, 7000, 0.75, true},
+		{"far below", 8000, 12000, 2000, 3000, 0, true},
+		{"above", 8000, 12000, 15000, 20000, 1, true},
+	}
+	for _, c := range cases {
+		got, _, ok := salaryFit(c.expectMin, c.expectMax, c.jobMin, c.jobMax)
+		if ok != c.wantOK || got != c.want {
+			t.Errorf("%s: got %v/%v, want %v/%v", c.name, got, ok, c.want, c.wantOK)
+		}
+	}
+}
+
+// blockingEmbedder 阻塞到 ctx 取消，记录同时进行的最大请求数
+type blockingEmbedder struct {
+	active, peak, cancelled atomic.Int32
+}
+
+func (b *blockingEmbedder) GetEmbeddingContext(ctx context.Context, text string) ([]float32, error) {
+	n := b.active.Add(1)
+	defer b.active.Add(-1)
+	for {
+		old := b.peak.Load()
+		if n <= old || b.peak.CompareAndSwap(old, n) {
+			break
+		}
+	}
+	<-ctx.Done()
+	b.cancelled.Add(1)
+	return nil, ctx.Err()
+}
+
+func TestRank_BoundsAndCancelsEmbeddingCalls(t *testing.T) {
+	cfg := testConfig(true)
+	cfg.Match.EmbeddingTimeout = 50 * time.Millisecond
+	embedder := &blockingEmbedder{}
+	s := NewScorer(cfg, embedder)
+
+	var jobs []model.JobListing
+	for i := 0; i < 10; i++ {
+		jobs = append(jobs, model.JobListing{JobTitle: "岗位" + strings.Repeat("A", i)})
+	}
+	if results := s.Rank(context.Background(), testProfile, jobs); len(results) != len(jobs) {
+		t.Fatalf("expected fallback results for all jobs, got %d", len(results))
+	}
+
+	// 超时后进行中的请求全部被取消，且不会再发起新的请求
+	deadline := time.Now().Add(time.Second)
+	for embedder.active.Load() > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if active := embedder.active.Load(); active != 0 {
+		t.Fatalf("%d embedding calls still running after timeout", active)
+	}
+	if peak := embedder.peak.Load(); peak > embeddingConcurrency {
+		t.Fatalf("peak concurrency %d exceeds %d", peak, embeddingConcurrency)
+	}
+	if got := embedder.cancelled.Load(); got != embeddingConcurrency {
+		t.Fatalf("expected %d cancelled calls, got %d", embeddingConcurrency, got)
+	}
+}
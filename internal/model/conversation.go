@@ -0,0 +1,70 @@
+package model
+
+import "time"
+
+// Conversation 服务端会话
+type Conversation struct {
+	ID             string             `json:"id"`
+	Token          string             `json:"token,omitempty"`          // 会话令牌，创建时签发给客户端，不随会话详情返回
+	Messages       []Message          `json:"messages"`                 // 历史消息（用户消息中的文件已解析为文本）
+	ToolResults    []ToolResultRecord `json:"toolResults,omitempty"`    // 工具调用记录
+	Resume         string             `json:"resume,omitempty"`         // 最近一次上传并解析出的简历内容
+	Profile        *ResumeProfile     `json:"profile,omitempty"`        // 从最近一次简历中提取的求职者画像
+	LastJobQueries []JobQueryRequest  `json:"lastJobQueries,omitempty"` // 最近一次岗位查询条件（多区域/多岗位名称时为多条，页码为已展示的最后一页），用于继续翻页
+	CreatedAt      time.Time          `json:"createdAt"`
+	UpdatedAt      time.Time          `json:"updatedAt"`
+	ExpiresAt      time.Time          `json:"expiresAt"`
+}
+
+// ToolResultRecord 工具调用记录
+type ToolResultRecord struct {
+	ToolCallID string    `json:"toolCallId"`
+	Name       string    `json:"name"`
+	Arguments  string    `json:"arguments"`
+	Result     string    `json:"result"`
+	IsError    bool      `json:"isError,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// ConversationSummary 会话摘要（用于列表展示）
+type ConversationSummary struct {
+	ID           string    `json:"id"`
+	MessageCount int       `json:"messageCount"`
+	HasResume    bool      `json:"hasResume"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+// Summary 生成会话摘要
+func (c *Conversation) Summary() ConversationSummary {
+	return ConversationSummary{
+		ID:           c.ID,
+		MessageCount: len(c.Messages),
+		HasResume:    c.Resume != "",
+		CreatedAt:    c.CreatedAt,
+		UpdatedAt:    c.UpdatedAt,
+		ExpiresAt:    c.ExpiresAt,
+	}
+}
+
+// ForClient 返回可以发给客户端的副本：去掉会话令牌和简历原文
+func (c *Conversation) ForClient() *Conversation {
+	clone := c.Clone()
+	clone.Token = ""
+	clone.Resume = ""
+	return clone
+}
+
+// Clone 深拷贝会话，避免存储层与调用方共享切片
+func (c *Conversation) Clone() *Conversation {
+	if c == nil {
+		return nil
+	}
+	clone := *c
+	clone.Messages = append([]Message(nil), c.Messages...)
+	clone.ToolResults = append([]ToolResultRecord(nil), c.ToolResults...)
+	clone.Profile = c.Profile.Clone()
+	clone.LastJobQueries = append([]JobQueryRequest(nil), c.LastJobQueries...)
+	return &clone
+}
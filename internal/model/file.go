@@ -0,0 +1,13 @@
+package model
+
+// UploadedFile 上传文件信息（OpenAI Files API 兼容格式）
+type UploadedFile struct {
+	ID        string `json:"id"`         // 文件ID，如 file-3f2a9c1b...
+	Object    string `json:"object"`     // 固定为 file
+	Bytes     int64  `json:"bytes"`      // 文件大小（字节）
+	CreatedAt int64  `json:"created_at"` // 上传时间（Unix秒）
+	ExpiresAt int64  `json:"expires_at"` // 过期时间（Unix秒）
+	Filename  string `json:"filename"`   // 原始文件名
+	Purpose   string `json:"purpose"`    // 用途，默认 user_data
+	MimeType  string `json:"mime_type"`  // 按文件内容识别的类型
+}
@@ -0,0 +1,15 @@
+package model
+
+// GroundingClaim 回复中提到的一条岗位事实
+type GroundingClaim struct {
+	Kind string `json:"kind"` // company / title / salary / url
+	Text string `json:"text"`
+}
+
+// GroundingVerdict 岗位事实核验结果
+type GroundingVerdict struct {
+	Grounded    bool             `json:"grounded"`              // 所有事实都能在工具结果中找到
+	Checked     int              `json:"checked"`               // 核验的事实数量
+	Unsupported []GroundingClaim `json:"unsupported,omitempty"` // 无法在工具结果中找到的事实
+	Action      string           `json:"action,omitempty"`      // 对不可信内容的处理方式：strip / flag / regenerate
+}
@@ -1,5 +1,11 @@
 package model
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // JobQueryRequest 岗位查询请求
 type JobQueryRequest struct {
 	Current             int    `json:"current" form:"current"`                                   // 当前页码
@@ -15,44 +21,170 @@ type JobQueryRequest struct {
 	Education           string `json:"education,omitempty" form:"education"`                     // 学历要求代码
 	CompanyNature       string `json:"companyNature,omitempty" form:"companyNature"`             // 企业类型代码
 	JobLocationAreaCode string `json:"jobLocationAreaCode,omitempty" form:"jobLocationAreaCode"` // 区域代码
+
+	// 通勤条件：以 latitude、longitude 为出发地计算到各岗位的通勤时间，不传给岗位API
+	CommuteMode       string `json:"commuteMode,omitempty" form:"commuteMode"`             // 通勤方式: transit-公交地铁, driving-驾车, walking-步行
+	MaxCommuteMinutes int    `json:"maxCommuteMinutes,omitempty" form:"maxCommuteMinutes"` // 最长通勤时间（分钟），超过的岗位不返回
+	SortByCommute     bool   `json:"sortByCommute,omitempty" form:"sortByCommute"`         // 按通勤时间从短到长排序
+}
+
+// HasCommute 是否需要计算通勤时间
+func (r *JobQueryRequest) HasCommute() bool {
+	return r.CommuteMode != "" || r.MaxCommuteMinutes > 0 || r.SortByCommute
 }
 
 // JobAPIResponse 岗位API响应
 type JobAPIResponse struct {
-	Code int          `json:"code"`
-	Msg  string       `json:"msg"`
-	Rows []JobListing `json:"rows"`
-	Data interface{}  `json:"data,omitempty"`
+	Code  int          `json:"code"`
+	Msg   string       `json:"msg"`
+	Total int          `json:"total,omitempty"` // 符合条件的岗位总数（接口未返回时为0）
+	Rows  []JobListing `json:"rows"`
+	Data  interface{}  `json:"data,omitempty"`
 }
 
 // JobListing 岗位信息
 type JobListing struct {
-	JobTitle            string `json:"jobTitle"`            // 职位名称
-	CompanyName         string `json:"companyName"`         // 公司名称
-	MinSalary           int    `json:"minSalary"`           // 最低薪资
-	MaxSalary           int    `json:"maxSalary"`           // 最高薪资
-	Education           string `json:"education"`           // 学历要求代码
-	Experience          string `json:"experience"`          // 经验要求代码
-	AppJobURL           string `json:"appJobUrl"`           // 职位链接
-	JobLocationAreaCode int    `json:"jobLocationAreaCode"` // 工作地点代码
+	JobID               string    `json:"jobId"`               // 岗位ID
+	JobTitle            string    `json:"jobTitle"`            // 职位名称
+	CompanyName         string    `json:"companyName"`         // 公司名称
+	MinSalary           int       `json:"minSalary"`           // 最低薪资
+	MaxSalary           int       `json:"maxSalary"`           // 最高薪资
+	Education           string    `json:"education"`           // 学历要求代码
+	Experience          string    `json:"experience"`          // 经验要求代码
+	AppJobURL           string    `json:"appJobUrl"`           // 职位链接
+	JobLocationAreaCode int       `json:"jobLocationAreaCode"` // 工作地点代码
+	Latitude            FlexFloat `json:"latitude,omitempty"`  // 工作地点纬度（岗位API返回时）
+	Longitude           FlexFloat `json:"longitude,omitempty"` // 工作地点经度（岗位API返回时）
+}
+
+// FlexFloat 兼容数字和数字字符串的浮点数，空字符串和 null 视为0
+type FlexFloat float64
+
+// UnmarshalJSON 解析 12.3 或 "12.3"
+func (f *FlexFloat) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*f = 0
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("无效的数字: %s", data)
+	}
+	*f = FlexFloat(v)
+	return nil
 }
 
 // FormattedJob 格式化后的岗位信息
 type FormattedJob struct {
-	JobTitle    string      `json:"jobTitle"`       // 职位名称
-	CompanyName string      `json:"companyName"`    // 公司名称
-	Salary      string      `json:"salary"`         // 薪资范围
-	Location    string      `json:"location"`       // 工作地点
-	Education   string      `json:"education"`      // 学历要求
-	Experience  string      `json:"experience"`     // 经验要求
-	AppJobURL   string      `json:"appJobUrl"`      // 职位链接
-	Data        interface{} `json:"data,omitempty"` // 额外数据（最后一条时包含）
+	JobID        string      `json:"jobId,omitempty"`        // 岗位ID（用于查询岗位详情）
+	JobTitle     string      `json:"jobTitle"`               // 职位名称
+	CompanyName  string      `json:"companyName"`            // 公司名称
+	Salary       string      `json:"salary"`                 // 薪资范围
+	Location     string      `json:"location"`               // 工作地点
+	Education    string      `json:"education"`              // 学历要求
+	Experience   string      `json:"experience"`             // 经验要求
+	AppJobURL    string      `json:"appJobUrl"`              // 职位链接
+	MatchScore   int         `json:"matchScore,omitempty"`   // 与求职者画像的匹配度（0-100，有画像时返回）
+	MatchReasons []string    `json:"matchReasons,omitempty"` // 推荐理由
+	Sources      []string    `json:"sources,omitempty"`      // 多区域/多岗位名称查询时，查到该岗位的查询（如 "市南区·前端"）
+	Commute      *JobCommute `json:"commute,omitempty"`      // 从出发地到工作地点的通勤（按通勤条件查询时返回）
+	Data         interface{} `json:"data,omitempty"`         // 额外数据（最后一条时包含）
 }
 
 // JobResponse 岗位查询结果
 type JobResponse struct {
-	JobListings []FormattedJob `json:"jobListings"`
-	Data        interface{}    `json:"data,omitempty"`
+	JobListings []FormattedJob   `json:"jobListings"`
+	Data        interface{}      `json:"data,omitempty"`
+	Exhausted   bool             `json:"exhausted,omitempty"` // 继续翻页时已没有更多结果
+	Queries     []JobQuerySource `json:"queries,omitempty"`   // 多区域/多岗位名称查询时各查询的结果概况
+
+	CommuteExcluded int `json:"commuteExcluded,omitempty"` // 因通勤超时或无法计算通勤而未返回的岗位数
+}
+
+// JobCommute 岗位通勤信息
+type JobCommute struct {
+	Mode     string `json:"mode"`               // 通勤方式名称，如 "公交地铁"
+	Minutes  int    `json:"minutes"`            // 预计通勤时间（分钟）
+	Distance int    `json:"distance,omitempty"` // 路线距离（米）
+}
+
+// JobQuerySource 合并查询中单个查询的结果概况
+type JobQuerySource struct {
+	Query string `json:"query"`           // 查询描述，与岗位的 sources 对应
+	Count int    `json:"count"`           // 该查询返回的岗位数（去重前）
+	Total int    `json:"total,omitempty"` // 符合该查询的岗位总数（岗位API返回时）
+	Error string `json:"error,omitempty"` // 查询失败原因，失败的查询不影响其他查询
+}
+
+// JobSearchResponse 岗位搜索接口响应
+type JobSearchResponse struct {
+	JobResponse
+	Pagination Pagination      `json:"pagination"`         // 分页信息
+	Location   *SearchLocation `json:"location,omitempty"` // 按地标搜索时解析出的坐标
+}
+
+// Pagination 分页信息
+type Pagination struct {
+	Current  int  `json:"current"`         // 当前页码
+	PageSize int  `json:"pageSize"`        // 每页数量
+	Count    int  `json:"count"`           // 本页岗位数
+	Total    int  `json:"total,omitempty"` // 岗位总数（岗位API返回时）
+	HasMore  bool `json:"hasMore"`         // 是否还有下一页
+}
+
+// SearchLocation 地标解析结果
+type SearchLocation struct {
+	Landmark  string `json:"landmark"`
+	Latitude  string `json:"latitude"`
+	Longitude string `json:"longitude"`
+	Radius    string `json:"radius"` // 搜索半径（km）
+}
+
+// JobDetailAPIResponse 岗位详情API响应
+type JobDetailAPIResponse struct {
+	Code int        `json:"code"`
+	Msg  string     `json:"msg"`
+	Data *JobDetail `json:"data"`
+}
+
+// JobDetail 岗位详情（岗位API原始字段）
+type JobDetail struct {
+	JobListing
+	JobDescription string `json:"jobDescription"` // 岗位职责
+	JobRequirement string `json:"jobRequirement"` // 任职要求
+	Welfare        string `json:"welfare"`        // 福利待遇，多个以逗号分隔
+	WorkAddress    string `json:"workAddress"`    // 工作地址
+	RecruitNum     int    `json:"recruitNum"`     // 招聘人数
+	CompanyNature  string `json:"companyNature"`  // 企业类型代码
+	CompanyScale   string `json:"companyScale"`   // 企业规模
+	Industry       string `json:"industry"`       // 所属行业
+	ContactPerson  string `json:"contactPerson"`  // 联系人
+	ContactPhone   string `json:"contactPhone"`   // 联系电话
+	ContactEmail   string `json:"contactEmail"`   // 联系邮箱
+	PublishTime    string `json:"publishTime"`    // 发布时间
+}
+
+// FormattedJobDetail 格式化后的岗位详情
+type FormattedJobDetail struct {
+	FormattedJob
+	Description   string      `json:"description,omitempty"`   // 岗位职责
+	Requirements  string      `json:"requirements,omitempty"`  // 任职要求
+	Benefits      []string    `json:"benefits,omitempty"`      // 福利待遇
+	Address       string      `json:"address,omitempty"`       // 工作地址
+	Headcount     int         `json:"headcount,omitempty"`     // 招聘人数
+	CompanyNature string      `json:"companyNature,omitempty"` // 企业类型
+	CompanyScale  string      `json:"companyScale,omitempty"`  // 企业规模
+	Industry      string      `json:"industry,omitempty"`      // 所属行业
+	Contact       *JobContact `json:"contact,omitempty"`       // 联系方式
+	PublishTime   string      `json:"publishTime,omitempty"`   // 发布时间
+}
+
+// JobContact 岗位联系方式
+type JobContact struct {
+	Name  string `json:"name,omitempty"`
+	Phone string `json:"phone,omitempty"`
+	Email string `json:"email,omitempty"`
 }
 
 // 学历代码映射
@@ -104,4 +236,32 @@ type AmapPlace struct {
 	Name     string `json:"name"`
 	Location string `json:"location"` // "经度,纬度"
 	Address  string `json:"address"`
+	CityName string `json:"cityname"` // 所在城市
+	AdName   string `json:"adname"`   // 所在区县
+}
+
+// AmapRouteResponse 高德地图路线规划响应（步行、驾车返回 paths，公交返回 transits）
+type AmapRouteResponse struct {
+	Status string `json:"status"`
+	Info   string `json:"info"`
+	Route  struct {
+		Paths    []AmapRoutePath `json:"paths"`
+		Transits []AmapRoutePath `json:"transits"`
+	} `json:"route"`
+}
+
+// AmapRoutePath 路线方案
+type AmapRoutePath struct {
+	Distance string `json:"distance"` // 距离（米）
+	Duration string `json:"duration"` // 预计耗时（秒）
+}
+
+// AmapGeocodeResponse 高德地图地理编码响应
+type AmapGeocodeResponse struct {
+	Status   string `json:"status"`
+	Info     string `json:"info"`
+	Geocodes []struct {
+		FormattedAddress string `json:"formatted_address"`
+		Location         string `json:"location"` // "经度,纬度"
+	} `json:"geocodes"`
 }
@@ -0,0 +1,23 @@
+package model
+
+// PlaceCandidate 地点候选
+type PlaceCandidate struct {
+	Index      int     `json:"index"`              // 序号，从1开始
+	Name       string  `json:"name"`               // 地点名称
+	Address    string  `json:"address,omitempty"`  // 详细地址
+	District   string  `json:"district,omitempty"` // 所在区县
+	Latitude   string  `json:"latitude"`           // 纬度
+	Longitude  string  `json:"longitude"`          // 经度
+	Confidence float64 `json:"confidence"`         // 与查询关键词的匹配置信度（0-1）
+}
+
+// LocationResult 地点查询结果
+// 候选地点相距较远、无法确定用户所指时 Ambiguous 为true，不返回坐标，需要用户从候选中选择
+type LocationResult struct {
+	Keywords   string           `json:"keywords"`
+	Latitude   string           `json:"latitude,omitempty"`  // 选定地点的纬度（无歧义时）
+	Longitude  string           `json:"longitude,omitempty"` // 选定地点的经度（无歧义时）
+	Ambiguous  bool             `json:"ambiguous,omitempty"`
+	Candidates []PlaceCandidate `json:"candidates"` // 按置信度从高到低排列
+	Message    string           `json:"message,omitempty"`
+}
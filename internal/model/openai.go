@@ -16,6 +16,9 @@ type ChatCompletionRequest struct {
 	User             string             `json:"user,omitempty"`
 	Tools            []Tool             `json:"tools,omitempty"`
 	ToolChoice       interface{}        `json:"tool_choice,omitempty"`
+	ResponseFormat   *ResponseFormat    `json:"response_format,omitempty"`
+	// ConversationID 服务端会话ID（扩展字段），携带时服务端保存并复用历史消息
+	ConversationID string `json:"conversation_id,omitempty"`
 }
 
 // Message 消息结构
@@ -67,6 +70,11 @@ type FunctionCall struct {
 	Arguments string `json:"arguments"`
 }
 
+// ResponseFormat 输出格式（如 {"type":"json_object"} 要求模型输出JSON）
+type ResponseFormat struct {
+	Type string `json:"type"`
+}
+
 // ChatCompletionResponse OpenAI Chat Completion响应
 type ChatCompletionResponse struct {
 	ID      string   `json:"id"`
@@ -75,6 +83,10 @@ type ChatCompletionResponse struct {
 	Model   string   `json:"model"`
 	Choices []Choice `json:"choices"`
 	Usage   *Usage   `json:"usage,omitempty"`
+	// ConversationID 服务端会话ID（扩展字段）
+	ConversationID string `json:"conversation_id,omitempty"`
+	// Grounding 岗位事实核验结果（扩展字段，开启 grounding.expose_verdict 时返回）
+	Grounding *GroundingVerdict `json:"grounding,omitempty"`
 }
 
 // Choice 选择项
@@ -99,6 +111,8 @@ type ChatCompletionChunk struct {
 	Created int64         `json:"created"`
 	Model   string        `json:"model"`
 	Choices []ChunkChoice `json:"choices"`
+	// Grounding 岗位事实核验结果（扩展字段，仅结束chunk携带）
+	Grounding *GroundingVerdict `json:"grounding,omitempty"`
 }
 
 // ChunkChoice 流式选择项
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ResumeProfile 从简历中提取的求职者画像
+type ResumeProfile struct {
+	Name              string   `json:"name,omitempty"`
+	Education         string   `json:"education,omitempty"`         // 最高学历代码（见 EducationMap）
+	ExperienceYears   float64  `json:"experienceYears,omitempty"`   // 工作年限
+	Experience        string   `json:"experience,omitempty"`        // 经验代码（见 ExperienceMap）
+	Skills            []string `json:"skills,omitempty"`            // 技能
+	Titles            []string `json:"titles,omitempty"`            // 曾任职位（最近的在前）
+	DesiredTitle      string   `json:"desiredTitle,omitempty"`      // 求职意向岗位
+	ExpectedSalaryMin int      `json:"expectedSalaryMin,omitempty"` // 期望薪资下限（元/月）
+	ExpectedSalaryMax int      `json:"expectedSalaryMax,omitempty"` // 期望薪资上限（元/月）
+	PreferredDistrict string   `json:"preferredDistrict,omitempty"` // 期望工作区域
+	PreferredAreaCode string   `json:"preferredAreaCode,omitempty"` // 期望工作区域代码
+	Source            string   `json:"source,omitempty"`            // 提取来源：rules / rules+llm
+}
+
+// IsEmpty 是否没有提取到任何信息
+func (p *ResumeProfile) IsEmpty() bool {
+	return p == nil || (p.Name == "" && p.Education == "" && p.Experience == "" && len(p.Skills) == 0 &&
+		len(p.Titles) == 0 && p.DesiredTitle == "" && p.ExpectedSalaryMin == 0 && p.ExpectedSalaryMax == 0 && p.PreferredDistrict == "")
+}
+
+// JobTitle 用于岗位查询的岗位关键字：优先求职意向，其次最近的职位
+func (p *ResumeProfile) JobTitle() string {
+	if p == nil {
+		return ""
+	}
+	if p.DesiredTitle != "" {
+		return p.DesiredTitle
+	}
+	if len(p.Titles) > 0 {
+		return p.Titles[0]
+	}
+	return ""
+}
+
+// Describe 生成供模型参考的画像描述
+func (p *ResumeProfile) Describe() string {
+	if p.IsEmpty() {
+		return ""
+	}
+
+	var lines []string
+	add := func(label, value string) {
+		if value != "" {
+			lines = append(lines, fmt.Sprintf("- %s：%s", label, value))
+		}
+	}
+	add("姓名", p.Name)
+	if p.Education != "" {
+		add("最高学历", fmt.Sprintf("%s（education=%s）", EducationMap[p.Education], p.Education))
+	}
+	if p.Experience != "" {
+		add("工作经验", fmt.Sprintf("%s（experience=%s）", ExperienceMap[p.Experience], p.Experience))
+	}
+	add("求职意向", p.DesiredTitle)
+	add("曾任职位", strings.Join(p.Titles, "、"))
+	add("技能", strings.Join(p.Skills, "、"))
+	switch {
+	case p.ExpectedSalaryMin > 0 && p.ExpectedSalaryMax > 0:
+		add("期望薪资", fmt.Sprintf("%d-%d元/月", p.ExpectedSalaryMin, p.ExpectedSalaryMax))
+	case p.ExpectedSalaryMin > 0:
+		add("期望薪资", fmt.Sprintf("%d元/月以上", p.ExpectedSalaryMin))
+	}
+	if p.PreferredDistrict != "" {
+		district := p.PreferredDistrict
+		if p.PreferredAreaCode != "" {
+			district += fmt.Sprintf("（jobLocationAreaCode=%s）", p.PreferredAreaCode)
+		}
+		add("期望区域", district)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Clone 深拷贝画像
+func (p *ResumeProfile) Clone() *ResumeProfile {
+	if p == nil {
+		return nil
+	}
+	clone := *p
+	clone.Skills = append([]string(nil), p.Skills...)
+	clone.Titles = append([]string(nil), p.Titles...)
+	return &clone
+}
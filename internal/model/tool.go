@@ -5,209 +5,6 @@ import (
 	"qd-sc/internal/config"
 )
 
-// GetAvailableTools 获取所有可用工具定义
-func GetAvailableTools() []Tool {
-	cfg := config.Get()
-	cityName := cfg.City.Name
-	landmarks := cfg.City.GetLandmarksExample()
-	areaCodes := cfg.City.GetAreaCodesDescription()
-
-	return []Tool{
-		{
-			Type: "function",
-			Function: FunctionDef{
-				Name:        "queryLocation",
-				Description: fmt.Sprintf("查询%s具体地点的经纬度坐标，用于后续基于地理位置的岗位查询", cityName),
-				Parameters: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"keywords": map[string]interface{}{
-							"type":        "string",
-							"description": fmt.Sprintf("具体的地名，例如：%s", landmarks),
-						},
-					},
-					"required": []string{"keywords"},
-				},
-			},
-		},
-		{
-			Type: "function",
-			Function: FunctionDef{
-				Name:        "queryJobsByArea",
-				Description: fmt.Sprintf("【必须调用】根据区域代码查询%s岗位信息。当用户询问任何与岗位、工作、招聘、求职相关的问题时，必须调用此工具获取真实数据。严禁在未调用此工具的情况下输出任何岗位信息。", cityName),
-				Parameters: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"jobTitle": map[string]interface{}{
-							"type":        "string",
-							"description": "岗位名称关键字，例如：Java开发、产品经理",
-						},
-						"current": map[string]interface{}{
-							"type":        "integer",
-							"description": "当前页码，用于分页查询，默认为1",
-							"default":     1,
-						},
-						"pageSize": map[string]interface{}{
-							"type":        "integer",
-							"description": "每页返回的岗位数量，默认为10",
-							"default":     10,
-						},
-						"jobLocationAreaCode": map[string]interface{}{
-							"type":        "string",
-							"description": fmt.Sprintf("区域代码，%s", areaCodes),
-						},
-						"order": map[string]interface{}{
-							"type":        "string",
-							"description": "排序方式，0:推荐, 1:最热, 2:最新发布，默认为0",
-						},
-						"minSalary": map[string]interface{}{
-							"type":        "string",
-							"description": "最低薪资，单位：元/月",
-						},
-						"maxSalary": map[string]interface{}{
-							"type":        "string",
-							"description": "最高薪资，单位：元/月",
-						},
-						"experience": map[string]interface{}{
-							"type":        "string",
-							"description": "经验要求代码，0:经验不限, 1:实习生, 2:应届毕业生, 3:1年以下, 4:1-3年, 5:3-5年, 6:5-10年, 7:10年以上",
-						},
-						"education": map[string]interface{}{
-							"type":        "string",
-							"description": "学历要求代码，-1:不限, 0:初中及以下, 1:中专/中技, 2:高中, 3:大专, 4:本科, 5:硕士, 6:博士, 7:MBA/EMBA, 8:留学-学士, 9:留学-硕士, 10:留学-博士",
-						},
-						"companyNature": map[string]interface{}{
-							"type":        "string",
-							"description": "企业类型代码，1:私营企业, 2:股份制企业, 3:国有企业, 4:外商及港澳台投资企业, 5:医院",
-						},
-					},
-					"required": []string{"jobTitle", "current", "pageSize"},
-				},
-			},
-		},
-		{
-			Type: "function",
-			Function: FunctionDef{
-				Name:        "queryJobsByLocation",
-				Description: fmt.Sprintf("【必须调用】根据经纬度和半径查询附近的%s岗位信息。当用户询问特定位置附近的岗位时，必须调用此工具获取真实数据。需要先调用queryLocation获取经纬度。严禁在未调用此工具的情况下输出任何岗位信息。", cityName),
-				Parameters: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"jobTitle": map[string]interface{}{
-							"type":        "string",
-							"description": "岗位名称关键字，例如：Java开发、产品经理",
-						},
-						"current": map[string]interface{}{
-							"type":        "integer",
-							"description": "当前页码，用于分页查询，默认为1",
-							"default":     1,
-						},
-						"pageSize": map[string]interface{}{
-							"type":        "integer",
-							"description": "每页返回的岗位数量，默认为10",
-							"default":     10,
-						},
-						"latitude": map[string]interface{}{
-							"type":        "string",
-							"description": "纬度，从queryLocation工具获取",
-						},
-						"longitude": map[string]interface{}{
-							"type":        "string",
-							"description": "经度，从queryLocation工具获取",
-						},
-						"radius": map[string]interface{}{
-							"type":        "string",
-							"description": "搜索半径，单位：千米，最大为50，建议使用5-10",
-							"default":     "10",
-						},
-						"order": map[string]interface{}{
-							"type":        "string",
-							"description": "排序方式，0:推荐, 1:最热, 2:最新发布，默认为0",
-						},
-						"minSalary": map[string]interface{}{
-							"type":        "string",
-							"description": "最低薪资，单位：元/月",
-						},
-						"maxSalary": map[string]interface{}{
-							"type":        "string",
-							"description": "最高薪资，单位：元/月",
-						},
-						"experience": map[string]interface{}{
-							"type":        "string",
-							"description": "经验要求代码，0:经验不限, 1:实习生, 2:应届毕业生, 3:1年以下, 4:1-3年, 5:3-5年, 6:5-10年, 7:10年以上",
-						},
-						"education": map[string]interface{}{
-							"type":        "string",
-							"description": "学历要求代码，-1:不限, 0:初中及以下, 1:中专/中技, 2:高中, 3:大专, 4:本科, 5:硕士, 6:博士, 7:MBA/EMBA, 8:留学-学士, 9:留学-硕士, 10:留学-博士",
-						},
-						"companyNature": map[string]interface{}{
-							"type":        "string",
-							"description": "企业类型代码，1:私营企业, 2:股份制企业, 3:国有企业, 4:外商及港澳台投资企业, 5:医院",
-						},
-					},
-					"required": []string{"jobTitle", "current", "pageSize", "latitude", "longitude", "radius"},
-				},
-			},
-		},
-		{
-			Type: "function",
-			Function: FunctionDef{
-				Name:        "parsePDF",
-				Description: "深度解析PDF文件，提取文本内容，特别适用于简历等复杂格式的PDF文件",
-				Parameters: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"fileUrl": map[string]interface{}{
-							"type":        "string",
-							"description": "PDF文件的URL地址",
-						},
-					},
-					"required": []string{"fileUrl"},
-				},
-			},
-		},
-		{
-			Type: "function",
-			Function: FunctionDef{
-				Name:        "parseImage",
-				Description: "解析图片文件，识别图片中的文本和内容，可用于识别简历截图、证书照片等",
-				Parameters: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"imageUrl": map[string]interface{}{
-							"type":        "string",
-							"description": "图片文件的URL地址",
-						},
-					},
-					"required": []string{"imageUrl"},
-				},
-			},
-		},
-		{
-			Type: "function",
-			Function: FunctionDef{
-				Name:        "queryPolicy",
-				Description: fmt.Sprintf("查询%s就业创业相关政策信息，包括就业补贴、创业扶持、见习补贴等政策", cityName),
-				Parameters: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"query": map[string]interface{}{
-							"type":        "string",
-							"description": "政策查询关键词，例如：就业补贴、创业扶持、见习补贴、职业培训等",
-						},
-						"topK": map[string]interface{}{
-							"type":        "integer",
-							"description": "返回最相关的政策数量，默认为3",
-							"default":     3,
-						},
-					},
-					"required": []string{"query"},
-				},
-			},
-		},
-	}
-}
-
 // GetSystemPrompt 获取系统提示词
 func GetSystemPrompt() string {
 	cfg := config.Get()
@@ -238,6 +35,7 @@ func GetSystemPrompt() string {
 - ✅ 收到岗位查询请求后，先调用 queryJobsByArea 或 queryJobsByLocation 工具
 - ✅ 工具返回结果后，系统会自动以 job-json 格式展示，你只需要添加简短的引导语
 - ✅ 如果工具返回空结果，如实告知用户未找到匹配岗位，建议调整条件
+- ✅ 上一轮列出了地点候选（location-options）时，用户回复的序号或名称作为 placeOption 传给 queryJobsByLocation，不要再次调用 queryLocation
 
 ## 数据处理规则
 1. 如果用户上传了文件，优先使用文件中提取的信息
@@ -289,12 +87,15 @@ func GetSystemPrompt() string {
 7. 【岗位信息完整性】展示岗位时必须包含以下所有字段：岗位名称、公司名称、薪资、工作地点（区域）、学历要求、经验要求、详情链接。**不得省略任何字段，特别是工作地点（location字段）**
 
 ## 工具特定说明
-1. 【区域代码映射】%s市区域代码：%s
+1. 【区域代码映射】%s市区域代码：%s。也可以直接传区域名称、简称或街道名称（如"黄岛"、"西海岸"），系统会自动解析；返回区域有多个可能时，请向用户确认具体区域
 2. 【多轮对话工具】某些工具支持多轮对话（如政策咨询），首次调用时不需要传入会话标识，后续调用时使用上次返回的标识以保持上下文
 3. 【岗位查询强制规则】
    - 进行任何岗位推荐时，**必须**调用 queryJobsByArea 或 queryJobsByLocation 工具
    - 岗位信息展示由系统自动完成，你只需提供简短引导语
    - **严禁**在未调用工具的情况下输出任何岗位相关数据
+   - 用户同时提到多个区域或多个岗位名称（如"市南区或崂山区的前端/Web开发"）时，在一次 queryJobsByArea 调用中用 jobLocationAreaCodes、jobTitles 传入全部区域和名称，系统会分别查询并合并去重
+4. 【继续翻页】用户要求"再看看更多"、"换一批"、"下一页"且没有改变条件时，调用 moreJobs 工具，它会沿用上一次的全部筛选条件并跳过已展示的岗位；不要自行改写 current 重新查询
+5. 【岗位详情】用户追问已展示岗位的职责、要求、福利或联系方式时（如"第二个岗位具体要求是什么"），调用 getJobDetail 工具，传入岗位的 jobId 或其在最近一次结果中的序号；不要重新查询岗位列表，也不要根据岗位名称推测详情
 
 ## 特别注意
 1. 【语义理解】理解用户输入的隐含含义和简称（如%s），在调用工具时使用准确完整的表达
@@ -0,0 +1,208 @@
+package normalize
+
+import (
+	"fmt"
+	"math"
+	"qd-sc/internal/model"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// educationLevels 学历关键词与 EducationMap 代码，按学历从高到低排列
+var educationLevels = []struct {
+	keywords []string
+	code     string
+}{
+	{[]string{"博士"}, "6"},
+	{[]string{"EMBA", "MBA"}, "7"},
+	{[]string{"硕士", "研究生"}, "5"},
+	{[]string{"本科", "学士"}, "4"},
+	{[]string{"大专", "专科", "高职"}, "3"},
+	{[]string{"高中"}, "2"},
+	{[]string{"中专", "中技", "技校", "职高"}, "1"},
+	{[]string{"初中"}, "0"},
+}
+
+// unlimitedWords 不限定条件的说法
+var unlimitedWords = []string{"不限", "无要求", "没有要求", "均可", "都可以"}
+
+var (
+	// experienceRangePattern 年限区间，如 1-3年、3到5年
+	experienceRangePattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*[-~～到至]\s*\d+(?:\.\d+)?\s*年`)
+	// experienceYearsPattern 年限，如 3年、3年以上、5年经验
+	experienceYearsPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*年`)
+	// experienceBelowPattern 年限以下，如 1年以下、不到1年
+	experienceBelowPattern = regexp.MustCompile(`(?:(\d+(?:\.\d+)?)\s*年\s*(?:以下|以内)|不到\s*(\d+(?:\.\d+)?)\s*年)`)
+)
+
+// HighestEducation 文本中出现的最高学历对应的 EducationMap 代码，没有时返回空字符串
+func HighestEducation(text string) string {
+	upper := strings.ToUpper(text)
+	for _, level := range educationLevels {
+		for _, kw := range level.keywords {
+			if strings.Contains(upper, kw) {
+				return level.code
+			}
+		}
+	}
+	return ""
+}
+
+// lowestEducation 文本中出现的最低学历对应的代码，如 "大专或本科" 为大专
+func lowestEducation(text string) string {
+	upper := strings.ToUpper(text)
+	code := ""
+	for _, level := range educationLevels {
+		for _, kw := range level.keywords {
+			if strings.Contains(upper, kw) {
+				code = level.code
+			}
+		}
+	}
+	return code
+}
+
+// ExperienceCode 工作年限对应的 ExperienceMap 代码，年限未知时返回空字符串
+func ExperienceCode(years float64, freshGraduate bool) string {
+	switch {
+	case freshGraduate && years < 1:
+		return "2"
+	case years <= 0:
+		return ""
+	case years < 1:
+		return "3"
+	case years < 3:
+		return "4"
+	case years < 5:
+		return "5"
+	case years < 10:
+		return "6"
+	default:
+		return "7"
+	}
+}
+
+// Education 将学历参数转换为 EducationMap 代码，空值返回空字符串
+// 支持代码、代码表中的名称，以及 "本科以上"、"大专或本科"（取最低学历）、"不限" 等说法
+func Education(v interface{}) (string, error) {
+	text, err := String(v)
+	if err != nil || text == "" {
+		return "", err
+	}
+	if code, ok := lookupCode(text, model.EducationMap); ok {
+		return code, nil
+	}
+	if containsAny(text, unlimitedWords) {
+		return "-1", nil
+	}
+	if code := lowestEducation(text); code != "" {
+		return code, nil
+	}
+	return "", fmt.Errorf("无法识别的学历 %q，请使用学历代码或如“本科”“大专以上”的说法", text)
+}
+
+// Experience 将经验参数转换为 ExperienceMap 代码，空值返回空字符串
+// 支持代码、代码表中的名称，以及 "3年经验"、"1年以下"、"半年"、"应届"、"实习"、"不限" 等说法；
+// 年限区间按下限计算
+func Experience(v interface{}) (string, error) {
+	text, err := String(v)
+	if err != nil || text == "" {
+		return "", err
+	}
+	if code, ok := lookupCode(text, model.ExperienceMap); ok {
+		return code, nil
+	}
+
+	arabic := toArabic(strings.ReplaceAll(text, "半年", "0.5年"))
+	switch {
+	case containsAny(arabic, []string{"应届", "毕业生"}):
+		return "2", nil
+	case strings.Contains(arabic, "实习"):
+		return "1", nil
+	case containsAny(arabic, unlimitedWords), containsAny(arabic, []string{"无经验", "没有经验", "零经验", "无需经验", "0经验"}):
+		return "0", nil
+	}
+
+	if m := experienceBelowPattern.FindStringSubmatch(arabic); m != nil {
+		limit := m[1] + m[2]
+		if years, _ := strconv.ParseFloat(limit, 64); years <= 1 {
+			return "3", nil
+		}
+		return "4", nil
+	}
+	var years float64
+	if m := experienceRangePattern.FindStringSubmatch(arabic); m != nil {
+		years, _ = strconv.ParseFloat(m[1], 64)
+	} else if m := experienceYearsPattern.FindStringSubmatch(arabic); m != nil {
+		years, _ = strconv.ParseFloat(m[1], 64)
+	} else {
+		return "", fmt.Errorf("无法识别的经验要求 %q，请使用经验代码或如“3年经验”“应届”的说法", text)
+	}
+	if years <= 0 {
+		return "0", nil
+	}
+	return ExperienceCode(years, false), nil
+}
+
+// Int 将整数参数（数字或数字字符串）转换为int，空值返回 def
+func Int(v interface{}, def int) (int, error) {
+	switch n := v.(type) {
+	case nil:
+		return def, nil
+	case float64:
+		if n != math.Trunc(n) {
+			return 0, fmt.Errorf("应为整数: %v", n)
+		}
+		return int(n), nil
+	case string:
+		s := strings.TrimSpace(n)
+		if s == "" {
+			return def, nil
+		}
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("应为整数: %q", n)
+		}
+		return i, nil
+	default:
+		return 0, fmt.Errorf("应为整数: %v", v)
+	}
+}
+
+// String 将字符串参数（字符串或数字）转换为去掉首尾空白的字符串，空值返回空字符串
+func String(v interface{}) (string, error) {
+	switch s := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return strings.TrimSpace(s), nil
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("应为字符串: %v", v)
+	}
+}
+
+// lookupCode 值为代码表中的代码或名称时返回对应代码
+func lookupCode(text string, codes map[string]string) (string, bool) {
+	if _, ok := codes[text]; ok {
+		return text, true
+	}
+	for code, name := range codes {
+		if name == text {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// containsAny 文本中是否包含任一关键词
+func containsAny(text string, words []string) bool {
+	for _, w := range words {
+		if strings.Contains(text, w) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package normalize
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// hourPattern 小时数，可带"半"和分钟，如 1小时、1个半小时、1.5h、1小时20分钟
+	hourPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)个?(半)?(?:小时|钟头|h|hr|hour|hours)(?:(\d+)(?:分钟|分))?$`)
+	// minutePattern 分钟数，如 40、40分钟、40min
+	minutePattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)(?:分钟|分|min|mins|minute|minutes)?$`)
+)
+
+// durationFillers 时长前后不影响数值的说法
+var durationFillers = strings.NewReplacer(
+	"通勤", "", "不超过", "", "最多", "", "以内", "", "之内", "", "以下", "", "左右", "", "内", "", "单程", "",
+)
+
+// Minutes 将时长参数（分钟数或中文表达式）转换为分钟，空值返回0
+// 支持 40、"40分钟"、"半小时"、"1小时"、"一个半小时"、"1小时20分钟"、"1.5h"、"40分钟以内"
+func Minutes(v interface{}) (int, error) {
+	if n, ok := v.(float64); ok {
+		if n < 0 {
+			return 0, fmt.Errorf("时长不能为负数: %v", n)
+		}
+		return int(math.Round(n)), nil
+	}
+	text, err := String(v)
+	if err != nil {
+		return 0, err
+	}
+	if text == "" {
+		return 0, nil
+	}
+
+	s := strings.ToLower(strings.Join(strings.Fields(toArabic(text)), ""))
+	s = durationFillers.Replace(s)
+	if s == "半小时" || s == "半个小时" || s == "半个钟头" {
+		return 30, nil
+	}
+	if m := hourPattern.FindStringSubmatch(s); m != nil {
+		hours, _ := strconv.ParseFloat(m[1], 64)
+		minutes := hours * 60
+		if m[2] != "" {
+			minutes += 30
+		}
+		if m[3] != "" {
+			extra, _ := strconv.Atoi(m[3])
+			minutes += float64(extra)
+		}
+		return int(math.Round(minutes)), nil
+	}
+	if m := minutePattern.FindStringSubmatch(s); m != nil {
+		minutes, _ := strconv.ParseFloat(m[1], 64)
+		return int(math.Round(minutes)), nil
+	}
+	return 0, fmt.Errorf("无法识别的时长 %q，请使用分钟数，如 40、1小时", text)
+}
+
+// Bool 将布尔参数（布尔值或 "true"/"false"、"是"/"否" 等字符串）转换为bool，空值返回false
+func Bool(v interface{}) (bool, error) {
+	switch b := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return b, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(b)) {
+		case "", "false", "0", "no", "否":
+			return false, nil
+		case "true", "1", "yes", "是":
+			return true, nil
+		}
+	}
+	return false, fmt.Errorf("应为布尔值: %v", v)
+}
@@ -0,0 +1,175 @@
+package normalize
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSalaryRange(t *testing.T) {
+	tests := []struct {
+		text     string
+		min, max int
+	}{
+		{"8000", 8000, 0},
+		{"8k", 8000, 0},
+		{"1万5", 15000, 0},
+		{"1.5万", 15000, 0},
+		{"一万五千", 15000, 0},
+		{"8-12k", 8000, 12000},
+		{"8000元-12000元/月", 8000, 12000},
+		{"1万5到2万", 15000, 20000},
+		{"年薪20万", 16667, 0},
+		{"月薪十五k以上", 15000, 0},
+		{"面议", 0, 0},
+		{"", 0, 0},
+	}
+	for _, tt := range tests {
+		min, max, err := SalaryRange(tt.text)
+		if err != nil || min != tt.min || max != tt.max {
+			t.Errorf("SalaryRange(%q) = %d, %d, %v; want %d, %d", tt.text, min, max, err, tt.min, tt.max)
+		}
+	}
+
+	for _, text := range []string{"很多", "日薪300"} {
+		if _, _, err := SalaryRange(text); err == nil || !strings.Contains(err.Error(), "8k") {
+			t.Errorf("SalaryRange(%q) should fail with a format hint, got %v", text, err)
+		}
+	}
+}
+
+func TestMonthlySalary(t *testing.T) {
+	if v, err := MonthlySalary(float64(9000), false); err != nil || v != 9000 {
+		t.Fatalf("number = %d, %v", v, err)
+	}
+	if v, err := MonthlySalary("8-12k", true); err != nil || v != 12000 {
+		t.Fatalf("upper of range = %d, %v", v, err)
+	}
+	if v, err := MonthlySalary("10k", true); err != nil || v != 10000 {
+		t.Fatalf("upper of single = %d, %v", v, err)
+	}
+	if _, err := MonthlySalary(true, false); err == nil {
+		t.Fatal("bool should be rejected")
+	}
+}
+
+func TestExperience(t *testing.T) {
+	tests := map[interface{}]string{
+		"":         "",
+		"5":        "5",
+		float64(4): "4",
+		"1-3年":     "4",
+		"3年经验":     "5",
+		"三年以上":     "5",
+		"5到10年":    "6",
+		"半年":       "3",
+		"1年以下":     "3",
+		"不到2年":     "4",
+		"十年以上":     "7",
+		"应届生":      "2",
+		"实习":       "1",
+		"经验不限":     "0",
+		"无经验":      "0",
+	}
+	for in, want := range tests {
+		if got, err := Experience(in); err != nil || got != want {
+			t.Errorf("Experience(%v) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := Experience("资深"); err == nil {
+		t.Error("unrecognized experience should fail")
+	}
+}
+
+func TestEducation(t *testing.T) {
+	tests := map[interface{}]string{
+		"":          "",
+		"4":         "4",
+		float64(-1): "-1",
+		"硕士":        "5",
+		"本科以上":      "4",
+		"大专或本科":     "3",
+		"学历不限":      "-1",
+		"MBA/EMBA":  "7",
+	}
+	for in, want := range tests {
+		if got, err := Education(in); err != nil || got != want {
+			t.Errorf("Education(%v) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := Education("名校"); err == nil {
+		t.Error("unrecognized education should fail")
+	}
+	if got := HighestEducation("2015-2019 青岛大学 本科；2019-2022 硕士"); got != "5" {
+		t.Errorf("HighestEducation = %q", got)
+	}
+}
+
+func TestExperienceCode(t *testing.T) {
+	tests := []struct {
+		years float64
+		fresh bool
+		want  string
+	}{
+		{0, false, ""}, {0, true, "2"}, {0.5, false, "3"}, {2, false, "4"}, {3, false, "5"}, {7, false, "6"}, {12, false, "7"},
+	}
+	for _, tt := range tests {
+		if got := ExperienceCode(tt.years, tt.fresh); got != tt.want {
+			t.Errorf("ExperienceCode(%v, %v) = %q, want %q", tt.years, tt.fresh, got, tt.want)
+		}
+	}
+}
+
+func TestIntAndString(t *testing.T) {
+	if n, err := Int(" 2 ", 1); err != nil || n != 2 {
+		t.Fatalf("Int(string) = %d, %v", n, err)
+	}
+	if n, err := Int(nil, 1); err != nil || n != 1 {
+		t.Fatalf("Int(nil) = %d, %v", n, err)
+	}
+	if _, err := Int("两页", 1); err == nil {
+		t.Fatal("non-numeric page should fail")
+	}
+	if _, err := Int(1.5, 1); err == nil {
+		t.Fatal("fraction should fail")
+	}
+	if s, err := String(float64(3)); err != nil || s != "3" {
+		t.Fatalf("String(number) = %q, %v", s, err)
+	}
+}
+
+func TestMinutes(t *testing.T) {
+	tests := map[interface{}]int{
+		nil:         0,
+		"":          0,
+		float64(45): 45,
+		"40":        40,
+		"40分钟":      40,
+		"40分钟以内":    40,
+		"半小时":       30,
+		"1小时":       60,
+		"一个小时":      60,
+		"一个半小时":     90,
+		"1小时20分钟":   80,
+		"1.5h":      90,
+		"通勤不超过50分钟": 50,
+	}
+	for in, want := range tests {
+		if got, err := Minutes(in); err != nil || got != want {
+			t.Errorf("Minutes(%v) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	if _, err := Minutes("很近"); err == nil {
+		t.Error("unrecognized duration should fail")
+	}
+}
+
+func TestBool(t *testing.T) {
+	for in, want := range map[interface{}]bool{nil: false, true: true, "true": true, "否": false} {
+		if got, err := Bool(in); err != nil || got != want {
+			t.Errorf("Bool(%v) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := Bool(float64(1)); err == nil {
+		t.Error("number should be rejected")
+	}
+}
@@ -0,0 +1,161 @@
+package normalize
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// salaryHint 薪资格式提示，随错误返回给模型
+const salaryHint = "请使用元/月金额，如 8000、8k、1万5、8-12k、年薪20万"
+
+var (
+	// salaryAmountPattern 金额，如 8000、8k、1.5万、1万5、1万5千
+	salaryAmountPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(?:([万wW])\s*(\d)?\s*千?|([kK千]))?`)
+	// salaryRangeSep 区间分隔符
+	salaryRangeSep = regexp.MustCompile(`^\s*(?:元|/月|每月)?\s*[-~～到至—]`)
+	// chineseTenPattern 带“十”的中文数字（已替换个位数字后），如 十五、2十、3十5
+	chineseTenPattern = regexp.MustCompile(`(\d)?十(\d)?`)
+)
+
+// chineseDigits 中文数字
+var chineseDigits = strings.NewReplacer(
+	"零", "0", "〇", "0", "一", "1", "二", "2", "两", "2", "三", "3", "四", "4",
+	"五", "5", "六", "6", "七", "7", "八", "8", "九", "9",
+)
+
+// negotiableWords 不限定薪资的说法
+var negotiableWords = []string{"面议", "不限", "无要求", "都可以", "均可"}
+
+// SalaryRange 将薪资表达式解析为月薪范围（元/月）
+// 支持 8000、8k、1万5、8-12k、1-1.5万、年薪20万 及中文数字；只有一个金额时作为下限，max 为0；
+// 不带单位且小于100的数按千元计算（如 "8-12"）；"面议"、"不限"等返回 0, 0
+func SalaryRange(text string) (min, max int, err error) {
+	text = toArabic(strings.TrimSpace(text))
+	if text == "" {
+		return 0, 0, nil
+	}
+
+	matches := salaryAmountPattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		for _, w := range negotiableWords {
+			if strings.Contains(text, w) {
+				return 0, 0, nil
+			}
+		}
+		return 0, 0, fmt.Errorf("无法识别的薪资 %q，%s", text, salaryHint)
+	}
+	for _, w := range []string{"日薪", "时薪", "/天", "/日", "每天", "小时"} {
+		if strings.Contains(text, w) {
+			return 0, 0, fmt.Errorf("暂不支持按日薪或时薪筛选 %q，%s", text, salaryHint)
+		}
+	}
+
+	annual := strings.Contains(text, "年")
+	amounts := make([]salaryAmount, 0, 2)
+	for _, m := range matches[:minInt(len(matches), 2)] {
+		amounts = append(amounts, parseAmount(text, m))
+	}
+
+	low := amounts[0]
+	if len(amounts) == 1 || !salaryRangeSep.MatchString(text[matches[0][1]:matches[1][0]]) {
+		return low.monthly("", annual), 0, nil
+	}
+	high := amounts[1]
+	return low.monthly(high.unit, annual), high.monthly("", annual), nil
+}
+
+// salaryAmount 解析出的单个金额
+type salaryAmount struct {
+	value    float64
+	unit     string // 万、千，没有单位时为空
+	thousand float64
+}
+
+// parseAmount 解析 salaryAmountPattern 的一个匹配
+func parseAmount(text string, m []int) salaryAmount {
+	group := func(i int) string {
+		if m[2*i] < 0 {
+			return ""
+		}
+		return text[m[2*i]:m[2*i+1]]
+	}
+
+	a := salaryAmount{}
+	a.value, _ = strconv.ParseFloat(group(1), 64)
+	switch {
+	case group(2) != "":
+		a.unit = "万"
+		a.thousand, _ = strconv.ParseFloat(group(3), 64)
+	case group(4) != "":
+		a.unit = "千"
+	}
+	return a
+}
+
+// monthly 换算为元/月，没有单位时使用 fallbackUnit（区间下限沿用上限的单位，如 "8-12k"）
+func (a salaryAmount) monthly(fallbackUnit string, annual bool) int {
+	unit := a.unit
+	if unit == "" {
+		unit = fallbackUnit
+	}
+
+	v := a.value
+	switch unit {
+	case "万":
+		v = v*10000 + a.thousand*1000
+	case "千":
+		v *= 1000
+	default:
+		// 不带单位的小数字通常是以千为单位（如“8-12”）
+		if v < 100 {
+			v *= 1000
+		}
+	}
+	if annual {
+		v /= 12
+	}
+	return int(math.Round(v))
+}
+
+// MonthlySalary 将薪资参数（数字或中文表达式）转换为元/月，空值返回0
+// upper 为true时取区间上限（用于最高薪资），只有一个金额时取该金额
+func MonthlySalary(v interface{}, upper bool) (int, error) {
+	text, err := String(v)
+	if err != nil {
+		return 0, err
+	}
+	min, max, err := SalaryRange(text)
+	if err != nil {
+		return 0, err
+	}
+	if upper && max > 0 {
+		return max, nil
+	}
+	return min, nil
+}
+
+// toArabic 将中文数字转换为阿拉伯数字，如 一万五 -> 1万5、二十 -> 20
+func toArabic(text string) string {
+	text = chineseDigits.Replace(text)
+	return chineseTenPattern.ReplaceAllStringFunc(text, func(s string) string {
+		m := chineseTenPattern.FindStringSubmatch(s)
+		tens, ones := m[1], m[2]
+		if tens == "" {
+			tens = "1"
+		}
+		if ones == "" {
+			ones = "0"
+		}
+		return tens + ones
+	})
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
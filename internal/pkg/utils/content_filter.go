@@ -8,6 +8,9 @@ import (
 // ThinkingTagPattern 匹配思维链标签的正则表达式
 var ThinkingTagPattern = regexp.MustCompile(`<think>[\s\S]*?</think>`)
 
+// multipleNewlinesPattern 匹配3个及以上连续换行符
+var multipleNewlinesPattern = regexp.MustCompile(`\n{3,}`)
+
 // FilterThinkingTags 过滤内容中的思维链标签
 func FilterThinkingTags(content string) string {
 	if content == "" {
@@ -21,8 +24,7 @@ func FilterThinkingTags(content string) string {
 	filtered = strings.TrimSpace(filtered)
 
 	// 将多个连续换行符替换为最多两个换行符
-	multipleNewlines := regexp.MustCompile(`\n{3,}`)
-	filtered = multipleNewlines.ReplaceAllString(filtered, "\n\n")
+	filtered = multipleNewlinesPattern.ReplaceAllString(filtered, "\n\n")
 
 	return filtered
 }
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"strings"
+)
+
+// DefaultReasoningTags 默认的思维链标签名称
+var DefaultReasoningTags = []string{"think"}
+
+// ThinkingStreamFilter 流式思维链过滤器
+// 每个请求独立创建一个实例，能处理标签被拆分到多个chunk的情况（如 "<thi" + "nk>"）
+// 非并发安全：同一个实例只应在单个流中顺序使用
+type ThinkingStreamFilter struct {
+	openTags  []string // 开始标签，如 <think>
+	closeTags []string // 结束标签，与 openTags 一一对应
+	inside    int      // 当前所在思维链标签的下标，-1 表示在思维链外部
+	pending   string   // 可能是标签前缀、需要等待下一个chunk才能确定的内容
+}
+
+// NewThinkingStreamFilter 创建流式思维链过滤器
+// tagNames 为标签名称（不含尖括号），为空时使用 DefaultReasoningTags
+func NewThinkingStreamFilter(tagNames ...string) *ThinkingStreamFilter {
+	if len(tagNames) == 0 {
+		tagNames = DefaultReasoningTags
+	}
+
+	f := &ThinkingStreamFilter{inside: -1}
+	for _, name := range tagNames {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		f.openTags = append(f.openTags, "<"+name+">")
+		f.closeTags = append(f.closeTags, "</"+name+">")
+	}
+	return f
+}
+
+// Write 处理一个chunk，返回可以安全输出给客户端的内容
+func (f *ThinkingStreamFilter) Write(chunk string) string {
+	if chunk == "" && f.pending == "" {
+		return ""
+	}
+
+	buf := f.pending + chunk
+	f.pending = ""
+
+	var result strings.Builder
+	for buf != "" {
+		if f.inside >= 0 {
+			// 当前在思维链内部，查找结束标签
+			closeTag := f.closeTags[f.inside]
+			if idx := strings.Index(buf, closeTag); idx != -1 {
+				buf = buf[idx+len(closeTag):]
+				f.inside = -1
+				continue
+			}
+			// 没找到结束标签：丢弃思维链内容，但保留可能是结束标签前缀的尾部
+			f.pending = partialTagSuffix(buf, []string{closeTag})
+			break
+		}
+
+		// 当前在思维链外部，查找最早出现的开始标签
+		tagIndex, idx := f.findOpenTag(buf)
+		if idx != -1 {
+			result.WriteString(buf[:idx])
+			buf = buf[idx+len(f.openTags[tagIndex]):]
+			f.inside = tagIndex
+			continue
+		}
+
+		// 没找到开始标签：输出内容，但保留可能是开始标签前缀的尾部
+		f.pending = partialTagSuffix(buf, f.openTags)
+		result.WriteString(buf[:len(buf)-len(f.pending)])
+		break
+	}
+
+	return result.String()
+}
+
+// Flush 流结束时调用，返回残留的待定内容（若仍在思维链内部则丢弃）并重置状态
+func (f *ThinkingStreamFilter) Flush() string {
+	rest := ""
+	if f.inside < 0 {
+		rest = f.pending
+	}
+	f.pending = ""
+	f.inside = -1
+	return rest
+}
+
+// InsideThinking 当前是否处于思维链内部
+func (f *ThinkingStreamFilter) InsideThinking() bool {
+	return f.inside >= 0
+}
+
+// findOpenTag 查找最早出现的开始标签，返回标签下标和位置
+func (f *ThinkingStreamFilter) findOpenTag(s string) (int, int) {
+	tagIndex, pos := -1, -1
+	for i, tag := range f.openTags {
+		if idx := strings.Index(s, tag); idx != -1 && (pos == -1 || idx < pos) {
+			tagIndex, pos = i, idx
+		}
+	}
+	return tagIndex, pos
+}
+
+// partialTagSuffix 返回 s 中可能是某个标签前缀的最长尾部（从最后一个 '<' 开始）
+func partialTagSuffix(s string, tags []string) string {
+	idx := strings.LastIndexByte(s, '<')
+	if idx == -1 {
+		return ""
+	}
+	suffix := s[idx:]
+	for _, tag := range tags {
+		if len(suffix) < len(tag) && strings.HasPrefix(tag, suffix) {
+			return suffix
+		}
+	}
+	return ""
+}
+
+// FilterReasoningTags 过滤指定名称的思维链标签（FilterThinkingTags 的可配置版本）
+// 未闭合的思维链内容会被整体丢弃
+func FilterReasoningTags(content string, tagNames []string) string {
+	if content == "" {
+		return content
+	}
+
+	f := NewThinkingStreamFilter(tagNames...)
+	filtered := f.Write(content) + f.Flush()
+
+	filtered = strings.TrimSpace(filtered)
+	return multipleNewlinesPattern.ReplaceAllString(filtered, "\n\n")
+}
+
+// ContainsReasoningTags 检查内容是否包含指定名称的思维链开始标签
+func ContainsReasoningTags(content string, tagNames []string) bool {
+	if len(tagNames) == 0 {
+		tagNames = DefaultReasoningTags
+	}
+	for _, name := range tagNames {
+		if name != "" && strings.Contains(content, "<"+name+">") {
+			return true
+		}
+	}
+	return false
+}
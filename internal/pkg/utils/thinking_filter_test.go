@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// streamThrough 将内容按给定大小切分后逐块写入过滤器
+func streamThrough(f *ThinkingStreamFilter, content string, chunkSize int) string {
+	var out strings.Builder
+	for len(content) > 0 {
+		n := chunkSize
+		if n > len(content) {
+			n = len(content)
+		}
+		out.WriteString(f.Write(content[:n]))
+		content = content[n:]
+	}
+	out.WriteString(f.Flush())
+	return out.String()
+}
+
+func TestThinkingStreamFilter_Chunks(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     []string
+		chunks   []string
+		expected string
+	}{
+		{
+			name:     "无思维链",
+			chunks:   []string{"你好，", "我是助手"},
+			expected: "你好，我是助手",
+		},
+		{
+			name:     "完整标签在单个chunk内",
+			chunks:   []string{"<think>思考</think>回答"},
+			expected: "回答",
+		},
+		{
+			name:     "开始标签跨chunk",
+			chunks:   []string{"前缀<thi", "nk>思考内容</think>回答"},
+			expected: "前缀回答",
+		},
+		{
+			name:     "结束标签跨chunk",
+			chunks:   []string{"<think>思考</th", "ink>回答"},
+			expected: "回答",
+		},
+		{
+			name:     "标签逐字节到达",
+			chunks:   strings.Split("<think>x</think>ok", ""),
+			expected: "ok",
+		},
+		{
+			name:     "非标签的尖括号原样输出",
+			chunks:   []string{"a<b", " 且 c>d"},
+			expected: "a<b 且 c>d",
+		},
+		{
+			name:     "流结束时残留的标签前缀原样输出",
+			chunks:   []string{"结尾<thi"},
+			expected: "结尾<thi",
+		},
+		{
+			name:     "未闭合的思维链被丢弃",
+			chunks:   []string{"回答<think>未结束的思考"},
+			expected: "回答",
+		},
+		{
+			name:     "自定义标签",
+			tags:     []string{"reasoning"},
+			chunks:   []string{"<reaso", "ning>推理</reasoning>结论<think>保留</think>"},
+			expected: "结论<think>保留</think>",
+		},
+		{
+			name:     "多个标签名",
+			tags:     []string{"think", "reasoning"},
+			chunks:   []string{"<reasoning>a</reasoning>1", "<think>b</think>2"},
+			expected: "12",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewThinkingStreamFilter(tt.tags...)
+			var out strings.Builder
+			for _, c := range tt.chunks {
+				out.WriteString(f.Write(c))
+			}
+			out.WriteString(f.Flush())
+			if got := out.String(); got != tt.expected {
+				t.Errorf("got %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestThinkingStreamFilter_AllSplitPoints(t *testing.T) {
+	content := "开始<think>第一段思考</think>中间<think>第二段</think>结束"
+	expected := "开始中间结束"
+
+	for size := 1; size <= len(content); size++ {
+		f := NewThinkingStreamFilter()
+		if got := streamThrough(f, content, size); got != expected {
+			t.Fatalf("chunkSize=%d: got %q, expected %q", size, got, expected)
+		}
+	}
+}
+
+// TestThinkingStreamFilter_ParallelStreams 模拟多个用户同时流式对话，
+// 每个流的思维链状态必须互不影响
+func TestThinkingStreamFilter_ParallelStreams(t *testing.T) {
+	const streams = 64
+
+	var wg sync.WaitGroup
+	errs := make(chan error, streams)
+
+	for i := 0; i < streams; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+
+			// 一半的流在思维链中停留很久，另一半没有思维链
+			var content, expected string
+			if id%2 == 0 {
+				content = fmt.Sprintf("<think>%s</think>回答%d", strings.Repeat("思考", 200), id)
+				expected = fmt.Sprintf("回答%d", id)
+			} else {
+				content = fmt.Sprintf("直接回答%d：%s", id, strings.Repeat("内容", 200))
+				expected = content
+			}
+
+			f := NewThinkingStreamFilter()
+			for round := 0; round < 20; round++ {
+				if got := streamThrough(f, content, 1+(id+round)%7); got != expected {
+					errs <- fmt.Errorf("stream %d round %d: got %q, expected %q", id, round, got, expected)
+					return
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestFilterReasoningTags(t *testing.T) {
+	got := FilterReasoningTags("<reasoning>推理</reasoning>\n\n\n\n结论", []string{"reasoning"})
+	if got != "结论" {
+		t.Errorf("FilterReasoningTags() = %q, expected %q", got, "结论")
+	}
+}
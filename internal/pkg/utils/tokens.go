@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// asciiCharsPerToken 非CJK文本平均每个token对应的字符数
+const asciiCharsPerToken = 4
+
+// isCJK 判断字符是否为中日韩文字或全角标点（这类字符通常每个字占约1个token）
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		(r >= 0x3000 && r <= 0x303F) || // CJK标点
+		(r >= 0xFF00 && r <= 0xFFEF) // 全角字符
+}
+
+// EstimateTokens 估算文本的token数量
+// 不依赖具体分词器：CJK字符按每字1个token计，其余字符按每4个字符1个token计，结果偏保守
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+
+	cjk, other := 0, 0
+	for _, r := range text {
+		if isCJK(r) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return cjk + (other+asciiCharsPerToken-1)/asciiCharsPerToken
+}
+
+// TruncateToTokens 将文本截断到估算不超过 maxTokens 个token
+// 发生截断时在末尾追加截断说明
+func TruncateToTokens(text string, maxTokens int) string {
+	if maxTokens <= 0 {
+		return ""
+	}
+	if EstimateTokens(text) <= maxTokens {
+		return text
+	}
+
+	cjk, other := 0, 0
+	cut := 0
+	for i, r := range text {
+		if isCJK(r) {
+			cjk++
+		} else {
+			other++
+		}
+		if cjk+(other+asciiCharsPerToken-1)/asciiCharsPerToken > maxTokens {
+			break
+		}
+		cut = i + utf8.RuneLen(r)
+	}
+	return fmt.Sprintf("%s\n...[内容过长已截断，原始长度 %d 字符]", text[:cut], utf8.RuneCountInString(text))
+}
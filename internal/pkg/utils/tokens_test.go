@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"empty", "", 0},
+		{"ascii", "abcdefgh", 2},
+		{"ascii rounds up", "abcde", 2},
+		{"cjk", "青岛岗位", 4},
+		{"cjk punctuation", "你好，世界。", 6},
+		{"mixed", "Java开发", 3},
+	}
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.text); got != tt.want {
+			t.Errorf("%s: EstimateTokens(%q) = %d, want %d", tt.name, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateToTokens(t *testing.T) {
+	if got := TruncateToTokens("短文本", 10); got != "短文本" {
+		t.Fatalf("text within budget should be unchanged, got %q", got)
+	}
+
+	long := strings.Repeat("岗位信息", 100)
+	got := TruncateToTokens(long, 20)
+	if !strings.HasPrefix(got, strings.Repeat("岗位信息", 5)) || !strings.Contains(got, "已截断") {
+		t.Fatalf("unexpected truncation: %q", got)
+	}
+	if !strings.Contains(got, "原始长度 400 字符") {
+		t.Fatalf("truncation note should report rune length: %q", got)
+	}
+}
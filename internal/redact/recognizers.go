@@ -0,0 +1,109 @@
+package redact
+
+import (
+	"regexp"
+	"strings"
+)
+
+// 识别器类型
+const (
+	KindPhone    = "phone"
+	KindIDCard   = "id_card"
+	KindBankCard = "bank_card"
+	KindEmail    = "email"
+	KindAddress  = "address"
+)
+
+// kindLabels 占位符中使用的类型名称
+var kindLabels = map[string]string{
+	KindPhone:    "手机号",
+	KindIDCard:   "身份证号",
+	KindBankCard: "银行卡号",
+	KindEmail:    "邮箱",
+	KindAddress:  "地址",
+}
+
+// span 识别出的敏感信息位置
+type span struct {
+	start, end int
+	kind       string
+}
+
+// recognizer 敏感信息识别器
+type recognizer struct {
+	kind    string
+	pattern *regexp.Regexp
+	group   int               // 取第几个子匹配作为敏感内容（0为整个匹配）
+	digits  bool              // 匹配前后不能紧挨数字（避免截取更长数字串的一部分）
+	valid   func(string) bool // 额外校验，为nil时不校验
+}
+
+// recognizers 按优先级排列，位置重叠时先出现的识别器优先
+var recognizers = []recognizer{
+	{kind: KindIDCard, pattern: regexp.MustCompile(`\d{17}[\dXx]`), digits: true, valid: validIDCard},
+	{kind: KindBankCard, pattern: regexp.MustCompile(`\d{4}(?:[ -]?\d{4}){3}\d{0,3}`), digits: true, valid: validLuhn},
+	{kind: KindPhone, pattern: regexp.MustCompile(`(?:\+?86[ -]?)?1[3-9]\d[ -]?\d{4}[ -]?\d{4}`), digits: true},
+	{kind: KindEmail, pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`)},
+	{kind: KindAddress, pattern: regexp.MustCompile(`(?m)(?:家庭住址|现住址|居住地址|通讯地址|联系地址|住址|地址)\s*[:：]\s*([^\s][^\n\t]*?)(?:\s{2,}|\t|$)`), group: 1},
+}
+
+// find 返回文本中该识别器匹配到的位置
+func (r recognizer) find(text string) []span {
+	var spans []span
+	for _, m := range r.pattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[2*r.group], m[2*r.group+1]
+		if start < 0 {
+			continue
+		}
+		if r.digits && (start > 0 && isDigit(text[start-1]) || end < len(text) && isDigit(text[end])) {
+			continue
+		}
+		if r.valid != nil && !r.valid(text[start:end]) {
+			continue
+		}
+		spans = append(spans, span{start: start, end: end, kind: r.kind})
+	}
+	return spans
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// idCardWeights 18位身份证号前17位的加权因子
+var idCardWeights = [17]int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+
+// idCardCheckCodes 加权和对11取模后对应的校验码
+const idCardCheckCodes = "10X98765432"
+
+// validIDCard 校验18位身份证号的校验码
+func validIDCard(id string) bool {
+	if len(id) != 18 {
+		return false
+	}
+	sum := 0
+	for i, w := range idCardWeights {
+		sum += int(id[i]-'0') * w
+	}
+	return idCardCheckCodes[sum%11] == strings.ToUpper(id[17:])[0]
+}
+
+// validLuhn 使用Luhn算法校验银行卡号（忽略空格和连字符）
+func validLuhn(card string) bool {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(card)
+	if len(digits) < 16 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
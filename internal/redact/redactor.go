@@ -0,0 +1,178 @@
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"qd-sc/internal/config"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 脱敏方式
+const (
+	ModeMask = "mask" // 替换为可还原的占位符，如 [手机号1]
+	ModeHash = "hash" // 替换为加盐哈希，如 [手机号#3f2a9c1b]，相同内容得到相同结果
+	ModeDrop = "drop" // 直接删除
+)
+
+// Redactor 敏感信息脱敏器
+// 识别手机号、身份证号（校验码校验）、银行卡号（Luhn校验）、邮箱和地址
+type Redactor struct {
+	enabled bool
+	mode    string
+	salt    string
+	kinds   map[string]bool
+}
+
+// New 创建脱敏器
+func New(cfg *config.RedactionConfig) *Redactor {
+	r := &Redactor{
+		enabled: cfg.Enabled != nil && *cfg.Enabled,
+		mode:    cfg.Mode,
+		salt:    cfg.Salt,
+		kinds:   make(map[string]bool),
+	}
+	if r.mode != ModeHash && r.mode != ModeDrop {
+		r.mode = ModeMask
+	}
+	for _, kind := range cfg.Kinds {
+		r.kinds[strings.TrimSpace(kind)] = true
+	}
+	return r
+}
+
+// Vault 占位符与原文的对应关系（mask 模式下可据此还原）
+// 同一个 Vault 中相同内容使用相同占位符，编号按类型递增
+type Vault struct {
+	mu       sync.Mutex
+	tokens   map[string]string // 占位符 -> 原文
+	reverse  map[string]string // 类型 + 原文 -> 占位符
+	counters map[string]int
+}
+
+// NewVault 创建占位符表
+func NewVault() *Vault {
+	return &Vault{
+		tokens:   make(map[string]string),
+		reverse:  make(map[string]string),
+		counters: make(map[string]int),
+	}
+}
+
+// placeholder 返回原文对应的占位符，不存在时分配新编号
+func (v *Vault) placeholder(kind, value string) string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key := kind + "\x00" + value
+	if token, ok := v.reverse[key]; ok {
+		return token
+	}
+	v.counters[kind]++
+	token := fmt.Sprintf("[%s%d]", kindLabels[kind], v.counters[kind])
+	v.tokens[token] = value
+	v.reverse[key] = token
+	return token
+}
+
+// Restore 将文本中的占位符还原为原文
+func (v *Vault) Restore(text string) string {
+	if v == nil {
+		return text
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if len(v.tokens) == 0 {
+		return text
+	}
+	pairs := make([]string, 0, len(v.tokens)*2)
+	for token, value := range v.tokens {
+		pairs = append(pairs, token, value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// Len 已记录的占位符数量
+func (v *Vault) Len() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return len(v.tokens)
+}
+
+// Enabled 是否启用脱敏
+func (r *Redactor) Enabled() bool {
+	return r != nil && r.enabled
+}
+
+// Redact 脱敏文本，mask 模式下占位符记录在 vault 中（vault 为nil时不保留对应关系）
+func (r *Redactor) Redact(text string, vault *Vault) string {
+	if !r.Enabled() || text == "" {
+		return text
+	}
+	spans := r.find(text)
+	if len(spans) == 0 {
+		return text
+	}
+	if vault == nil {
+		vault = NewVault()
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+	last := 0
+	for _, s := range spans {
+		b.WriteString(text[last:s.start])
+		b.WriteString(r.replacement(s.kind, text[s.start:s.end], vault))
+		last = s.end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// String 脱敏用于写日志的文本
+func (r *Redactor) String(text string) string {
+	return r.Redact(text, nil)
+}
+
+// replacement 按脱敏方式生成替换文本
+func (r *Redactor) replacement(kind, value string, vault *Vault) string {
+	switch r.mode {
+	case ModeDrop:
+		return ""
+	case ModeHash:
+		sum := sha256.Sum256([]byte(r.salt + value))
+		return fmt.Sprintf("[%s#%s]", kindLabels[kind], hex.EncodeToString(sum[:4]))
+	default:
+		return vault.placeholder(kind, value)
+	}
+}
+
+// find 找出所有敏感信息位置，按位置排序并去除重叠（优先级高的识别器优先）
+func (r *Redactor) find(text string) []span {
+	var selected []span
+	for _, rec := range recognizers {
+		if len(r.kinds) > 0 && !r.kinds[rec.kind] {
+			continue
+		}
+		for _, s := range rec.find(text) {
+			if !overlaps(selected, s) {
+				selected = append(selected, s)
+			}
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].start < selected[j].start })
+	return selected
+}
+
+// overlaps 判断 s 是否与已选位置重叠
+func overlaps(selected []span, s span) bool {
+	for _, o := range selected {
+		if s.start < o.end && o.start < s.end {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+
+	"qd-sc/internal/config"
+)
+
+func newTestRedactor(mode string, kinds ...string) *Redactor {
+	enabled := true
+	return New(&config.RedactionConfig{Enabled: &enabled, Mode: mode, Salt: "s", Kinds: kinds})
+}
+
+const testText = "张伟  电话：138-1234-5678  邮箱：zhangwei@example.com\n" +
+	"身份证：370202199001011237\n" +
+	"工资卡：6222 0212 3456 7894\n" +
+	"家庭住址：青岛市市南区香港中路10号  期望薪资：8k"
+
+func TestRedact_MaskAndRestore(t *testing.T) {
+	r := newTestRedactor(ModeMask)
+	vault := NewVault()
+	got := r.Redact(testText, vault)
+
+	for _, secret := range []string{"1234-5678", "zhangwei@", "370202199001011237", "7894", "香港中路"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("%q not redacted: %s", secret, got)
+		}
+	}
+	for _, token := range []string{"[手机号1]", "[邮箱1]", "[身份证号1]", "[银行卡号1]", "[地址1]"} {
+		if !strings.Contains(got, token) {
+			t.Errorf("missing placeholder %s: %s", token, got)
+		}
+	}
+	if !strings.Contains(got, "期望薪资：8k") || !strings.HasPrefix(got, "张伟") {
+		t.Errorf("non-sensitive text changed: %s", got)
+	}
+	if restored := vault.Restore(got); restored != testText {
+		t.Errorf("restore mismatch:\n%s\nwant:\n%s", restored, testText)
+	}
+
+	// 同一占位符表中相同内容复用占位符
+	if again := r.Redact("再次联系 13812345678 或 138-1234-5678", vault); strings.Count(again, "[手机号") != 2 || !strings.Contains(again, "[手机号2]") {
+		t.Errorf("unexpected numbering: %s", again)
+	}
+}
+
+func TestRedact_RejectsInvalidNumbers(t *testing.T) {
+	r := newTestRedactor(ModeMask)
+	for _, text := range []string{
+		"订单号 370202199001011238",     // 身份证校验码错误
+		"卡号 6222021234567890",        // Luhn 校验失败
+		"编号 2138123456789",           // 手机号是更长数字串的一部分
+		"2019.07-2021.06 青岛某某科技有限公司", // 日期不应被识别
+	} {
+		if got := r.String(text); got != text {
+			t.Errorf("String(%q) = %q, want unchanged", text, got)
+		}
+	}
+}
+
+func TestRedact_HashAndDrop(t *testing.T) {
+	hashed := newTestRedactor(ModeHash).String("手机 13812345678，备用 13812345678")
+	parts := strings.Split(hashed, "[手机号#")
+	if len(parts) != 3 || parts[1][:8] != parts[2][:8] || strings.Contains(hashed, "1381234") {
+		t.Errorf("unexpected hash output: %s", hashed)
+	}
+
+	if got := newTestRedactor(ModeDrop).String("邮箱：a@b.cn 谢谢"); got != "邮箱： 谢谢" {
+		t.Errorf("drop = %q", got)
+	}
+}
+
+func TestRedact_KindsAndDisabled(t *testing.T) {
+	got := newTestRedactor(ModeMask, KindEmail).String("13812345678 a@b.cn")
+	if got != "13812345678 [邮箱1]" {
+		t.Errorf("kinds filter = %q", got)
+	}
+
+	disabled := false
+	r := New(&config.RedactionConfig{Enabled: &disabled})
+	if got := r.String(testText); got != testText {
+		t.Errorf("disabled redactor changed text")
+	}
+	var nilRedactor *Redactor
+	if got := nilRedactor.String(testText); got != testText {
+		t.Errorf("nil redactor changed text")
+	}
+}
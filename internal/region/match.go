@@ -0,0 +1,121 @@
+package region
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// 匹配程度，越高越可信
+const (
+	scoreExact    = 100 // 代码、名称、别名或拼音完全相同
+	scoreShort    = 90  // 去掉“区”“县”等后缀后相同，如 黄岛 -> 黄岛区
+	scorePinyin   = 80  // 拼音带后缀、为前缀或只差一个字母，如 huangdaoqu、huandao
+	scoreContains = 60  // 文本中包含区域名称，如 黄岛那边
+	scorePartial  = 50  // 区域名称中包含文本，如 塔什库尔干 -> 塔什库尔干塔吉克自治县
+)
+
+// regionSuffixes 区域名称后缀，按长度从长到短排列
+var regionSuffixes = []string{"自治县", "新区", "街道", "区", "县", "市", "镇", "乡"}
+
+// pinyinSuffixes 拼音形式的区域后缀
+var pinyinSuffixes = []string{"jiedao", "xinqu", "xian", "shi", "qu", "zhen", "xiang"}
+
+// matchScore 文本与区域的匹配程度，0 表示不匹配
+func matchScore(r *Region, query string) int {
+	if r.Level == LevelDistrict && query == strings.ToLower(r.Code) {
+		return scoreExact
+	}
+
+	score := 0
+	names := append([]string{r.Name}, r.Aliases...)
+	for _, name := range names {
+		name = strings.ToLower(name)
+		short := shortName(name)
+		switch {
+		case query == name:
+			return scoreExact
+		case shortName(query) == short && utf8.RuneCountInString(short) >= 2:
+			score = maxInt(score, scoreShort)
+		case strings.Contains(query, name) || (utf8.RuneCountInString(short) >= 2 && strings.Contains(query, short)):
+			score = maxInt(score, scoreContains)
+		case utf8.RuneCountInString(query) >= 2 && strings.Contains(name, query):
+			score = maxInt(score, scorePartial)
+		}
+	}
+
+	if r.Pinyin != "" && isLetters(query) {
+		switch {
+		case query == r.Pinyin:
+			return scoreExact
+		case trimPinyinSuffix(query) == r.Pinyin,
+			len(query) >= 3 && strings.HasPrefix(r.Pinyin, query),
+			len(r.Pinyin) >= 5 && withinOneEdit(trimPinyinSuffix(query), r.Pinyin):
+			score = maxInt(score, scorePinyin)
+		}
+	}
+	return score
+}
+
+// shortName 去掉区域后缀，去掉后不足两个字时保留原名
+func shortName(name string) string {
+	for _, suffix := range regionSuffixes {
+		if short := strings.TrimSuffix(name, suffix); short != name && utf8.RuneCountInString(short) >= 2 {
+			return short
+		}
+	}
+	return name
+}
+
+// trimPinyinSuffix 去掉拼音形式的区域后缀，如 huangdaoqu -> huangdao
+func trimPinyinSuffix(query string) string {
+	for _, suffix := range pinyinSuffixes {
+		if short := strings.TrimSuffix(query, suffix); short != query && len(short) >= 2 {
+			return short
+		}
+	}
+	return query
+}
+
+// isLetters 是否只包含英文字母
+func isLetters(s string) bool {
+	for _, c := range s {
+		if c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// withinOneEdit 两个字符串的编辑距离是否不超过1
+func withinOneEdit(a, b string) bool {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	if len(a)-len(b) > 1 {
+		return false
+	}
+	i, j, edits := 0, 0, 0
+	for i < len(a) && j < len(b) {
+		if a[i] == b[j] {
+			i++
+			j++
+			continue
+		}
+		edits++
+		if edits > 1 {
+			return false
+		}
+		if len(a) == len(b) {
+			j++
+		}
+		i++
+	}
+	return edits+(len(a)-i)+(len(b)-j) <= 1
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,181 @@
+package region
+
+import (
+	"errors"
+	"fmt"
+	"qd-sc/internal/config"
+	"strings"
+)
+
+// ErrNotFound 没有匹配的区域
+var ErrNotFound = errors.New("未找到匹配的区域")
+
+// ErrAmbiguous 区域名称匹配到多个区县
+var ErrAmbiguous = errors.New("区域名称有多个可能")
+
+// Level 区域层级
+type Level int
+
+const (
+	LevelCity     Level = iota // 城市
+	LevelDistrict              // 区县
+	LevelStreet                // 街道、镇
+)
+
+// Region 区域节点
+type Region struct {
+	Name     string
+	Code     string // 岗位API区域代码，街道为所属区县的代码，城市为空
+	Level    Level
+	Aliases  []string
+	Pinyin   string
+	Parent   *Region
+	Children []*Region
+}
+
+// District 所属区县，区县返回自身，城市返回nil
+func (r *Region) District() *Region {
+	for n := r; n != nil; n = n.Parent {
+		if n.Level == LevelDistrict {
+			return n
+		}
+	}
+	return nil
+}
+
+// DisplayName 展示名称，街道附带所属区县，如 "辛安街道（黄岛区）"
+func (r *Region) DisplayName() string {
+	if r.Level == LevelStreet && r.Parent != nil {
+		return fmt.Sprintf("%s（%s）", r.Name, r.Parent.Name)
+	}
+	return r.Name
+}
+
+// Tree 城市 -> 区县 -> 街道 的区域树
+type Tree struct {
+	city      *Region
+	districts []*Region
+	byCode    map[string]*Region
+	nodes     []*Region // 全部区县和街道
+}
+
+// New 根据城市配置构建区域树，区县顺序与配置一致（config.Load 已按代码排序）
+func New(city *config.CityConfig) *Tree {
+	t := &Tree{
+		city:   &Region{Name: city.Name, Level: LevelCity},
+		byCode: make(map[string]*Region),
+	}
+
+	districts := city.Districts
+	if len(districts) == 0 {
+		for name, code := range city.AreaCodes {
+			districts = append(districts, config.DistrictConfig{Name: name, Code: code})
+		}
+	}
+	for _, d := range districts {
+		district := &Region{
+			Name:    d.Name,
+			Code:    d.Code,
+			Level:   LevelDistrict,
+			Aliases: d.Aliases,
+			Pinyin:  strings.ToLower(d.Pinyin),
+			Parent:  t.city,
+		}
+		for _, s := range d.Streets {
+			street := &Region{
+				Name:    s.Name,
+				Code:    d.Code,
+				Level:   LevelStreet,
+				Aliases: s.Aliases,
+				Pinyin:  strings.ToLower(s.Pinyin),
+				Parent:  district,
+			}
+			district.Children = append(district.Children, street)
+		}
+		t.city.Children = append(t.city.Children, district)
+		t.districts = append(t.districts, district)
+		t.byCode[d.Code] = district
+		t.nodes = append(t.nodes, district)
+		t.nodes = append(t.nodes, district.Children...)
+	}
+	return t
+}
+
+// City 城市节点
+func (t *Tree) City() *Region {
+	return t.city
+}
+
+// Districts 全部区县
+func (t *Tree) Districts() []*Region {
+	return t.districts
+}
+
+// ByCode 按区域代码查找区县，不存在时返回nil
+func (t *Tree) ByCode(code string) *Region {
+	return t.byCode[code]
+}
+
+// Resolve 将用户文本（区域代码、名称、简称、别名、拼音或街道名称）解析为区域
+// 取匹配程度最高的区域；最高的几个区域属于不同区县时返回 ErrAmbiguous，没有匹配时返回 ErrNotFound
+// 错误信息不含原文本，附带可选区域或候选区域，由调用方补充上下文
+func (t *Tree) Resolve(text string) (*Region, error) {
+	query := t.normalize(text)
+	if query == "" {
+		return nil, fmt.Errorf("%w（区域为空）", ErrNotFound)
+	}
+
+	var best []*Region
+	bestScore := 0
+	for _, r := range t.nodes {
+		score := matchScore(r, query)
+		switch {
+		case score == 0 || score < bestScore:
+		case score > bestScore:
+			best, bestScore = []*Region{r}, score
+		default:
+			best = append(best, r)
+		}
+	}
+	if len(best) == 0 {
+		return nil, fmt.Errorf("%w（可选区域: %s）", ErrNotFound, t.names())
+	}
+
+	// 同一区县的区县和街道同时匹配时取区县
+	chosen := best[0]
+	var candidates []string
+	seen := make(map[string]bool)
+	for _, r := range best {
+		if r.Level < chosen.Level {
+			chosen = r
+		}
+		if !seen[r.Code] {
+			seen[r.Code] = true
+			candidates = append(candidates, r.DisplayName())
+		}
+	}
+	if len(candidates) > 1 {
+		return nil, fmt.Errorf("%w，可能是 %s，请指明具体区域", ErrAmbiguous, strings.Join(candidates, "、"))
+	}
+	return chosen, nil
+}
+
+// normalize 去掉空白和城市名前缀并转为小写，如 "青岛 黄岛区" -> "黄岛区"
+func (t *Tree) normalize(text string) string {
+	text = strings.ToLower(strings.Join(strings.Fields(text), ""))
+	if city := strings.TrimSuffix(t.city.Name, "市"); city != "" {
+		if rest := strings.TrimPrefix(text, city); rest != "" {
+			text = rest
+		}
+	}
+	return text
+}
+
+// names 全部区县名称
+func (t *Tree) names() string {
+	names := make([]string, len(t.districts))
+	for i, d := range t.districts {
+		names[i] = d.Name
+	}
+	return strings.Join(names, "、")
+}
@@ -0,0 +1,74 @@
+package region
+
+import (
+	"errors"
+	"testing"
+
+	"qd-sc/internal/config"
+)
+
+func testTree() *Tree {
+	return New(&config.CityConfig{
+		Name: "青岛",
+		Districts: []config.DistrictConfig{
+			{Name: "市南区", Code: "0", Pinyin: "shinan"},
+			{Name: "市北区", Code: "1", Pinyin: "shibei"},
+			{Name: "崂山区", Code: "3", Pinyin: "laoshan"},
+			{Name: "黄岛区", Code: "4", Pinyin: "huangdao", Aliases: []string{"西海岸新区"}, Streets: []config.StreetConfig{
+				{Name: "辛安街道", Pinyin: "xinan"},
+			}},
+			{Name: "胶州市", Code: "7", Pinyin: "jiaozhou"},
+			{Name: "巴楚县", Code: "10", Pinyin: "bachu"},
+			{Name: "塔什库尔干塔吉克自治县", Code: "11", Pinyin: "tashikuergan", Aliases: []string{"塔县"}},
+		},
+	})
+}
+
+func TestResolve(t *testing.T) {
+	tree := testTree()
+	tests := map[string]string{
+		"黄岛":         "黄岛区",
+		"黄岛区":        "黄岛区",
+		"青岛市黄岛区":     "黄岛区",
+		"西海岸":        "黄岛区",
+		"4":          "黄岛区",
+		"huangdao":   "黄岛区",
+		"HuangDaoQu": "黄岛区",
+		"huandao":    "黄岛区",
+		"黄岛那边":       "黄岛区",
+		"市南":         "市南区",
+		"胶州":         "胶州市",
+		"10":         "巴楚县",
+		"塔县":         "塔什库尔干塔吉克自治县",
+		"塔什库尔干":      "塔什库尔干塔吉克自治县",
+		"辛安":         "辛安街道",
+	}
+	for text, want := range tests {
+		r, err := tree.Resolve(text)
+		if err != nil || r.Name != want {
+			t.Errorf("Resolve(%q) = %v, %v; want %s", text, r, err, want)
+		}
+	}
+
+	street, _ := tree.Resolve("辛安街道")
+	if street.Level != LevelStreet || street.Code != "4" || street.District().Name != "黄岛区" || street.DisplayName() != "辛安街道（黄岛区）" {
+		t.Fatalf("street should map to its district: %+v", street)
+	}
+
+	if _, err := tree.Resolve("北京"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if _, err := tree.Resolve("市南区和崂山区"); !errors.Is(err, ErrAmbiguous) {
+		t.Fatalf("expected ambiguous, got %v", err)
+	}
+}
+
+func TestNew_FromAreaCodes(t *testing.T) {
+	tree := New(&config.CityConfig{Name: "青岛", AreaCodes: map[string]string{"崂山区": "3"}})
+	if r := tree.ByCode("3"); r == nil || r.Name != "崂山区" || r.Parent != tree.City() {
+		t.Fatalf("ByCode(3) = %+v", r)
+	}
+	if r, err := tree.Resolve("崂山"); err != nil || r.Code != "3" {
+		t.Fatalf("Resolve(崂山) = %+v, %v", r, err)
+	}
+}
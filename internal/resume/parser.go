@@ -0,0 +1,216 @@
+package resume
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"qd-sc/internal/config"
+	"qd-sc/internal/model"
+	"qd-sc/internal/normalize"
+	contentutils "qd-sc/internal/pkg/utils"
+	"strings"
+	"time"
+)
+
+// 画像提取来源
+const (
+	SourceRules    = "rules"
+	SourceRulesLLM = "rules+llm"
+)
+
+// maxLLMInputRunes 发送给LLM的简历文本最大字符数
+const maxLLMInputRunes = 4000
+
+// Completer 非流式聊天补全接口（*client.LLMClient 满足该接口）
+type Completer interface {
+	ChatCompletion(req *model.ChatCompletionRequest) (*model.ChatCompletionResponse, error)
+}
+
+// Parser 简历画像解析器
+// 先用规则提取字段，启用LLM时再用一次结构化输出补全规则未提取到的字段
+type Parser struct {
+	cfg           *config.ResumeConfig
+	model         string
+	reasoningTags []string
+	areaCodes     map[string]string
+	completer     Completer
+	now           func() time.Time
+}
+
+// NewParser 创建简历画像解析器
+// completer 为nil时只使用规则提取
+func NewParser(cfg *config.Config, completer Completer) *Parser {
+	p := &Parser{
+		cfg:           &cfg.Resume,
+		model:         cfg.Resume.Model,
+		reasoningTags: cfg.LLM.ReasoningTags,
+		areaCodes:     cfg.City.AreaCodes,
+		completer:     completer,
+		now:           time.Now,
+	}
+	if p.model == "" {
+		p.model = cfg.LLM.Model
+	}
+	return p
+}
+
+// Parse 从OCR文本中提取求职者画像
+func (p *Parser) Parse(ctx context.Context, text string) *model.ResumeProfile {
+	start := time.Now()
+	profile := extractByRules(text, p.areaCodes, p.now())
+
+	if p.cfg.LLMEnabled != nil && *p.cfg.LLMEnabled && p.completer != nil {
+		if extracted, err := p.extractByLLM(ctx, text); err != nil {
+			log.Printf("LLM简历画像提取失败，使用规则结果: %v", err)
+		} else {
+			p.merge(profile, extracted)
+			profile.Source = SourceRulesLLM
+		}
+	}
+
+	log.Printf("简历画像提取完成: source=%s 学历=%s 经验=%s 意向=%s 技能数=%d 耗时=%v",
+		profile.Source, profile.Education, profile.Experience, profile.JobTitle(), len(profile.Skills), time.Since(start))
+	return profile
+}
+
+// llmProfile LLM结构化输出的字段
+type llmProfile struct {
+	Name              string   `json:"name"`
+	Education         string   `json:"education"`
+	ExperienceYears   float64  `json:"years_of_experience"`
+	Skills            []string `json:"skills"`
+	Titles            []string `json:"titles"`
+	DesiredTitle      string   `json:"desired_title"`
+	ExpectedSalaryMin int      `json:"expected_salary_min"`
+	ExpectedSalaryMax int      `json:"expected_salary_max"`
+	PreferredDistrict string   `json:"preferred_district"`
+}
+
+// resumeSystemPrompt LLM简历结构化提取提示词
+const resumeSystemPrompt = `你是简历信息提取器。请从用户提供的简历文本中提取信息，只输出一个JSON对象，不要输出任何解释：
+{
+  "name": "姓名",
+  "education": "最高学历，如 本科、大专、硕士",
+  "years_of_experience": 工作年限（数字，应届生为0）,
+  "skills": ["技能"],
+  "titles": ["曾任职位，最近的在前"],
+  "desired_title": "求职意向岗位",
+  "expected_salary_min": 期望月薪下限（元，数字）,
+  "expected_salary_max": 期望月薪上限（元，数字）,
+  "preferred_district": "期望工作的区/县"
+}
+简历中没有的信息使用空字符串、0或空数组，不要猜测。`
+
+// extractByLLM 使用LLM结构化输出提取画像
+func (p *Parser) extractByLLM(ctx context.Context, text string) (*llmProfile, error) {
+	runes := []rune(text)
+	if len(runes) > maxLLMInputRunes {
+		runes = runes[:maxLLMInputRunes]
+	}
+
+	maxTokens := p.cfg.LLMMaxTokens
+	temperature := 0.0
+	req := &model.ChatCompletionRequest{
+		Model: p.model,
+		Messages: []model.Message{
+			{Role: "system", Content: resumeSystemPrompt},
+			{Role: "user", Content: string(runes)},
+		},
+		Temperature:    &temperature,
+		MaxTokens:      &maxTokens,
+		ResponseFormat: &model.ResponseFormat{Type: "json_object"},
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.LLMTimeout)
+	defer cancel()
+
+	type completion struct {
+		resp *model.ChatCompletionResponse
+		err  error
+	}
+	done := make(chan completion, 1)
+	go func() {
+		resp, err := p.completer.ChatCompletion(req)
+		done <- completion{resp: resp, err: err}
+	}()
+
+	var resp *model.ChatCompletionResponse
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return nil, r.err
+		}
+		resp = r.resp
+	case <-ctx.Done():
+		// LLM客户端不支持context，超时后不再等待其返回
+		return nil, fmt.Errorf("LLM简历画像提取超时(%v): %w", p.cfg.LLMTimeout, ctx.Err())
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("LLM简历画像提取返回为空")
+	}
+	content, _ := resp.Choices[0].Message.Content.(string)
+	return parseLLMProfile(contentutils.FilterReasoningTags(content, p.reasoningTags))
+}
+
+// parseLLMProfile 解析LLM输出的JSON（兼容代码块包裹和前后多余文字）
+func parseLLMProfile(output string) (*llmProfile, error) {
+	start := strings.Index(output, "{")
+	end := strings.LastIndex(output, "}")
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("LLM输出中没有JSON对象: %q", output)
+	}
+
+	var extracted llmProfile
+	if err := json.Unmarshal([]byte(output[start:end+1]), &extracted); err != nil {
+		return nil, fmt.Errorf("解析LLM简历画像失败: %w", err)
+	}
+	return &extracted, nil
+}
+
+// merge 用LLM结果补全规则未提取到的字段，技能和职位取并集
+func (p *Parser) merge(profile *model.ResumeProfile, extracted *llmProfile) {
+	if profile.Name == "" {
+		profile.Name = strings.TrimSpace(extracted.Name)
+	}
+	if profile.Education == "" {
+		profile.Education = normalize.HighestEducation(extracted.Education)
+	}
+	if profile.Experience == "" && extracted.ExperienceYears > 0 {
+		profile.ExperienceYears = extracted.ExperienceYears
+		profile.Experience = normalize.ExperienceCode(extracted.ExperienceYears, false)
+	}
+	if profile.DesiredTitle == "" {
+		profile.DesiredTitle = strings.TrimSpace(extracted.DesiredTitle)
+	}
+	if profile.ExpectedSalaryMin == 0 && profile.ExpectedSalaryMax == 0 {
+		profile.ExpectedSalaryMin, profile.ExpectedSalaryMax = extracted.ExpectedSalaryMin, extracted.ExpectedSalaryMax
+	}
+	if profile.PreferredDistrict == "" {
+		profile.PreferredDistrict, profile.PreferredAreaCode = matchDistrict(extracted.PreferredDistrict, p.areaCodes)
+	}
+	profile.Titles = union(profile.Titles, extracted.Titles, 0)
+	profile.Skills = union(profile.Skills, extracted.Skills, maxSkills)
+}
+
+// union 合并两个字符串列表（忽略大小写去重），limit 大于0时限制结果数量
+func union(a, b []string, limit int) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var result []string
+	for _, list := range [][]string{a, b} {
+		for _, item := range list {
+			item = strings.TrimSpace(item)
+			key := strings.ToLower(item)
+			if item == "" || seen[key] {
+				continue
+			}
+			if limit > 0 && len(result) >= limit {
+				return result
+			}
+			seen[key] = true
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,85 @@
+package resume
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"qd-sc/internal/config"
+	"qd-sc/internal/model"
+)
+
+var testAreaCodes = map[string]string{"市南区": "0", "崂山区": "3", "黄岛区": "4"}
+
+const testResume = `张伟
+个人简历
+学历：本科
+求职意向：Java开发工程师
+期望薪资：8k-12k
+期望工作地点：崂山
+【专业技能】
+熟练掌握Java、Spring Boot；熟悉MySQL、Redis
+【工作经历】
+2019.07-2021.06 青岛某某科技有限公司 Java开发工程师
+2021.07-至今 青岛海洋软件有限公司 后端开发
+【教育背景】
+2015.09-2019.06 青岛大学 计算机科学与技术 本科`
+
+func TestExtractByRules(t *testing.T) {
+	now := time.Date(2024, 7, 1, 0, 0, 0, 0, time.Local)
+	p := extractByRules(testResume, testAreaCodes, now)
+
+	if p.Name != "张伟" || p.Education != "4" || p.DesiredTitle != "Java开发工程师" {
+		t.Fatalf("unexpected basics: %+v", p)
+	}
+	// 2019.07-2021.06 共23个月 + 2021.07-2024.07 共36个月
+	if p.ExperienceYears != 4.9 || p.Experience != "5" {
+		t.Fatalf("experience = %v (%s), want 4.9 (5)", p.ExperienceYears, p.Experience)
+	}
+	if p.ExpectedSalaryMin != 8000 || p.ExpectedSalaryMax != 12000 {
+		t.Fatalf("salary = %d-%d", p.ExpectedSalaryMin, p.ExpectedSalaryMax)
+	}
+	if p.PreferredDistrict != "崂山区" || p.PreferredAreaCode != "3" {
+		t.Fatalf("district = %s (%s)", p.PreferredDistrict, p.PreferredAreaCode)
+	}
+	if want := []string{"Java开发工程师", "后端开发"}; !reflect.DeepEqual(p.Titles, want) {
+		t.Fatalf("titles = %v, want %v", p.Titles, want)
+	}
+	if want := []string{"Java", "Spring Boot", "MySQL", "Redis"}; !reflect.DeepEqual(p.Skills, want) {
+		t.Fatalf("skills = %v, want %v", p.Skills, want)
+	}
+}
+
+// fakeCompleter 返回固定内容的LLM
+type fakeCompleter struct {
+	content string
+	req     *model.ChatCompletionRequest
+}
+
+func (f *fakeCompleter) ChatCompletion(req *model.ChatCompletionRequest) (*model.ChatCompletionResponse, error) {
+	f.req = req
+	return &model.ChatCompletionResponse{Choices: []model.Choice{{Message: model.Message{Role: "assistant", Content: f.content}}}}, nil
+}
+
+func TestParser_LLMFillsMissingFields(t *testing.T) {
+	cfg := &config.Config{}
+	enabled := true
+	cfg.Resume.LLMEnabled = &enabled
+	cfg.Resume.LLMTimeout = time.Second
+	cfg.City.AreaCodes = testAreaCodes
+
+	completer := &fakeCompleter{content: "```json\n" + `{"name":"李娜","education":"硕士","years_of_experience":2,"skills":["Python","java"],"titles":["数据分析师"],"desired_title":"数据分析","preferred_district":"黄岛区"}` + "\n```"}
+	p := NewParser(cfg, completer).Parse(context.Background(), "技能：Java\n联系电话：13800000000")
+
+	if completer.req.ResponseFormat == nil || completer.req.ResponseFormat.Type != "json_object" {
+		t.Fatalf("expected json_object response format, got %+v", completer.req.ResponseFormat)
+	}
+	if p.Source != SourceRulesLLM || p.Name != "李娜" || p.Education != "5" || p.Experience != "4" || p.DesiredTitle != "数据分析" || p.PreferredAreaCode != "4" {
+		t.Fatalf("unexpected profile: %+v", p)
+	}
+	// 规则结果在前，LLM结果去重后追加
+	if want := []string{"Java", "Python"}; !reflect.DeepEqual(p.Skills, want) {
+		t.Fatalf("skills = %v, want %v", p.Skills, want)
+	}
+}
@@ -0,0 +1,281 @@
+package resume
+
+import (
+	"math"
+	"qd-sc/internal/model"
+	"qd-sc/internal/normalize"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sectionHeadings 简历常见的段落标题
+var sectionHeadings = []string{
+	"个人信息", "基本信息", "求职意向", "教育背景", "教育经历", "工作经历", "工作经验", "实习经历",
+	"项目经验", "项目经历", "专业技能", "技能特长", "技能证书", "证书", "自我评价", "个人总结",
+}
+
+// workSections 工作经历类段落
+var workSections = map[string]bool{"工作经历": true, "工作经验": true, "实习经历": true}
+
+// titleSuffixes 常见职位名称后缀（用于从“时间 公司 职位”格式中识别职位）
+var titleSuffixes = []string{
+	"工程师", "经理", "主管", "专员", "助理", "设计师", "会计", "出纳", "教师", "老师", "销售", "文员", "总监",
+	"顾问", "开发", "运营", "司机", "技术员", "操作工", "普工", "护士", "医生", "分析师", "架构师", "实习生",
+	"主任", "店长", "厨师", "客服", "采购", "质检员", "讲师", "编辑", "策划",
+}
+
+// skillPrefixes 技能描述中需要去掉的程度词
+var skillPrefixes = []string{"熟练掌握", "熟练使用", "熟练", "精通", "熟悉", "掌握", "了解", "擅长", "会使用", "能够使用"}
+
+const maxSkills = 20
+
+var (
+	namePattern         = regexp.MustCompile(`姓\s*名\s*[：:]\s*(\p{Han}{2,4})`)
+	educationPattern    = regexp.MustCompile(`(?:最高学历|学\s*历)\s*[：:]\s*(\S+)`)
+	experienceYearsExpr = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*年(?:以上)?(?:的)?(?:工作|相关|行业)*经验`)
+	workYearsPattern    = regexp.MustCompile(`(?:工作年限|工作经验)\s*[：:]\s*(\d+(?:\.\d+)?)\s*年`)
+	desiredTitlePattern = regexp.MustCompile(`(?:求职意向|意向岗位|期望职位|期望岗位|应聘岗位|目标岗位)\s*[：:]\s*([^\s，,。；;|｜]+)`)
+	pastTitlePattern    = regexp.MustCompile(`(?:职\s*位|岗\s*位|职\s*务|担任)\s*[：:]\s*([^\s，,。；;|｜]+)`)
+	skillsLinePattern   = regexp.MustCompile(`^(?:专业技能|技能特长|掌握技能|技能)\s*[：:]\s*(.+)$`)
+	salaryLinePattern   = regexp.MustCompile(`(?:期望薪资|期望月薪|期望薪酬|期望工资|薪资要求)\s*[：:]\s*([^\n]+)`)
+	districtLinePattern = regexp.MustCompile(`(?:期望工作地点|期望地点|工作地点|期望城市|意向城市|期望地区|意向地区)\s*[：:]\s*([^\n]+)`)
+	// dateRangePattern 时间段，如 2018.07-2021.06、2019年3月 - 至今
+	dateRangePattern  = regexp.MustCompile(`((?:19|20)\d{2})\s*(?:[年./-]\s*(\d{1,2}))?\s*月?\s*[-~～至到—–]+\s*(?:((?:19|20)\d{2})\s*(?:[年./-]\s*(\d{1,2}))?\s*月?|(至今|今|现在))`)
+	skillSplitPattern = regexp.MustCompile(`[,，、;；/|｜\t]+|\s{2,}`)
+)
+
+// extractByRules 基于规则从简历文本中提取画像
+func extractByRules(text string, areaCodes map[string]string, now time.Time) *model.ResumeProfile {
+	profile := &model.ResumeProfile{Source: SourceRules}
+	sections := splitSections(text)
+
+	profile.Name = extractName(text)
+
+	if m := educationPattern.FindStringSubmatch(text); m != nil {
+		profile.Education = normalize.HighestEducation(m[1])
+	}
+	if profile.Education == "" {
+		profile.Education = normalize.HighestEducation(text)
+	}
+
+	years := 0.0
+	if m := workYearsPattern.FindStringSubmatch(text); m != nil {
+		years, _ = strconv.ParseFloat(m[1], 64)
+	} else if m := experienceYearsExpr.FindStringSubmatch(text); m != nil {
+		years, _ = strconv.ParseFloat(m[1], 64)
+	} else {
+		years = workYears(sections, now)
+	}
+	profile.ExperienceYears = years
+	profile.Experience = normalize.ExperienceCode(years, strings.Contains(text, "应届"))
+
+	if m := desiredTitlePattern.FindStringSubmatch(text); m != nil {
+		profile.DesiredTitle = m[1]
+	}
+	profile.Titles = pastTitles(sections)
+	profile.Skills = extractSkills(text, sections)
+
+	if m := salaryLinePattern.FindStringSubmatch(text); m != nil {
+		profile.ExpectedSalaryMin, profile.ExpectedSalaryMax, _ = normalize.SalaryRange(m[1])
+	}
+
+	district := ""
+	if m := districtLinePattern.FindStringSubmatch(text); m != nil {
+		district = m[1]
+	} else {
+		district = sections["求职意向"]
+	}
+	profile.PreferredDistrict, profile.PreferredAreaCode = matchDistrict(district, areaCodes)
+
+	return profile
+}
+
+// splitSections 按段落标题切分简历，返回 标题 -> 段落内容（同名段落合并）
+func splitSections(text string) map[string]string {
+	sections := make(map[string]string)
+	current := ""
+	for _, line := range strings.Split(text, "\n") {
+		if heading := sectionHeading(line); heading != "" {
+			current = heading
+			continue
+		}
+		if current != "" {
+			sections[current] += line + "\n"
+		}
+	}
+	return sections
+}
+
+// sectionHeading 判断一行是否为段落标题（去掉装饰符号后只有标题本身）
+func sectionHeading(line string) string {
+	trimmed := strings.Trim(strings.TrimSpace(line), "【】[]■●◆#*-—=：: ")
+	for _, h := range sectionHeadings {
+		if trimmed == h {
+			return h
+		}
+	}
+	return ""
+}
+
+// extractName 提取姓名：优先“姓名：”字段，其次首行的2-4个汉字
+func extractName(text string) string {
+	if m := namePattern.FindStringSubmatch(text); m != nil {
+		return m[1]
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		runes := []rune(line)
+		if len(runes) >= 2 && len(runes) <= 4 && isAllHan(line) && !strings.Contains(line, "简历") && sectionHeading(line) == "" {
+			return line
+		}
+		return ""
+	}
+	return ""
+}
+
+func isAllHan(s string) bool {
+	for _, r := range s {
+		if r < 0x4E00 || r > 0x9FFF {
+			return false
+		}
+	}
+	return true
+}
+
+// workYears 根据工作经历中的时间段累计工作年限
+func workYears(sections map[string]string, now time.Time) float64 {
+	months := 0
+	for name, content := range sections {
+		if !workSections[name] || name == "实习经历" {
+			continue
+		}
+		for _, m := range dateRangePattern.FindAllStringSubmatch(content, -1) {
+			start := monthIndex(m[1], m[2])
+			end := monthIndex(strconv.Itoa(now.Year()), strconv.Itoa(int(now.Month())))
+			if m[5] == "" {
+				end = monthIndex(m[3], m[4])
+			}
+			if end > start {
+				months += end - start
+			}
+		}
+	}
+	return math.Round(float64(months)/12*10) / 10
+}
+
+// monthIndex 年月转换为月份序号，未写月份时按1月计算
+func monthIndex(year, month string) int {
+	y, _ := strconv.Atoi(year)
+	m, err := strconv.Atoi(month)
+	if err != nil || m < 1 || m > 12 {
+		m = 1
+	}
+	return y*12 + m - 1
+}
+
+// pastTitles 从工作经历中提取职位（按出现顺序，通常最近的在前）
+func pastTitles(sections map[string]string) []string {
+	var titles []string
+	seen := make(map[string]bool)
+	add := func(t string) {
+		t = strings.Trim(t, "（）()")
+		if t != "" && !seen[t] {
+			seen[t] = true
+			titles = append(titles, t)
+		}
+	}
+
+	for _, heading := range sectionHeadings {
+		content, ok := sections[heading]
+		if !ok || !workSections[heading] {
+			continue
+		}
+		for _, line := range strings.Split(content, "\n") {
+			if m := pastTitlePattern.FindStringSubmatch(line); m != nil {
+				add(m[1])
+				continue
+			}
+			// “2019.03-至今 青岛某某有限公司 Java开发工程师” 格式
+			if dateRangePattern.MatchString(line) {
+				rest := dateRangePattern.ReplaceAllString(line, " ")
+				for _, token := range strings.Fields(rest) {
+					if hasTitleSuffix(token) {
+						add(token)
+					}
+				}
+			}
+		}
+	}
+	return titles
+}
+
+func hasTitleSuffix(token string) bool {
+	if strings.HasSuffix(token, "公司") {
+		return false
+	}
+	for _, suffix := range titleSuffixes {
+		if strings.HasSuffix(token, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractSkills 提取技能：技能字段所在行以及技能段落
+func extractSkills(text string, sections map[string]string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if m := skillsLinePattern.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			lines = append(lines, m[1])
+		}
+	}
+	for _, heading := range []string{"专业技能", "技能特长"} {
+		lines = append(lines, strings.Split(sections[heading], "\n")...)
+	}
+
+	var skills []string
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		for _, token := range skillSplitPattern.Split(line, -1) {
+			token = strings.TrimSpace(strings.Trim(strings.TrimSpace(token), "。.：:•·-—()（）0123456789"))
+			for _, prefix := range skillPrefixes {
+				token = strings.TrimPrefix(token, prefix)
+			}
+			n := len([]rune(token))
+			if n == 0 || n > 20 || seen[strings.ToLower(token)] {
+				continue
+			}
+			seen[strings.ToLower(token)] = true
+			skills = append(skills, token)
+			if len(skills) >= maxSkills {
+				return skills
+			}
+		}
+	}
+	return skills
+}
+
+// matchDistrict 在文本中查找城市区域，返回区域名称和代码（支持省略“区”“市”后缀）
+func matchDistrict(text string, areaCodes map[string]string) (string, string) {
+	if text == "" {
+		return "", ""
+	}
+	best, bestCode, bestPos := "", "", -1
+	for name, code := range areaCodes {
+		short := strings.TrimRight(name, "区市县")
+		for _, candidate := range []string{name, short} {
+			if len([]rune(candidate)) < 2 {
+				continue
+			}
+			if pos := strings.Index(text, candidate); pos >= 0 && (bestPos < 0 || pos < bestPos) {
+				best, bestCode, bestPos = name, code, pos
+			}
+		}
+	}
+	return best, bestCode
+}
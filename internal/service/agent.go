@@ -0,0 +1,384 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"qd-sc/internal/model"
+	contentutils "qd-sc/internal/pkg/utils"
+	"strings"
+)
+
+// maxAgentIterations 单次请求最多进行的LLM调用轮数
+const maxAgentIterations = 10
+
+// AgentEventType 智能体事件类型
+type AgentEventType string
+
+const (
+	// AgentEventContentDelta 增量文本内容（已过滤思维链）
+	AgentEventContentDelta AgentEventType = "content_delta"
+	// AgentEventToolCallStarted 开始执行工具调用
+	AgentEventToolCallStarted AgentEventType = "tool_call_started"
+	// AgentEventToolCallFinished 工具调用执行完成
+	AgentEventToolCallFinished AgentEventType = "tool_call_finished"
+	// AgentEventJobCards 岗位查询结果，需要渲染为岗位卡片
+	AgentEventJobCards AgentEventType = "job_cards"
+	// AgentEventFinal 对话结束
+	AgentEventFinal AgentEventType = "final"
+)
+
+// AgentEvent 智能体事件
+// 流式与非流式响应都由同一组事件渲染而来
+type AgentEvent struct {
+	Type         AgentEventType
+	Content      string                  // ContentDelta：增量文本
+	ToolCall     *model.ToolCall         // ToolCallStarted/ToolCallFinished：对应的工具调用
+	ToolResult   string                  // ToolCallFinished：工具返回结果
+	ToolError    error                   // ToolCallFinished：工具执行错误
+	Jobs         *model.JobResponse      // JobCards：岗位查询结果
+	FinishReason string                  // Final：结束原因
+	Grounding    *model.GroundingVerdict // Final：岗位事实核验结果（开启 grounding.expose_verdict 时）
+}
+
+// agentTurn 单轮LLM响应的汇总结果
+type agentTurn struct {
+	content      string           // 过滤思维链后的完整文本
+	buffered     bool             // 文本是否被缓冲（尚未发送给客户端）
+	toolCalls    []model.ToolCall // 合并后的工具调用
+	finishReason string
+}
+
+// RunAgent 运行对话智能体，以事件流的形式返回结果
+// 事件通道关闭表示处理结束，之后可从错误通道读取处理错误（无错误时读到nil）
+func (s *ChatService) RunAgent(ctx context.Context, req *model.ChatCompletionRequest) (<-chan AgentEvent, <-chan error) {
+	eventChan := make(chan AgentEvent, 100)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(errChan)
+		defer close(eventChan)
+
+		emit := func(event AgentEvent) error {
+			select {
+			case eventChan <- event:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if err := s.runConversation(ctx, req, emit); err != nil {
+			if ctx.Err() != nil {
+				log.Printf("请求被取消: %v", ctx.Err())
+				return
+			}
+			errChan <- err
+		}
+	}()
+
+	return eventChan, errChan
+}
+
+// runAgentLoop 智能体主循环：调用LLM、拦截岗位幻觉、执行工具，直到对话结束
+// history 为已解析文件内容的对话消息（会话历史 + 本轮用户消息），不含系统提示词
+// knownJobs 为会话中此前工具返回的岗位，用于核验回复中的岗位事实
+func (s *ChatService) runAgentLoop(ctx context.Context, req *model.ChatCompletionRequest, history []model.Message, knownJobs []model.FormattedJob, emit func(AgentEvent) error) error {
+	// 每个请求独立的思维链过滤器，避免并发请求之间互相影响
+	thinkFilter := s.newThinkingFilter()
+
+	// 准备消息
+	messages := s.prepareMessages(history, resumeProfileFrom(ctx))
+
+	// 上一轮等待用户选择的地点候选，供意图识别和 queryJobsByLocation 的 placeOption 使用
+	ctx = withPlaceOptions(ctx, pendingPlaceOptions(history))
+
+	// 识别意图，确定提供给模型的工具、tool_choice 和是否拦截岗位幻觉
+	plan := s.planForIntent(s.classifyIntent(ctx, history))
+
+	// 构建请求（使用配置文件中的实际模型名称）
+	llmReq := &model.ChatCompletionRequest{
+		Model:       s.cfg.LLM.Model,
+		Messages:    messages,
+		Tools:       plan.tools,
+		ToolChoice:  plan.toolChoice,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		MaxTokens:   req.MaxTokens,
+		Stream:      true,
+	}
+
+	// 追踪是否已调用过岗位工具
+	jobToolCalled := false
+
+	// 追踪是否已经发送过幻觉拦截消息（避免重复发送）
+	hallucinationIntercepted := false
+
+	// 核验回复中的岗位事实是否来自工具返回的岗位记录
+	grounded := newGroundingState(knownJobs)
+
+	// 已展示给用户的岗位，供岗位详情工具按序号或岗位ID查找
+	shown := newShownJobs(history)
+	ctx = withShownJobs(ctx, shown)
+	final := func() error {
+		return emit(AgentEvent{Type: AgentEventFinal, FinishReason: "stop", Grounding: s.exposedVerdict(grounded)})
+	}
+
+	for iteration := 0; iteration < maxAgentIterations; iteration++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// 岗位意图且尚未调用岗位工具时，先缓冲文本，检测幻觉后再决定是否发送
+		guard := plan.guard && !jobToolCalled
+		// 已有岗位记录时同样缓冲文本，核验岗位事实后再发送
+		verify := s.groundingActive(grounded)
+
+		// 调用LLM前控制上下文大小，超出预算时压缩历史
+		s.compactContext(llmReq)
+
+		turn, err := s.streamLLMTurn(ctx, llmReq, thinkFilter, guard || verify, emit)
+		if err != nil {
+			return err
+		}
+
+		if guard && s.containsJobHallucination(turn.content) {
+			if len(turn.toolCalls) > 0 {
+				// 模型已经在调用工具，只丢弃工具调用前的幻觉文本
+				log.Printf("丢弃工具调用前的岗位幻觉文本，内容长度: %d", len(turn.content))
+				turn.content = ""
+			} else {
+				log.Printf("拦截岗位幻觉输出，内容长度: %d，强制重新调用工具", len(turn.content))
+
+				// 不发送幻觉内容，提示用户并强制调用岗位工具
+				if !hallucinationIntercepted {
+					hallucinationIntercepted = true
+					if err := emit(AgentEvent{Type: AgentEventContentDelta, Content: s.getHallucinationWarningMessage()}); err != nil {
+						return err
+					}
+				}
+
+				llmReq.Messages = append(llmReq.Messages, model.Message{
+					Role:    "user",
+					Content: "请调用岗位查询工具获取真实数据，不要自行编造岗位信息。",
+				})
+				llmReq.ToolChoice = s.getJobToolChoice()
+				continue
+			}
+		}
+
+		if verify && turn.content != "" {
+			content, unsupported := s.groundContent(grounded, turn.content, len(turn.toolCalls) == 0)
+			if unsupported != nil {
+				// 要求模型仅依据工具返回的岗位数据重新回答
+				llmReq.Messages = append(llmReq.Messages,
+					model.Message{Role: "assistant", Content: turn.content},
+					model.Message{Role: "user", Content: regenerateInstruction(unsupported)},
+				)
+				continue
+			}
+			turn.content = content
+		}
+
+		if turn.buffered && turn.content != "" {
+			if err := emit(AgentEvent{Type: AgentEventContentDelta, Content: turn.content}); err != nil {
+				return err
+			}
+		}
+
+		// 没有工具调用，对话结束
+		if len(turn.toolCalls) == 0 {
+			log.Printf("模型返回finish_reason=%s，对话结束", turn.finishReason)
+			return final()
+		}
+
+		llmReq.Messages = append(llmReq.Messages, model.Message{
+			Role:      "assistant",
+			Content:   turn.content,
+			ToolCalls: turn.toolCalls,
+		})
+
+		// 并发执行本轮全部工具调用
+		for i := range turn.toolCalls {
+			if err := emit(AgentEvent{Type: AgentEventToolCallStarted, ToolCall: &turn.toolCalls[i]}); err != nil {
+				return err
+			}
+		}
+		results := s.executeToolCalls(ctx, turn.toolCalls)
+
+		// 按原始顺序处理结果并追加工具消息
+		for i := range turn.toolCalls {
+			toolCall := &turn.toolCalls[i]
+			result, callErr := results[i].content, results[i].err
+			flags := s.tools.Flags(toolCall.Function.Name)
+			if flags.JobTool {
+				jobToolCalled = true
+			}
+
+			if callErr != nil {
+				// 错误信息可能包含上游返回的用户数据，写入日志和发送给LLM前同样脱敏
+				errText := s.redactor.String(callErr.Error())
+				log.Printf("工具调用失败 [%s]: %s", toolCall.Function.Name, errText)
+				result = fmt.Sprintf("工具调用失败: %s", errText)
+			}
+
+			if err := emit(AgentEvent{Type: AgentEventToolCallFinished, ToolCall: toolCall, ToolResult: result, ToolError: callErr}); err != nil {
+				return err
+			}
+
+			// 澄清型工具（地点查询）结果有歧义：直接列出候选请用户选择并结束对话
+			if callErr == nil && flags.Clarifies {
+				var loc model.LocationResult
+				if err := json.Unmarshal([]byte(result), &loc); err != nil {
+					log.Printf("解析地点候选失败: %v", err)
+				} else if loc.Ambiguous && len(loc.Candidates) > 0 {
+					if err := emit(AgentEvent{Type: AgentEventContentDelta, Content: RenderPlaceOptions(&loc)}); err != nil {
+						return err
+					}
+					log.Printf("地点 %s 有 %d 个候选，等待用户选择", loc.Keywords, len(loc.Candidates))
+					return final()
+				}
+			}
+
+			var jobResp *model.JobResponse
+			if callErr == nil && flags.JobTool {
+				if parsed, err := parseJobResponse(result); err != nil {
+					log.Printf("解析岗位数据失败: %v", err)
+				} else {
+					jobResp = parsed
+					grounded.verifier.Add(jobResp.JobListings...)
+					shown.add(jobResp.JobListings)
+				}
+			}
+
+			// 结束型工具（岗位查询）成功：直接展示岗位卡片并结束对话，避免模型再次复述岗位信息
+			if jobResp != nil && flags.TerminatesStream {
+				if err := emit(AgentEvent{Type: AgentEventJobCards, Jobs: jobResp}); err != nil {
+					return err
+				}
+				log.Printf("岗位推荐完成，结束对话")
+				return final()
+			}
+
+			// 确保result不为空
+			if result == "" {
+				result = "工具执行完成"
+			}
+
+			llmReq.Messages = append(llmReq.Messages, model.Message{
+				Role:       "tool",
+				Content:    result,
+				ToolCallID: toolCall.ID,
+			})
+		}
+
+		// 被强制调用的工具已执行，恢复为 auto 模式
+		llmReq.ToolChoice = "auto"
+
+		// 已输出的文本与下一轮回复之间留出空行
+		if turn.content != "" {
+			if err := emit(AgentEvent{Type: AgentEventContentDelta, Content: "\n\n"}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return fmt.Errorf("超过最大工具调用次数")
+}
+
+// streamLLMTurn 进行一轮流式LLM调用
+// guard 为 true 时文本只缓冲不发送，由调用方检测幻觉后决定是否发送
+func (s *ChatService) streamLLMTurn(
+	ctx context.Context,
+	llmReq *model.ChatCompletionRequest,
+	thinkFilter *contentutils.ThinkingStreamFilter,
+	guard bool,
+	emit func(AgentEvent) error,
+) (*agentTurn, error) {
+	responseChan, respErrChan, err := s.llmClient.ChatCompletionStream(llmReq)
+	if err != nil {
+		return nil, fmt.Errorf("LLM流式请求失败: %w", err)
+	}
+
+	turn := &agentTurn{buffered: guard}
+	var content strings.Builder
+	var rawToolCalls []model.ToolCall
+
+	// 用于合并重复日志的计数器
+	toolCallChunks := 0
+
+	write := func(text string) error {
+		if text == "" {
+			return nil
+		}
+		content.WriteString(text)
+		if guard {
+			return nil
+		}
+		return emit(AgentEvent{Type: AgentEventContentDelta, Content: text})
+	}
+
+	for responseChan != nil {
+		select {
+		case <-ctx.Done():
+			log.Printf("流式处理被取消: %v", ctx.Err())
+			return nil, ctx.Err()
+		case chunk, ok := <-responseChan:
+			if !ok {
+				responseChan = nil
+				break
+			}
+			if len(chunk.Choices) == 0 {
+				continue
+			}
+
+			choice := chunk.Choices[0]
+			if text, ok := choice.Delta.Content.(string); ok && text != "" {
+				// 实时过滤思维链标签 - 处理跨chunk的情况
+				if err := write(thinkFilter.Write(text)); err != nil {
+					return nil, err
+				}
+			}
+
+			// 收集工具调用（流式响应中工具调用会分块到达）
+			if len(choice.Delta.ToolCalls) > 0 {
+				rawToolCalls = append(rawToolCalls, choice.Delta.ToolCalls...)
+				toolCallChunks++
+			}
+
+			if choice.FinishReason != "" {
+				turn.finishReason = choice.FinishReason
+				log.Printf("收到finish_reason: %s", turn.finishReason)
+			}
+		case err, ok := <-respErrChan:
+			if ok && err != nil {
+				return nil, err
+			}
+			respErrChan = nil
+		}
+	}
+
+	// chunk通道关闭后，确认是否有读取错误
+	if respErrChan != nil {
+		if err := <-respErrChan; err != nil {
+			return nil, err
+		}
+	}
+
+	// 输出被暂存的可能是标签前缀的残留内容（本轮响应已结束，不会再有后续chunk）
+	if err := write(thinkFilter.Flush()); err != nil {
+		return nil, err
+	}
+
+	if toolCallChunks > 0 {
+		log.Printf("收到tool_calls chunk x%d，由服务端执行", toolCallChunks)
+	}
+
+	turn.content = content.String()
+	if guard {
+		turn.content = strings.TrimSpace(turn.content)
+	}
+	turn.toolCalls = s.mergeToolCalls(rawToolCalls)
+	return turn, nil
+}
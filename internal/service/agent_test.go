@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"qd-sc/internal/client"
+	"qd-sc/internal/config"
+	"qd-sc/internal/intent"
+	"qd-sc/internal/jobcache"
+	"qd-sc/internal/match"
+	"qd-sc/internal/model"
+	"qd-sc/internal/redact"
+	"qd-sc/internal/resume"
+	"qd-sc/internal/session"
+	"qd-sc/internal/tool"
+)
+
+// fakeLLM 按脚本依次返回流式响应的LLM服务
+type fakeLLM struct {
+	mu       sync.Mutex
+	turns    [][]model.ChatCompletionChunk
+	requests []model.ChatCompletionRequest
+}
+
+func (f *fakeLLM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req model.ChatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	if len(f.turns) == 0 {
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	turn := f.turns[0]
+	f.turns = f.turns[1:]
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, chunk := range turn {
+		data, _ := json.Marshal(chunk)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+// textTurn 构造只包含文本的一轮流式响应
+func textTurn(parts ...string) []model.ChatCompletionChunk {
+	chunks := make([]model.ChatCompletionChunk, 0, len(parts)+1)
+	for _, p := range parts {
+		chunks = append(chunks, model.ChatCompletionChunk{
+			Choices: []model.ChunkChoice{{Delta: model.Message{Content: p}}},
+		})
+	}
+	return append(chunks, model.ChatCompletionChunk{
+		Choices: []model.ChunkChoice{{FinishReason: "stop"}},
+	})
+}
+
+// toolCallTurn 构造一轮工具调用响应，参数被拆成两个chunk到达
+func toolCallTurn(name, arguments string) []model.ChatCompletionChunk {
+	idx := 0
+	half := len(arguments) / 2
+	return []model.ChatCompletionChunk{
+		{Choices: []model.ChunkChoice{{Delta: model.Message{ToolCalls: []model.ToolCall{
+			{Index: &idx, ID: "call_1", Type: "function", Function: model.FunctionCall{Name: name, Arguments: arguments[:half]}},
+		}}}}},
+		{Choices: []model.ChunkChoice{{Delta: model.Message{ToolCalls: []model.ToolCall{
+			{Index: &idx, Function: model.FunctionCall{Arguments: arguments[half:]}},
+		}}}}},
+		{Choices: []model.ChunkChoice{{FinishReason: "tool_calls"}}},
+	}
+}
+
+// newTestChatService 创建连接到假LLM和假岗位API的对话服务，extraYAML 追加到测试配置末尾
+func newTestChatService(t *testing.T, llm *fakeLLM, jobRows []model.JobListing, extraYAML ...string) *ChatService {
+	t.Helper()
+
+	llmServer := httptest.NewServer(llm)
+	t.Cleanup(llmServer.Close)
+
+	// 根路径为岗位列表，/{id} 为岗位详情
+	jobServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := strings.TrimPrefix(r.URL.Path, "/"); id != "" {
+			resp := model.JobDetailAPIResponse{Code: 404, Msg: "岗位不存在"}
+			for _, row := range jobRows {
+				if row.JobID == id {
+					resp = model.JobDetailAPIResponse{Code: 200, Data: &model.JobDetail{
+						JobListing:     row,
+						JobDescription: row.JobTitle + "岗位职责",
+						Welfare:        "五险一金，双休、餐补",
+						ContactPhone:   "0532-88888888",
+					}}
+				}
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(model.JobAPIResponse{Code: 200, Rows: jobRows})
+	}))
+	t.Cleanup(jobServer.Close)
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	configYAML := fmt.Sprintf("llm:\n  base_url: %q\n  model: test-model\n  max_retries: 1\njob_api:\n  base_url: %q\n", llmServer.URL, jobServer.URL) + strings.Join(extraYAML, "")
+	if err := os.WriteFile(configPath, []byte(configYAML), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+
+	fileParser := NewFileParser(cfg, client.NewOCRClient(cfg), nil)
+	locationService := NewLocationService(cfg, client.NewAmapClient(cfg))
+	jobCache, err := jobcache.New(&cfg.JobCache)
+	if err != nil {
+		t.Fatalf("create job cache: %v", err)
+	}
+	jobService := NewJobService(cfg, client.NewJobClient(cfg), match.NewScorer(cfg, nil), jobCache, nil)
+
+	registry := tool.NewRegistry()
+	if err := RegisterBuiltinTools(registry, BuiltinToolDeps{
+		Config:          cfg,
+		LocationService: locationService,
+		JobService:      jobService,
+		FileParser:      fileParser,
+		Redactor:        redact.New(&cfg.Redaction),
+	}); err != nil {
+		t.Fatalf("register tools: %v", err)
+	}
+
+	llmClient := client.NewLLMClient(cfg)
+	classifier, err := intent.NewClassifier(cfg, llmClient)
+	if err != nil {
+		t.Fatalf("create intent classifier: %v", err)
+	}
+
+	return NewChatService(cfg, llmClient, fileParser, locationService, jobService, nil, registry, session.NewMemoryStore(time.Hour, 0), classifier, resume.NewParser(cfg, nil))
+}
+
+// collectEvents 运行智能体并收集全部事件
+func collectEvents(t *testing.T, s *ChatService, userMessage string) []AgentEvent {
+	t.Helper()
+
+	events, errs := s.RunAgent(context.Background(), &model.ChatCompletionRequest{
+		Model:    ExposedModelName,
+		Messages: []model.Message{{Role: "user", Content: userMessage}},
+	})
+
+	var collected []AgentEvent
+	for event := range events {
+		collected = append(collected, event)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("RunAgent error: %v", err)
+	}
+	return collected
+}
+
+// joinContent 拼接所有增量文本
+func joinContent(events []AgentEvent) string {
+	var b strings.Builder
+	for _, e := range events {
+		if e.Type == AgentEventContentDelta {
+			b.WriteString(e.Content)
+		}
+	}
+	return b.String()
+}
+
+func TestRunAgent_PlainAnswerFiltersThinking(t *testing.T) {
+	llm := &fakeLLM{turns: [][]model.ChatCompletionChunk{
+		textTurn("<thi", "nk>用户在打招呼</think>", "你好，", "有什么可以帮您？"),
+	}}
+	s := newTestChatService(t, llm, nil)
+
+	events := collectEvents(t, s, "你好")
+
+	if got := joinContent(events); got != "你好，有什么可以帮您？" {
+		t.Fatalf("content = %q", got)
+	}
+	last := events[len(events)-1]
+	if last.Type != AgentEventFinal || last.FinishReason != "stop" {
+		t.Fatalf("expected final stop event, got %+v", last)
+	}
+}
+
+func TestRunAgent_InterceptsHallucinationThenShowsJobCards(t *testing.T) {
+	hallucination := "为您推荐以下岗位：岗位名称：Java开发\n公司名称：某某科技\n薪资范围：8000-12000元/月"
+	llm := &fakeLLM{turns: [][]model.ChatCompletionChunk{
+		textTurn(hallucination),
+		toolCallTurn("queryJobsByArea", `{"jobTitle":"Java","current":1,"pageSize":10}`),
+	}}
+	rows := []model.JobListing{
+		{JobTitle: "Java开发工程师", CompanyName: "青岛软件园", MinSalary: 9000, MaxSalary: 15000, Education: "4", Experience: "4", AppJobURL: "https://jobs.example/1"},
+		{JobTitle: "Java实习生", CompanyName: "海洋科技", Education: "3", Experience: "1", AppJobURL: "https://jobs.example/2"},
+	}
+	s := newTestChatService(t, llm, rows)
+
+	events := collectEvents(t, s, "帮我推荐Java岗位")
+
+	content := joinContent(events)
+	if strings.Contains(content, "某某科技") {
+		t.Fatalf("hallucinated content leaked to client: %q", content)
+	}
+	if !strings.Contains(content, s.getHallucinationWarningMessage()) {
+		t.Fatalf("expected hallucination warning, got %q", content)
+	}
+
+	var cards *model.JobResponse
+	var started, finished int
+	for _, e := range events {
+		switch e.Type {
+		case AgentEventJobCards:
+			cards = e.Jobs
+		case AgentEventToolCallStarted:
+			started++
+		case AgentEventToolCallFinished:
+			finished++
+			if e.ToolError != nil {
+				t.Fatalf("tool error: %v", e.ToolError)
+			}
+		}
+	}
+	if started != 1 || finished != 1 {
+		t.Fatalf("expected one tool call started/finished, got %d/%d", started, finished)
+	}
+	if cards == nil || len(cards.JobListings) != 2 {
+		t.Fatalf("expected 2 job cards, got %+v", cards)
+	}
+	if cards.JobListings[0].Salary != "9000-15000元/月" || cards.JobListings[1].Salary != "薪资面议" {
+		t.Fatalf("unexpected salaries: %+v", cards.JobListings)
+	}
+	if last := events[len(events)-1]; last.Type != AgentEventFinal {
+		t.Fatalf("expected final event after job cards, got %+v", last)
+	}
+
+	// 拦截后的第二次请求必须强制调用岗位工具
+	if len(llm.requests) != 2 {
+		t.Fatalf("expected 2 LLM requests, got %d", len(llm.requests))
+	}
+	if choice, ok := llm.requests[1].ToolChoice.(map[string]interface{}); !ok || choice["type"] != "function" {
+		t.Fatalf("expected forced tool_choice on retry, got %#v", llm.requests[1].ToolChoice)
+	}
+}
+
+func TestRenderJobCards(t *testing.T) {
+	segments := RenderJobCards(&model.JobResponse{JobListings: []model.FormattedJob{{JobTitle: "A"}, {JobTitle: "B"}}})
+	if len(segments) != 3 {
+		t.Fatalf("expected intro + 2 cards, got %d segments", len(segments))
+	}
+	for _, card := range segments[1:] {
+		if !strings.HasPrefix(card, "``` job-json\n") {
+			t.Fatalf("card not wrapped in job-json block: %q", card)
+		}
+	}
+
+	empty := RenderJobCards(&model.JobResponse{})
+	if len(empty) != 1 || !strings.Contains(empty[0], "未找到") {
+		t.Fatalf("unexpected empty rendering: %q", empty)
+	}
+}
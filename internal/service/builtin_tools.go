@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"qd-sc/internal/config"
+	"qd-sc/internal/redact"
+	"qd-sc/internal/tool"
+	"qd-sc/pkg/utils"
+	"strings"
+	"time"
+)
+
+// BuiltinToolDeps 内置工具依赖的服务
+type BuiltinToolDeps struct {
+	Config          *config.Config
+	LocationService *LocationService
+	JobService      *JobService
+	PolicyService   *PolicyService
+	FileParser      *FileParser
+	Redactor        *redact.Redactor // 文件解析结果返回给模型前脱敏，为nil时不脱敏
+}
+
+// RegisterBuiltinTools 注册系统内置工具
+// 城市特有的工具可以在此之后直接向注册表追加，无需修改对话服务
+func RegisterBuiltinTools(reg *tool.Registry, deps BuiltinToolDeps) error {
+	builtins := []tool.Tool{
+		newQueryLocationTool(deps),
+		newQueryJobsByAreaTool(deps),
+		newQueryJobsByLocationTool(deps),
+		newMoreJobsTool(deps),
+		newGetJobDetailTool(deps),
+		newParsePDFTool(deps),
+		newParseImageTool(deps),
+		newQueryPolicyTool(deps),
+	}
+
+	for _, t := range builtins {
+		if err := reg.Register(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jobFilterProperties 岗位查询工具共用的筛选参数定义
+func jobFilterProperties() map[string]interface{} {
+	return map[string]interface{}{
+		"jobTitle": map[string]interface{}{
+			"type":        "string",
+			"description": "岗位名称关键字，例如：Java开发、产品经理",
+		},
+		"current": map[string]interface{}{
+			"type":        "integer",
+			"description": "当前页码，用于分页查询，默认为1",
+			"default":     1,
+		},
+		"pageSize": map[string]interface{}{
+			"type":        "integer",
+			"description": "每页返回的岗位数量，默认为10",
+			"default":     10,
+		},
+		"order": map[string]interface{}{
+			"type":        "string",
+			"description": "排序方式，0:推荐, 1:最热, 2:最新发布，默认为0",
+		},
+		"minSalary": map[string]interface{}{
+			"type":        "string",
+			"description": "最低薪资，单位：元/月；也可直接传用户的说法，如 8k、1万5、年薪20万（自动换算为月薪），面议或不限时不传",
+		},
+		"maxSalary": map[string]interface{}{
+			"type":        "string",
+			"description": "最高薪资，单位：元/月；也可直接传用户的说法，如 12k、2万；传区间（如 8-12k）时取上限",
+		},
+		"experience": map[string]interface{}{
+			"type":        "string",
+			"description": "经验要求代码，0:经验不限, 1:实习生, 2:应届毕业生, 3:1年以下, 4:1-3年, 5:3-5年, 6:5-10年, 7:10年以上；也可直接传用户的说法，如“3年经验”“应届”",
+		},
+		"education": map[string]interface{}{
+			"type":        "string",
+			"description": "学历要求代码，-1:不限, 0:初中及以下, 1:中专/中技, 2:高中, 3:大专, 4:本科, 5:硕士, 6:博士, 7:MBA/EMBA, 8:留学-学士, 9:留学-硕士, 10:留学-博士；也可直接传用户的说法，如“本科以上”",
+		},
+		"companyNature": map[string]interface{}{
+			"type":        "string",
+			"description": "企业类型代码，1:私营企业, 2:股份制企业, 3:国有企业, 4:外商及港澳台投资企业, 5:医院",
+		},
+	}
+}
+
+// newQueryLocationTool 地点经纬度查询工具
+func newQueryLocationTool(deps BuiltinToolDeps) tool.Tool {
+	city := &deps.Config.City
+	return &tool.Func{
+		ToolName:    "queryLocation",
+		Description: fmt.Sprintf("查询%s具体地点的经纬度坐标，用于后续基于地理位置的岗位查询。返回按置信度排列的候选地点（含地址和区县）；同名地点有多处且相距较远时，系统会直接请用户选择", city.Name),
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"keywords": map[string]interface{}{
+					"type":        "string",
+					"description": fmt.Sprintf("具体的地名，例如：%s", city.GetLandmarksExample()),
+				},
+			},
+			"required": []string{"keywords"},
+		},
+		ToolFlags: tool.Flags{Clarifies: true},
+		Handler: func(ctx context.Context, args tool.Args) (string, error) {
+			keywords, ok := args.String("keywords")
+			if !ok {
+				return "", fmt.Errorf("缺少keywords参数")
+			}
+
+			result, err := deps.LocationService.SearchCandidates(keywords)
+			if err != nil {
+				return "", err
+			}
+			return utils.ToJSONStringPretty(result)
+		},
+	}
+}
+
+// newQueryJobsByAreaTool 按区域查询岗位工具
+func newQueryJobsByAreaTool(deps BuiltinToolDeps) tool.Tool {
+	city := &deps.Config.City
+	properties := jobFilterProperties()
+	properties["jobLocationAreaCode"] = map[string]interface{}{
+		"type":        "string",
+		"description": fmt.Sprintf("区域代码或名称，%s；也可直接传用户说的区域名称、简称、别名或街道（如“黄岛”“西海岸新区”），系统会自动解析", city.GetAreaCodesDescription()),
+	}
+	properties["jobLocationAreaCodes"] = map[string]interface{}{
+		"type":        "array",
+		"items":       map[string]interface{}{"type": "string"},
+		"description": "多个区域代码或名称，用户同时提到多个区域（如“市南区或崂山区”）时使用，每个区域分别查询后合并去重",
+	}
+	properties["jobTitles"] = map[string]interface{}{
+		"type":        "array",
+		"items":       map[string]interface{}{"type": "string"},
+		"description": "多个岗位名称关键字，用户提到多个岗位或同义说法（如“前端/Web开发”）时使用，每个名称分别查询后合并去重",
+	}
+
+	return &tool.Func{
+		ToolName:    "queryJobsByArea",
+		Description: fmt.Sprintf("【必须调用】根据区域代码查询%s岗位信息。当用户询问任何与岗位、工作、招聘、求职相关的问题时，必须调用此工具获取真实数据。严禁在未调用此工具的情况下输出任何岗位信息。涉及多个区域或多个岗位名称时，在一次调用中通过 jobLocationAreaCodes、jobTitles 传入（区域数×岗位名称数最多%d个），不要拆成多次调用。", city.Name, maxFanOutQueries),
+		Parameters: map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+			"required":   []string{"current", "pageSize"},
+		},
+		ToolFlags: tool.Flags{JobTool: true, TerminatesStream: true},
+		Handler: func(ctx context.Context, args tool.Args) (string, error) {
+			return deps.JobService.QueryJobsByArea(ctx, applyProfileDefaults(args, resumeProfileFrom(ctx)))
+		},
+	}
+}
+
+// newQueryJobsByLocationTool 按经纬度查询附近岗位工具
+func newQueryJobsByLocationTool(deps BuiltinToolDeps) tool.Tool {
+	properties := jobFilterProperties()
+	properties["latitude"] = map[string]interface{}{
+		"type":        "string",
+		"description": "纬度，从queryLocation工具获取",
+	}
+	properties["longitude"] = map[string]interface{}{
+		"type":        "string",
+		"description": "经度，从queryLocation工具获取",
+	}
+	properties["radius"] = map[string]interface{}{
+		"type":        "string",
+		"description": "搜索半径，单位：千米，最大为50，建议使用5-10",
+		"default":     "10",
+	}
+	properties["placeOption"] = map[string]interface{}{
+		"type":        "string",
+		"description": "用户从地点候选中选择的序号或名称（上一轮系统列出了同名地点的候选时使用），传入后按所选地点的坐标查询，无需再提供latitude、longitude",
+	}
+	properties["commuteMode"] = map[string]interface{}{
+		"type":        "string",
+		"description": "通勤方式，transit:公交地铁, driving:驾车, walking:步行。用户关心从该位置（如家）出发的通勤时间时传入，岗位会附带预计通勤时间",
+	}
+	properties["maxCommuteMinutes"] = map[string]interface{}{
+		"type":        "integer",
+		"description": "最长通勤时间（分钟），只返回通勤时间不超过该值的岗位，例如用户说“通勤40分钟以内”时传40；也可直接传“1小时”等说法",
+	}
+	properties["sortByCommute"] = map[string]interface{}{
+		"type":        "boolean",
+		"description": "是否按通勤时间从短到长排序，用户希望“离家近”“通勤方便”的岗位排在前面时传true",
+	}
+
+	return &tool.Func{
+		ToolName:    "queryJobsByLocation",
+		Description: fmt.Sprintf("【必须调用】根据经纬度和半径查询附近的%s岗位信息。当用户询问特定位置附近的岗位时，必须调用此工具获取真实数据。需要先调用queryLocation获取经纬度。严禁在未调用此工具的情况下输出任何岗位信息。用户提到通勤时间或通勤方式时，以该位置为出发地，通过 commuteMode、maxCommuteMinutes、sortByCommute 按通勤时间筛选和排序。", deps.Config.City.Name),
+		Parameters: map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+			"required":   []string{"jobTitle", "current", "pageSize", "radius"},
+		},
+		ToolFlags: tool.Flags{JobTool: true, TerminatesStream: true},
+		Handler: func(ctx context.Context, args tool.Args) (string, error) {
+			if err := applyPlaceOption(ctx, args); err != nil {
+				return "", err
+			}
+			return deps.JobService.QueryJobsByLocation(ctx, applyProfileDefaults(args, resumeProfileFrom(ctx)))
+		},
+	}
+}
+
+// newMoreJobsTool 继续翻页工具，沿用最近一次岗位查询的全部筛选条件
+func newMoreJobsTool(deps BuiltinToolDeps) tool.Tool {
+	return &tool.Func{
+		ToolName:    "moreJobs",
+		Description: "在最近一次岗位查询的基础上查看更多岗位（下一页），保留之前的全部筛选条件，并跳过已经展示过的岗位。当用户说“再看看更多”“换一批”“下一页”等，且不改变查询条件时调用；条件有变化时请重新调用岗位查询工具",
+		Parameters: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+		},
+		ToolFlags: tool.Flags{JobTool: true, TerminatesStream: true},
+		Handler: func(ctx context.Context, args tool.Args) (string, error) {
+			return deps.JobService.MoreJobs(ctx)
+		},
+	}
+}
+
+// newGetJobDetailTool 岗位详情工具，只能查询对话中已展示的岗位
+func newGetJobDetailTool(deps BuiltinToolDeps) tool.Tool {
+	return &tool.Func{
+		ToolName:    "getJobDetail",
+		Description: "查询已展示岗位的详细信息（岗位职责、任职要求、福利待遇、工作地址、联系方式）。当用户追问某个已推荐岗位的具体要求、待遇或联系方式时调用，例如“第二个岗位具体要求是什么”。只能查询本次对话中已经展示过的岗位",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"jobId": map[string]interface{}{
+					"type":        "string",
+					"description": "岗位ID，取自已展示岗位的jobId字段",
+				},
+				"index": map[string]interface{}{
+					"type":        "integer",
+					"description": "岗位在最近一次展示结果中的序号，从1开始；未提供jobId时使用",
+				},
+			},
+		},
+		ToolFlags: tool.Flags{JobTool: true},
+		Handler: func(ctx context.Context, args tool.Args) (string, error) {
+			shown := shownJobsFrom(ctx)
+			if shown == nil {
+				return "", fmt.Errorf("当前对话中还没有展示过岗位，请先查询岗位")
+			}
+
+			jobID, _ := args.String("jobId")
+			index := args.Int("index", 0)
+			if strings.TrimSpace(jobID) == "" && index == 0 {
+				return "", fmt.Errorf("缺少jobId或index参数")
+			}
+
+			job, err := shown.resolve(jobID, index)
+			if err != nil {
+				return "", err
+			}
+
+			detail, err := deps.JobService.GetJobDetail(ctx, job.JobID)
+			if err != nil {
+				return "", err
+			}
+			return utils.ToJSONStringPretty(detail)
+		},
+	}
+}
+
+// newParsePDFTool PDF解析工具
+func newParsePDFTool(deps BuiltinToolDeps) tool.Tool {
+	return &tool.Func{
+		ToolName:    "parsePDF",
+		Description: "深度解析PDF文件，提取文本内容，特别适用于简历等复杂格式的PDF文件。返回文本、页数以及是否为简历",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"fileUrl": map[string]interface{}{
+					"type":        "string",
+					"description": "PDF文件的URL地址，或通过上传接口得到的文件ID（file-开头）",
+				},
+			},
+			"required": []string{"fileUrl"},
+		},
+		Handler: func(ctx context.Context, args tool.Args) (string, error) {
+			fileURL, ok := args.String("fileUrl")
+			if !ok || fileURL == "" {
+				return "", fmt.Errorf("缺少fileUrl参数")
+			}
+			return parseFileTool(ctx, deps.FileParser, deps.Redactor, fileURL, FileKindPDF)
+		},
+	}
+}
+
+// newParseImageTool 图片解析工具
+func newParseImageTool(deps BuiltinToolDeps) tool.Tool {
+	return &tool.Func{
+		ToolName:    "parseImage",
+		Description: "解析图片文件，识别图片中的文本和内容，可用于识别简历截图、证书照片等。返回文本以及是否为简历",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"imageUrl": map[string]interface{}{
+					"type":        "string",
+					"description": "图片文件的URL地址，或通过上传接口得到的文件ID（file-开头）",
+				},
+			},
+			"required": []string{"imageUrl"},
+		},
+		Handler: func(ctx context.Context, args tool.Args) (string, error) {
+			imageURL, ok := args.String("imageUrl")
+			if !ok || imageURL == "" {
+				return "", fmt.Errorf("缺少imageUrl参数")
+			}
+			return parseFileTool(ctx, deps.FileParser, deps.Redactor, imageURL, FileKindImage)
+		},
+	}
+}
+
+// parseFileTool 文件解析工具的公共实现，结果在当前会话内按URL缓存
+func parseFileTool(ctx context.Context, parser *FileParser, redactor *redact.Redactor, fileURL, kind string) (string, error) {
+	if parser == nil {
+		return "", fmt.Errorf("文件解析服务未配置")
+	}
+
+	file, err := parser.Parse(ctx, conversationIDFrom(ctx), fileURL, kind)
+	if err != nil {
+		return "", fmt.Errorf("解析文件失败: %w", err)
+	}
+	file.Text = redactor.Redact(file.Text, redactionVaultFrom(ctx))
+	return utils.ToJSONStringPretty(file)
+}
+
+// newQueryPolicyTool 政策咨询工具
+func newQueryPolicyTool(deps BuiltinToolDeps) tool.Tool {
+	return &tool.Func{
+		ToolName:    "queryPolicy",
+		Description: fmt.Sprintf("查询%s就业创业相关政策信息，包括就业补贴、创业扶持、见习补贴等政策", deps.Config.City.Name),
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "政策查询关键词，例如：就业补贴、创业扶持、见习补贴、职业培训等",
+				},
+				"topK": map[string]interface{}{
+					"type":        "integer",
+					"description": "返回最相关的政策数量，默认为3",
+					"default":     3,
+				},
+			},
+			"required": []string{"query"},
+		},
+		ToolFlags: tool.Flags{PolicyTool: true},
+		Handler: func(ctx context.Context, args tool.Args) (string, error) {
+			query, ok := args.String("query")
+			if !ok || query == "" {
+				return "", fmt.Errorf("缺少query参数")
+			}
+
+			// 获取topK参数，默认为3
+			topK := args.Int("topK", 3)
+
+			// 搜索相关政策
+			ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			defer cancel()
+
+			results, err := deps.PolicyService.SearchPolicies(ctx, query, topK)
+			if err != nil {
+				return "", fmt.Errorf("搜索政策失败: %w", err)
+			}
+
+			if len(results) == 0 {
+				return "未找到相关政策信息，建议您换个关键词重新搜索或联系相关部门咨询。", nil
+			}
+
+			// 格式化返回结果
+			var resultBuilder strings.Builder
+			resultBuilder.WriteString(fmt.Sprintf("为您找到 %d 条相关政策：\n\n", len(results)))
+
+			for i, result := range results {
+				resultBuilder.WriteString(fmt.Sprintf("【政策 %d】\n", i+1))
+				resultBuilder.WriteString(result.Content)
+				resultBuilder.WriteString("\n")
+				resultBuilder.WriteString(fmt.Sprintf("相似度评分: %.2f\n", 1.0-result.Distance))
+				resultBuilder.WriteString("\n---\n\n")
+			}
+
+			return resultBuilder.String(), nil
+		},
+	}
+}
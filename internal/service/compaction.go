@@ -0,0 +1,319 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"qd-sc/internal/model"
+	contentutils "qd-sc/internal/pkg/utils"
+	"regexp"
+	"strings"
+)
+
+const (
+	// messageOverheadTokens 每条消息的格式开销（角色、分隔符等）
+	messageOverheadTokens = 4
+	// minContextBudget 上下文预算下限，避免配置异常时把历史压缩殆尽
+	minContextBudget = 1024
+	// summaryPrefix 早期对话摘要消息的前缀
+	summaryPrefix = "[早期对话摘要]\n"
+	// summaryLineRunes 摘要中每条消息保留的最大字符数
+	summaryLineRunes = 80
+	// maxSummaryLines 摘要最多保留的行数（超出时丢弃最早的行）
+	maxSummaryLines = 30
+)
+
+// jobCardBlockPattern 匹配助手回复中的岗位卡片代码块
+var jobCardBlockPattern = regexp.MustCompile("(?s)``` job-json\n.*?\n```")
+
+// compactionOptions 上下文压缩参数
+type compactionOptions struct {
+	budget              int // 消息可用的token预算
+	keepRecentTurns     int // 完整保留的最近对话轮数
+	toolResultMaxTokens int // 过期工具结果截断后的最大token数
+}
+
+// compactContext 在调用LLM前检查上下文预算，超出时压缩历史消息
+func (s *ChatService) compactContext(llmReq *model.ChatCompletionRequest) {
+	opts := s.compactionOptions(llmReq)
+	before := estimateMessagesTokens(llmReq.Messages)
+	if before <= opts.budget {
+		return
+	}
+
+	llmReq.Messages = compactMessages(llmReq.Messages, opts)
+	after := estimateMessagesTokens(llmReq.Messages)
+	log.Printf("上下文超出预算(%d tokens)，已压缩历史: %d -> %d tokens，移除 %d tokens", opts.budget, before, after, before-after)
+	if after > opts.budget {
+		log.Printf("警告：压缩后上下文仍超出预算 %d tokens", after-opts.budget)
+	}
+}
+
+// compactionOptions 根据模型配置计算本次请求的压缩参数
+func (s *ChatService) compactionOptions(llmReq *model.ChatCompletionRequest) compactionOptions {
+	ctxCfg := &s.cfg.LLM.Context
+
+	reserved := ctxCfg.ReservedOutput
+	if llmReq.MaxTokens != nil && *llmReq.MaxTokens > 0 {
+		reserved = *llmReq.MaxTokens
+	}
+
+	// 工具定义同样占用上下文
+	toolTokens := 0
+	if len(llmReq.Tools) > 0 {
+		if data, err := json.Marshal(llmReq.Tools); err == nil {
+			toolTokens = contentutils.EstimateTokens(string(data))
+		}
+	}
+
+	budget := ctxCfg.WindowFor(llmReq.Model) - reserved - toolTokens
+	if budget < minContextBudget {
+		budget = minContextBudget
+	}
+
+	return compactionOptions{
+		budget:              budget,
+		keepRecentTurns:     ctxCfg.KeepRecentTurns,
+		toolResultMaxTokens: ctxCfg.ToolResultMaxTokens,
+	}
+}
+
+// estimateMessageTokens 估算单条消息的token数
+func estimateMessageTokens(msg model.Message) int {
+	tokens := messageOverheadTokens + contentutils.EstimateTokens(messageText(msg))
+	for _, tc := range msg.ToolCalls {
+		tokens += contentutils.EstimateTokens(tc.Function.Name) + contentutils.EstimateTokens(tc.Function.Arguments)
+	}
+	return tokens
+}
+
+// estimateMessagesTokens 估算消息列表的token数
+func estimateMessagesTokens(messages []model.Message) int {
+	total := 0
+	for _, msg := range messages {
+		total += estimateMessageTokens(msg)
+	}
+	return total
+}
+
+// messageText 获取消息的文本内容
+func messageText(msg model.Message) string {
+	switch content := msg.Content.(type) {
+	case nil:
+		return ""
+	case string:
+		return content
+	default:
+		data, _ := json.Marshal(content)
+		return string(data)
+	}
+}
+
+// compactMessages 按代价从低到高逐步压缩消息，直到不超过预算：
+// 1. 压缩工具结果中的JSON缩进（无损）
+// 2. 截断过期的工具结果（最近一轮工具调用之前的结果）
+// 3. 将较早的对话轮次合并为摘要，保留最近一次上传的简历
+// 4. 逐步减少完整保留的轮数，最后截断最近一轮的工具结果
+func compactMessages(messages []model.Message, opts compactionOptions) []model.Message {
+	compacted := append([]model.Message(nil), messages...)
+	fits := func() bool { return estimateMessagesTokens(compacted) <= opts.budget }
+
+	compactToolJSON(compacted)
+	if fits() {
+		return compacted
+	}
+
+	truncateToolResults(compacted, lastToolRoundStart(compacted), opts.toolResultMaxTokens)
+	if fits() {
+		return compacted
+	}
+
+	for keep := opts.keepRecentTurns; keep >= 1; keep-- {
+		compacted = summarizeOlderTurns(compacted, keep)
+		if fits() {
+			return compacted
+		}
+	}
+
+	truncateToolResults(compacted, len(compacted), opts.toolResultMaxTokens)
+	return compacted
+}
+
+// compactToolJSON 去除工具结果中JSON的缩进和空白
+func compactToolJSON(messages []model.Message) {
+	for i := range messages {
+		if messages[i].Role != "tool" {
+			continue
+		}
+		text, ok := messages[i].Content.(string)
+		if !ok || !json.Valid([]byte(text)) {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, []byte(text)); err == nil {
+			messages[i].Content = buf.String()
+		}
+	}
+}
+
+// lastToolRoundStart 返回最近一轮工具调用（最后一条带tool_calls的助手消息）的位置
+// 该位置之后的工具结果是模型当前需要的，之前的视为过期
+func lastToolRoundStart(messages []model.Message) int {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "assistant" && len(messages[i].ToolCalls) > 0 {
+			return i
+		}
+	}
+	return len(messages)
+}
+
+// truncateToolResults 截断 end 之前的工具结果
+func truncateToolResults(messages []model.Message, end, maxTokens int) {
+	for i := 0; i < end && i < len(messages); i++ {
+		if messages[i].Role != "tool" {
+			continue
+		}
+		if text, ok := messages[i].Content.(string); ok {
+			messages[i].Content = contentutils.TruncateToTokens(text, maxTokens)
+		}
+	}
+}
+
+// summarizeOlderTurns 保留最近 keep 轮对话，更早的轮次合并为一条摘要消息
+// 一轮对话从一条用户消息开始，工具调用与结果随所在轮次整体保留或移除
+func summarizeOlderTurns(messages []model.Message, keep int) []model.Message {
+	var head []model.Message // 系统提示词
+	body := messages
+	if len(body) > 0 && body[0].Role == "system" && !isSummaryMessage(body[0]) {
+		head, body = body[:1], body[1:]
+	}
+
+	// 已有的摘要
+	var lines []string
+	var resume string
+	if len(body) > 0 && isSummaryMessage(body[0]) {
+		lines, resume = parseSummary(messageText(body[0]))
+		body = body[1:]
+	}
+
+	var userIdx []int
+	for i, msg := range body {
+		if msg.Role == "user" {
+			userIdx = append(userIdx, i)
+		}
+	}
+	if len(userIdx) <= keep {
+		return messages
+	}
+
+	cut := userIdx[len(userIdx)-keep]
+	older, recent := body[:cut], body[cut:]
+
+	for _, msg := range older {
+		text := messageText(msg)
+		switch msg.Role {
+		case "user":
+			if r, rest, ok := splitResume(text); ok {
+				resume = r
+				text = rest + " [上传了简历]"
+			}
+			lines = append(lines, "用户: "+abbreviate(text))
+		case "assistant":
+			if n := len(jobCardBlockPattern.FindAllString(text, -1)); n > 0 {
+				text = jobCardBlockPattern.ReplaceAllString(text, "")
+				text += fmt.Sprintf(" [展示了%d个岗位卡片]", n)
+			}
+			if strings.TrimSpace(text) != "" {
+				lines = append(lines, "助手: "+abbreviate(text))
+			}
+			if len(msg.ToolCalls) > 0 {
+				names := make([]string, 0, len(msg.ToolCalls))
+				for _, tc := range msg.ToolCalls {
+					names = append(names, tc.Function.Name)
+				}
+				lines = append(lines, "助手调用工具: "+strings.Join(names, ", "))
+			}
+		}
+	}
+	if len(lines) > maxSummaryLines {
+		lines = lines[len(lines)-maxSummaryLines:]
+	}
+
+	// 最近的轮次中已有简历时，摘要里不再重复保留旧简历
+	for _, msg := range recent {
+		if msg.Role == "user" && strings.Contains(messageText(msg), resumeContentMarker) {
+			resume = ""
+			break
+		}
+	}
+
+	result := make([]model.Message, 0, len(head)+1+len(recent))
+	result = append(result, head...)
+	result = append(result, model.Message{Role: "system", Content: buildSummary(lines, resume)})
+	return append(result, recent...)
+}
+
+// isSummaryMessage 是否为压缩生成的摘要消息
+func isSummaryMessage(msg model.Message) bool {
+	return msg.Role == "system" && strings.HasPrefix(messageText(msg), summaryPrefix)
+}
+
+// buildSummary 生成摘要消息内容
+func buildSummary(lines []string, resume string) string {
+	var b strings.Builder
+	b.WriteString(summaryPrefix)
+	for _, line := range lines {
+		b.WriteString("- ")
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	if resume != "" {
+		b.WriteString("\n")
+		b.WriteString(resumeContentMarker)
+		b.WriteString(resume)
+	}
+	return b.String()
+}
+
+// parseSummary 解析已有摘要，返回摘要行和其中保留的简历
+func parseSummary(summary string) ([]string, string) {
+	summary = strings.TrimPrefix(summary, summaryPrefix)
+	var resume string
+	if idx := strings.Index(summary, "\n"+resumeContentMarker); idx >= 0 {
+		resume = summary[idx+1+len(resumeContentMarker):]
+		summary = summary[:idx]
+	}
+
+	var lines []string
+	for _, line := range strings.Split(summary, "\n") {
+		if line = strings.TrimPrefix(line, "- "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, resume
+}
+
+// splitResume 从用户消息中拆出简历内容，返回简历和其余文本
+func splitResume(text string) (resume, rest string, ok bool) {
+	idx := strings.LastIndex(text, resumeContentMarker)
+	if idx < 0 {
+		return "", text, false
+	}
+	resume = text[idx+len(resumeContentMarker):]
+	// 同一条消息中简历之后可能还有其他文件内容
+	if end := strings.Index(resume, "\n\n[用户上传的"); end >= 0 {
+		resume = resume[:end]
+	}
+	return resume, strings.TrimSpace(text[:idx]), true
+}
+
+// abbreviate 将文本压缩为单行并截断到 summaryLineRunes 个字符
+func abbreviate(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= summaryLineRunes {
+		return text
+	}
+	return string(runes[:summaryLineRunes]) + "…"
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"qd-sc/internal/model"
+)
+
+// longHistory 构造多轮对话：第一轮上传简历，之后每轮都有工具调用和较大的工具结果
+func longHistory(turns int) []model.Message {
+	messages := []model.Message{
+		{Role: "system", Content: "系统提示词"},
+		{Role: "user", Content: "这是我的简历\n\n" + resumeContentMarker + "姓名：张三\n学历：本科\n技能：Java"},
+		{Role: "assistant", Content: "收到您的简历。"},
+	}
+	for i := 0; i < turns; i++ {
+		messages = append(messages,
+			model.Message{Role: "user", Content: "帮我找工作 " + strings.Repeat("很长的需求描述", 20)},
+			model.Message{Role: "assistant", ToolCalls: []model.ToolCall{{ID: "call", Function: model.FunctionCall{Name: "queryJobsByArea", Arguments: `{}`}}}},
+			model.Message{Role: "tool", ToolCallID: "call", Content: "{\n  \"jobs\": \"" + strings.Repeat("岗位数据", 300) + "\"\n}"},
+			model.Message{Role: "assistant", Content: "为您找到以下岗位\n``` job-json\n{}\n```\n"},
+		)
+	}
+	return messages
+}
+
+func TestCompactMessages_WithinBudgetUnchanged(t *testing.T) {
+	messages := longHistory(1)
+	got := compactMessages(messages, compactionOptions{budget: 1 << 20, keepRecentTurns: 2, toolResultMaxTokens: 100})
+	if len(got) != len(messages) {
+		t.Fatalf("messages should be kept, got %d want %d", len(got), len(messages))
+	}
+}
+
+func TestCompactMessages_SummarizesAndKeepsLatestResume(t *testing.T) {
+	messages := longHistory(6)
+	opts := compactionOptions{budget: 1000, keepRecentTurns: 2, toolResultMaxTokens: 50}
+
+	got := compactMessages(messages, opts)
+
+	if after := estimateMessagesTokens(got); after > opts.budget {
+		t.Fatalf("compacted context %d exceeds budget %d", after, opts.budget)
+	}
+	if got[0].Content != "系统提示词" {
+		t.Fatalf("system prompt must stay first: %+v", got[0])
+	}
+	if !isSummaryMessage(got[1]) {
+		t.Fatalf("expected summary message after system prompt, got %+v", got[1])
+	}
+	summary := messageText(got[1])
+	if !strings.Contains(summary, "姓名：张三") {
+		t.Fatalf("latest resume dropped from summary: %q", summary)
+	}
+	if !strings.Contains(summary, "[展示了1个岗位卡片]") || strings.Contains(summary, "job-json") {
+		t.Fatalf("job cards not summarized: %q", summary)
+	}
+
+	// 保留的轮次必须以用户消息开始，且每条工具结果前都有对应的工具调用
+	if got[2].Role != "user" {
+		t.Fatalf("recent turns should start with a user message, got %q", got[2].Role)
+	}
+	for i, msg := range got {
+		if msg.Role == "tool" && (i == 0 || len(got[i-1].ToolCalls) == 0) {
+			t.Fatalf("tool message %d lost its tool call", i)
+		}
+	}
+}
+
+func TestCompactMessages_TruncatesStaleToolResultsFirst(t *testing.T) {
+	messages := longHistory(2)
+	staleTool, latestTool := 5, 9
+	opts := compactionOptions{budget: estimateMessagesTokens(messages) - 500, keepRecentTurns: 4, toolResultMaxTokens: 50}
+
+	got := compactMessages(messages, opts)
+
+	if len(got) != len(messages) {
+		t.Fatalf("truncating stale tool output should be enough, messages changed from %d to %d", len(messages), len(got))
+	}
+	if !strings.Contains(messageText(got[staleTool]), "已截断") {
+		t.Fatalf("stale tool result not truncated: %q", messageText(got[staleTool]))
+	}
+	if strings.Contains(messageText(got[latestTool]), "已截断") {
+		t.Fatalf("latest tool result should be kept in full")
+	}
+	if strings.Contains(messageText(got[latestTool]), "\n  ") {
+		t.Fatalf("tool JSON should be compacted")
+	}
+}
+
+func TestCompactMessages_RepeatedCompactionMergesSummary(t *testing.T) {
+	opts := compactionOptions{budget: 1500, keepRecentTurns: 1, toolResultMaxTokens: 50}
+	first := compactMessages(longHistory(4), opts)
+
+	next := append(first, model.Message{Role: "user", Content: "再找找 " + strings.Repeat("需求", 400)})
+	second := compactMessages(next, opts)
+
+	summaries := 0
+	for _, msg := range second {
+		if isSummaryMessage(msg) {
+			summaries++
+		}
+	}
+	if summaries != 1 {
+		t.Fatalf("expected exactly one summary message, got %d", summaries)
+	}
+	if !strings.Contains(messageText(second[1]), "姓名：张三") {
+		t.Fatalf("resume lost after repeated compaction")
+	}
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"qd-sc/internal/model"
+	"qd-sc/internal/session"
+	"strings"
+	"sync"
+	"time"
+)
+
+// conversationLockStripes 会话锁分片数量
+const conversationLockStripes = 64
+
+// conversationLocks 按会话ID分片的互斥锁，保证同一会话的多轮请求串行执行
+type conversationLocks [conversationLockStripes]sync.Mutex
+
+// lock 锁定会话，返回解锁函数
+func (l *conversationLocks) lock(id string) func() {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+	mu := &l[h.Sum32()%conversationLockStripes]
+	mu.Lock()
+	return mu.Unlock
+}
+
+// turnRecorder 记录一轮对话中发送给客户端的内容和工具调用结果，用于保存会话
+type turnRecorder struct {
+	emit        func(AgentEvent) error
+	content     strings.Builder
+	toolResults []model.ToolResultRecord
+}
+
+// newTurnRecorder 包装事件发送函数
+func newTurnRecorder(emit func(AgentEvent) error) *turnRecorder {
+	return &turnRecorder{emit: emit}
+}
+
+// record 记录事件后转发给原发送函数
+func (r *turnRecorder) record(event AgentEvent) error {
+	switch event.Type {
+	case AgentEventContentDelta:
+		r.content.WriteString(event.Content)
+	case AgentEventJobCards:
+		for _, segment := range RenderJobCards(event.Jobs) {
+			r.content.WriteString(segment)
+		}
+	case AgentEventToolCallFinished:
+		r.toolResults = append(r.toolResults, model.ToolResultRecord{
+			ToolCallID: event.ToolCall.ID,
+			Name:       event.ToolCall.Function.Name,
+			Arguments:  event.ToolCall.Function.Arguments,
+			Result:     event.ToolResult,
+			IsError:    event.ToolError != nil,
+			CreatedAt:  time.Now(),
+		})
+	}
+	return r.emit(event)
+}
+
+// conversationIDKey 上下文中会话ID的键
+type conversationIDKey struct{}
+
+// withConversationID 在上下文中记录会话ID，供工具按会话缓存数据
+func withConversationID(ctx context.Context, conversationID string) context.Context {
+	return context.WithValue(ctx, conversationIDKey{}, conversationID)
+}
+
+// conversationIDFrom 读取上下文中的会话ID，无状态请求返回空字符串
+func conversationIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(conversationIDKey{}).(string)
+	return id
+}
+
+// ClaimConversation 校验调用方对会话的所有权
+// 会话不存在时创建空会话并返回新签发的令牌；已存在时 token 须与创建时签发的令牌一致，否则返回 session.ErrForbidden。
+// 未配置会话存储时不做校验
+func (s *ChatService) ClaimConversation(ctx context.Context, conversationID, token string) (string, error) {
+	if s.sessions == nil {
+		return "", nil
+	}
+
+	unlock := s.convLocks.lock(conversationID)
+	defer unlock()
+
+	conv, err := s.sessions.Get(ctx, conversationID)
+	if err == nil {
+		return "", session.CheckToken(conv, token)
+	}
+	if !errors.Is(err, session.ErrNotFound) {
+		return "", fmt.Errorf("加载会话失败: %w", err)
+	}
+
+	issued, err := session.NewToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.sessions.Save(ctx, &model.Conversation{ID: conversationID, Token: issued}); err != nil {
+		return "", fmt.Errorf("创建会话失败: %w", err)
+	}
+	log.Printf("创建新会话: %s", conversationID)
+	return issued, nil
+}
+
+// runConversation 加载会话历史、运行智能体，并在成功后保存本轮对话
+// 未携带会话ID或未配置会话存储时按无状态方式处理
+func (s *ChatService) runConversation(ctx context.Context, req *model.ChatCompletionRequest, emit func(AgentEvent) error) error {
+	ctx = withRedactionVault(ctx)
+	if req.ConversationID == "" || s.sessions == nil {
+		newMessages, resume := s.processUserMessages(ctx, req.Messages)
+		ctx = withResumeProfile(ctx, s.parseResumeProfile(ctx, resume))
+		ctx = withJobQueryState(ctx, newJobQueryState(nil))
+		return s.runAgentLoop(ctx, req, newMessages, nil, emit)
+	}
+
+	// 同一会话的请求串行执行，避免并发写入覆盖历史
+	unlock := s.convLocks.lock(req.ConversationID)
+	defer unlock()
+
+	conv, err := s.sessions.Get(ctx, req.ConversationID)
+	if errors.Is(err, session.ErrNotFound) {
+		log.Printf("创建新会话: %s", req.ConversationID)
+		conv = &model.Conversation{ID: req.ConversationID}
+	} else if err != nil {
+		return fmt.Errorf("加载会话失败: %w", err)
+	}
+
+	ctx = withConversationID(ctx, req.ConversationID)
+	newMessages, resume := s.processUserMessages(ctx, req.Messages)
+	if profile := s.parseResumeProfile(ctx, resume); profile != nil {
+		conv.Profile = profile
+	}
+	ctx = withResumeProfile(ctx, conv.Profile)
+	jobQuery := newJobQueryState(conv.LastJobQueries)
+	ctx = withJobQueryState(ctx, jobQuery)
+
+	history := make([]model.Message, 0, len(conv.Messages)+len(newMessages))
+	history = append(history, conv.Messages...)
+	history = append(history, newMessages...)
+
+	recorder := newTurnRecorder(emit)
+	if err := s.runAgentLoop(ctx, req, history, s.knownJobs(conv), recorder.record); err != nil {
+		return err
+	}
+
+	// 回复已发送给客户端，保存失败只记录日志
+	conv.LastJobQueries = jobQuery.get()
+	if err := s.saveConversation(ctx, conv, newMessages, resume, recorder); err != nil {
+		log.Printf("保存会话失败 [%s]: %v", conv.ID, err)
+	}
+	return nil
+}
+
+// knownJobs 会话中此前岗位工具成功返回的岗位，用于核验后续回复
+func (s *ChatService) knownJobs(conv *model.Conversation) []model.FormattedJob {
+	var jobs []model.FormattedJob
+	for _, r := range conv.ToolResults {
+		if r.IsError || !s.tools.Flags(r.Name).JobTool {
+			continue
+		}
+		if jobResp, err := parseJobResponse(r.Result); err == nil {
+			jobs = append(jobs, jobResp.JobListings...)
+		}
+	}
+	return jobs
+}
+
+// saveConversation 追加本轮消息、工具结果和简历内容后保存会话（画像已在加载时更新）
+func (s *ChatService) saveConversation(
+	ctx context.Context,
+	conv *model.Conversation,
+	newMessages []model.Message,
+	resume string,
+	recorder *turnRecorder,
+) error {
+	conv.Messages = append(conv.Messages, newMessages...)
+	if reply := strings.TrimSpace(recorder.content.String()); reply != "" {
+		conv.Messages = append(conv.Messages, model.Message{Role: "assistant", Content: reply})
+	}
+	conv.Messages = trimHistory(conv.Messages, s.cfg.Session.MaxMessages)
+	conv.ToolResults = trimToolResults(append(conv.ToolResults, recorder.toolResults...), s.cfg.Session.MaxToolResults)
+	if resume != "" {
+		conv.Resume = resume
+	}
+
+	if err := s.sessions.Save(ctx, conv); err != nil {
+		return err
+	}
+	log.Printf("会话已保存 [%s]，历史消息数: %d", conv.ID, len(conv.Messages))
+	return nil
+}
+
+// trimToolResults 只保留最近 maxResults 条工具调用记录
+func trimToolResults(results []model.ToolResultRecord, maxResults int) []model.ToolResultRecord {
+	if maxResults <= 0 || len(results) <= maxResults {
+		return results
+	}
+	return append([]model.ToolResultRecord(nil), results[len(results)-maxResults:]...)
+}
+
+// trimHistory 只保留最近 maxMessages 条消息，且保证历史以用户消息开头
+func trimHistory(messages []model.Message, maxMessages int) []model.Message {
+	if maxMessages <= 0 || len(messages) <= maxMessages {
+		return messages
+	}
+
+	trimmed := messages[len(messages)-maxMessages:]
+	for len(trimmed) > 0 && trimmed[0].Role != "user" {
+		trimmed = trimmed[1:]
+	}
+	return append([]model.Message(nil), trimmed...)
+}
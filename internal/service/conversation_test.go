@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"qd-sc/internal/model"
+	"qd-sc/internal/session"
+)
+
+// runTurn 在指定会话中运行一轮对话
+func runTurn(t *testing.T, s *ChatService, conversationID, userMessage string) {
+	t.Helper()
+
+	events, errs := s.RunAgent(context.Background(), &model.ChatCompletionRequest{
+		Model:          ExposedModelName,
+		Messages:       []model.Message{{Role: "user", Content: userMessage}},
+		ConversationID: conversationID,
+	})
+	for range events {
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("RunAgent error: %v", err)
+	}
+}
+
+func TestRunAgent_ConversationHistoryPersisted(t *testing.T) {
+	llm := &fakeLLM{turns: [][]model.ChatCompletionChunk{
+		textTurn("你好，我是就业助手。"),
+		textTurn("您刚才说了你好。"),
+	}}
+	s := newTestChatService(t, llm, nil)
+
+	runTurn(t, s, "conv-1", "你好")
+	runTurn(t, s, "conv-1", "我刚才说了什么？")
+
+	// 第二轮请求应携带第一轮的历史：system + user + assistant + user
+	second := llm.requests[1].Messages
+	if len(second) != 4 {
+		t.Fatalf("expected 4 messages in second request, got %d: %+v", len(second), second)
+	}
+	if second[1].Content != "你好" || second[2].Role != "assistant" || second[2].Content != "你好，我是就业助手。" {
+		t.Fatalf("history not replayed: %+v", second)
+	}
+
+	conv, err := s.sessions.Get(context.Background(), "conv-1")
+	if err != nil {
+		t.Fatalf("get conversation: %v", err)
+	}
+	if len(conv.Messages) != 4 {
+		t.Fatalf("expected 4 stored messages, got %d", len(conv.Messages))
+	}
+}
+
+func TestRunAgent_ConversationStoresToolResults(t *testing.T) {
+	llm := &fakeLLM{turns: [][]model.ChatCompletionChunk{
+		toolCallTurn("queryJobsByArea", `{"jobTitle":"Java","current":1,"pageSize":10}`),
+	}}
+	rows := []model.JobListing{{JobTitle: "Java开发工程师", CompanyName: "青岛软件园", AppJobURL: "https://jobs.example/1"}}
+	s := newTestChatService(t, llm, rows)
+
+	runTurn(t, s, "conv-jobs", "帮我找Java岗位")
+
+	conv, err := s.sessions.Get(context.Background(), "conv-jobs")
+	if err != nil {
+		t.Fatalf("get conversation: %v", err)
+	}
+	if len(conv.ToolResults) != 1 || conv.ToolResults[0].Name != "queryJobsByArea" || conv.ToolResults[0].IsError {
+		t.Fatalf("unexpected tool results: %+v", conv.ToolResults)
+	}
+	last := conv.Messages[len(conv.Messages)-1]
+	if last.Role != "assistant" || last.Content == "" {
+		t.Fatalf("job cards reply not stored: %+v", last)
+	}
+}
+
+func TestClaimConversation_IssuesAndChecksToken(t *testing.T) {
+	llm := &fakeLLM{turns: [][]model.ChatCompletionChunk{textTurn("你好，我是就业助手。")}}
+	s := newTestChatService(t, llm, nil)
+	ctx := context.Background()
+
+	token, err := s.ClaimConversation(ctx, "conv-owner", "")
+	if err != nil || token == "" {
+		t.Fatalf("first claim should issue a token, got %q, %v", token, err)
+	}
+	runTurn(t, s, "conv-owner", "你好")
+
+	// 保存本轮对话后令牌不变，只有持有令牌的调用方可以继续会话
+	if issued, err := s.ClaimConversation(ctx, "conv-owner", token); err != nil || issued != "" {
+		t.Fatalf("owner claim = %q, %v", issued, err)
+	}
+	for _, other := range []string{"", "guess"} {
+		if _, err := s.ClaimConversation(ctx, "conv-owner", other); !errors.Is(err, session.ErrForbidden) {
+			t.Fatalf("claim with token %q = %v, want ErrForbidden", other, err)
+		}
+	}
+	if conv, _ := s.sessions.Get(ctx, "conv-owner"); len(conv.Messages) != 2 {
+		t.Fatalf("turn not stored in claimed conversation: %+v", conv)
+	}
+}
+
+func TestRunAgent_StatelessWithoutConversationID(t *testing.T) {
+	llm := &fakeLLM{turns: [][]model.ChatCompletionChunk{textTurn("好的")}}
+	s := newTestChatService(t, llm, nil)
+
+	collectEvents(t, s, "你好")
+
+	if list, _ := s.sessions.List(context.Background()); len(list) != 0 {
+		t.Fatalf("stateless request should not create sessions: %+v", list)
+	}
+	if _, err := s.sessions.Get(context.Background(), ""); !errors.Is(err, session.ErrNotFound) {
+		t.Fatalf("unexpected conversation for empty id: %v", err)
+	}
+}
+
+func TestTrimHistory(t *testing.T) {
+	messages := []model.Message{
+		{Role: "user", Content: "1"}, {Role: "assistant", Content: "1"},
+		{Role: "user", Content: "2"}, {Role: "assistant", Content: "2"},
+		{Role: "user", Content: "3"}, {Role: "assistant", Content: "3"},
+	}
+
+	trimmed := trimHistory(messages, 3)
+	if len(trimmed) != 2 || trimmed[0].Role != "user" || trimmed[0].Content != "3" {
+		t.Fatalf("unexpected trimmed history: %+v", trimmed)
+	}
+	if got := trimHistory(messages, 0); len(got) != len(messages) {
+		t.Fatalf("maxMessages<=0 should keep all messages")
+	}
+}
+
+func TestTrimToolResults(t *testing.T) {
+	var results []model.ToolResultRecord
+	for i := 0; i < 5; i++ {
+		results = append(results, model.ToolResultRecord{Name: "queryJobsByArea", Result: strconv.Itoa(i)})
+	}
+
+	trimmed := trimToolResults(results, 2)
+	if len(trimmed) != 2 || trimmed[0].Result != "3" || trimmed[1].Result != "4" {
+		t.Fatalf("unexpected trimmed tool results: %+v", trimmed)
+	}
+	if got := trimToolResults(results, 0); len(got) != len(results) {
+		t.Fatalf("maxResults<=0 should keep all tool results")
+	}
+}
+
+func TestRunAgent_GroundingStripsFabricatedCompany(t *testing.T) {
+	llm := &fakeLLM{turns: [][]model.ChatCompletionChunk{
+		toolCallTurn("queryJobsByArea", `{"jobTitle":"Java","current":1,"pageSize":10}`),
+		textTurn("青岛软件园的Java开发工程师比较适合您。", "另外海尔智家股份有限公司也在招聘同类岗位。"),
+	}}
+	rows := []model.JobListing{{JobTitle: "Java开发工程师", CompanyName: "青岛软件园", AppJobURL: "https://jobs.example/1"}}
+	s := newTestChatService(t, llm, rows)
+	s.cfg.Grounding.ExposeVerdict = true
+
+	runTurn(t, s, "conv-grounding", "帮我找Java岗位")
+
+	events, errs := s.RunAgent(context.Background(), &model.ChatCompletionRequest{
+		Model:          ExposedModelName,
+		Messages:       []model.Message{{Role: "user", Content: "第一个怎么样？"}},
+		ConversationID: "conv-grounding",
+	})
+	var collected []AgentEvent
+	for event := range events {
+		collected = append(collected, event)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("RunAgent error: %v", err)
+	}
+
+	// 会话中已有的岗位用于核验，编造的公司所在句子被删除
+	if got := joinContent(collected); got != "青岛软件园的Java开发工程师比较适合您。" {
+		t.Fatalf("content = %q", got)
+	}
+	final := collected[len(collected)-1]
+	if final.Type != AgentEventFinal || final.Grounding == nil {
+		t.Fatalf("expected final event with verdict, got %+v", final)
+	}
+	if v := final.Grounding; v.Grounded || v.Action != "strip" || len(v.Unsupported) != 1 || v.Unsupported[0].Text != "海尔智家股份有限公司" {
+		t.Fatalf("unexpected verdict: %+v", v)
+	}
+}
+
+func TestGetJobDetailTool_ResolvesShownJobs(t *testing.T) {
+	llm := &fakeLLM{turns: [][]model.ChatCompletionChunk{
+		toolCallTurn("queryJobsByArea", `{"jobTitle":"Java","current":1,"pageSize":10}`),
+		toolCallTurn("getJobDetail", `{"index":2}`),
+		textTurn("该岗位提供五险一金。"),
+		toolCallTurn("getJobDetail", `{"jobId":"J-999"}`),
+		textTurn("抱歉，没有找到该岗位。"),
+	}}
+	rows := []model.JobListing{
+		{JobID: "J-1", JobTitle: "Java开发工程师", CompanyName: "青岛软件园", AppJobURL: "https://jobs.example/1"},
+		{JobID: "J-2", JobTitle: "Java测试工程师", CompanyName: "海洋科技", AppJobURL: "https://jobs.example/2"},
+		{JobID: "J-999", JobTitle: "未展示岗位", CompanyName: "其他公司"},
+	}
+	s := newTestChatService(t, llm, rows[:2])
+
+	runTurn(t, s, "conv-detail", "帮我找Java岗位")
+	runTurn(t, s, "conv-detail", "第二个岗位具体要求是什么")
+
+	// 第三次LLM请求携带 getJobDetail 的结果
+	detailResult := llm.requests[2].Messages[len(llm.requests[2].Messages)-1]
+	var detail model.FormattedJobDetail
+	if err := json.Unmarshal([]byte(detailResult.Content.(string)), &detail); err != nil {
+		t.Fatalf("decode detail %q: %v", detailResult.Content, err)
+	}
+	if detail.JobID != "J-2" || detail.CompanyName != "海洋科技" || detail.Description != "Java测试工程师岗位职责" {
+		t.Fatalf("unexpected detail: %+v", detail)
+	}
+	if len(detail.Benefits) != 3 || detail.Contact == nil || detail.Contact.Phone != "0532-88888888" {
+		t.Fatalf("unexpected benefits/contact: %+v", detail)
+	}
+
+	// 未展示过的岗位ID被拒绝，不请求岗位API
+	runTurn(t, s, "conv-detail", "J-999 这个岗位呢")
+	rejected := llm.requests[4].Messages[len(llm.requests[4].Messages)-1]
+	if content, _ := rejected.Content.(string); !strings.Contains(content, "不在已展示的岗位中") {
+		t.Fatalf("expected unknown job id to be rejected, got %q", content)
+	}
+}
+
+func TestShownJobs_Resolve(t *testing.T) {
+	cards := RenderJobCards(&model.JobResponse{JobListings: []model.FormattedJob{
+		{JobID: "A-1", JobTitle: "A"}, {JobID: "A-2", JobTitle: "B"},
+	}})
+	shown := newShownJobs([]model.Message{{Role: "assistant", Content: strings.Join(cards, "")}})
+	shown.add([]model.FormattedJob{{JobID: "B-1", JobTitle: "C"}})
+
+	if job, err := shown.resolve("", 1); err != nil || job.JobID != "B-1" {
+		t.Fatalf("index should resolve against latest batch, got %+v %v", job, err)
+	}
+	if job, err := shown.resolve("A-2", 0); err != nil || job.JobTitle != "B" {
+		t.Fatalf("earlier job id should resolve, got %+v %v", job, err)
+	}
+	if _, err := shown.resolve("", 2); err == nil {
+		t.Fatal("out of range index should fail")
+	}
+	if _, err := newShownJobs(nil).resolve("A-1", 0); err == nil {
+		t.Fatal("empty registry should fail")
+	}
+}
+
+func TestMoreJobsTool_ContinuesLastQuery(t *testing.T) {
+	llm := &fakeLLM{turns: [][]model.ChatCompletionChunk{
+		toolCallTurn("moreJobs", `{}`),
+		textTurn("请先告诉我想找什么岗位。"),
+		toolCallTurn("queryJobsByArea", `{"jobTitle":"Java","current":1,"pageSize":10}`),
+		toolCallTurn("moreJobs", `{}`),
+	}}
+	rows := []model.JobListing{
+		{JobID: "J-1", JobTitle: "Java开发工程师", CompanyName: "青岛软件园", AppJobURL: "https://jobs.example/1"},
+		{JobID: "J-2", JobTitle: "Java测试工程师", CompanyName: "海洋科技", AppJobURL: "https://jobs.example/2"},
+	}
+	s := newTestChatService(t, llm, rows)
+
+	// 没有查询过岗位时提示先查询
+	runTurn(t, s, "conv-more", "再看看更多")
+	rejected := llm.requests[1].Messages[len(llm.requests[1].Messages)-1]
+	if content, _ := rejected.Content.(string); !strings.Contains(content, "还没有查询过岗位") {
+		t.Fatalf("expected moreJobs without prior query to fail, got %q", content)
+	}
+
+	runTurn(t, s, "conv-more", "帮我找Java岗位")
+	conv, err := s.sessions.Get(context.Background(), "conv-more")
+	if err != nil {
+		t.Fatalf("get conversation: %v", err)
+	}
+	if len(conv.LastJobQueries) != 1 || conv.LastJobQueries[0].JobTitle != "Java" || conv.LastJobQueries[0].Current != 1 {
+		t.Fatalf("last query not stored: %+v", conv.LastJobQueries)
+	}
+
+	// 下一页返回的岗位均已展示过，且不足一页，视为没有更多
+	runTurn(t, s, "conv-more", "换一批")
+	conv, err = s.sessions.Get(context.Background(), "conv-more")
+	if err != nil {
+		t.Fatalf("get conversation: %v", err)
+	}
+	if len(conv.LastJobQueries) != 1 || conv.LastJobQueries[0].JobTitle != "Java" || conv.LastJobQueries[0].Current != 2 {
+		t.Fatalf("page not advanced: %+v", conv.LastJobQueries)
+	}
+	last, _ := conv.Messages[len(conv.Messages)-1].Content.(string)
+	if !strings.Contains(last, "没有更多符合条件的岗位") || strings.Contains(last, "job-json") {
+		t.Fatalf("expected exhausted notice without repeated cards, got %q", last)
+	}
+}
@@ -0,0 +1,485 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"qd-sc/internal/client"
+	"qd-sc/internal/config"
+	"qd-sc/internal/docextract"
+	"qd-sc/internal/filestore"
+	"qd-sc/pkg/metrics"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// 文件类型
+const (
+	FileKindPDF   = "pdf"
+	FileKindImage = "image"
+	FileKindOther = "file"
+)
+
+// ExtractPathOCR 文件解析路径：调用OCR服务（本地提取路径见 docextract.Method*）
+const ExtractPathOCR = "ocr"
+
+// maxRedirects 下载远程文件时允许的最大重定向次数
+const maxRedirects = 5
+
+// imageExtensions 按扩展名识别的图片格式
+var imageExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".bmp": true, ".gif": true, ".webp": true, ".tif": true, ".tiff": true,
+}
+
+// mimeExtensions data: URL 内容提交给OCR服务时使用的文件扩展名
+var mimeExtensions = map[string]string{
+	"application/pdf":  ".pdf",
+	"image/png":        ".png",
+	"image/jpeg":       ".jpg",
+	"image/gif":        ".gif",
+	"image/bmp":        ".bmp",
+	"image/webp":       ".webp",
+	filestore.MimeDOCX: ".docx",
+	filestore.MimeDOC:  ".doc",
+}
+
+// ParsedFile 文件解析结果（parsePDF/parseImage 工具的返回结构）
+type ParsedFile struct {
+	URL      string `json:"url,omitempty"`
+	FileID   string `json:"file_id,omitempty"` // 通过 /v1/files 上传的文件ID
+	Kind     string `json:"kind"`              // pdf / image / file
+	Text     string `json:"text"`              // 解析出的文本
+	Pages    int    `json:"pages"`             // 页数（图片为1，无法判断时为0）
+	IsResume bool   `json:"is_resume"`         // 内容是否为简历
+	Cached   bool   `json:"cached"`            // 是否命中会话内缓存
+}
+
+// fileCacheEntry 文件解析缓存项
+type fileCacheEntry struct {
+	file     ParsedFile
+	lastUsed time.Time
+}
+
+// FileParser 文件解析服务
+// 校验文件URL（或解析上传文件ID）、提取文本并识别简历，同一会话内按URL/文件ID缓存解析结果
+// 有文本层的PDF和DOCX在本地直接提取，扫描件、图片和其他类型才调用OCR服务
+type FileParser struct {
+	ocrClient      *client.OCRClient
+	extractor      *docextract.Extractor
+	httpClient     *http.Client     // 下载远程PDF/DOCX用于本地提取
+	maxDownload    int64            // 下载大小上限，与上传文件大小上限一致
+	files          *filestore.Store // 上传文件存储，为nil时不支持文件ID
+	allowedSchemes map[string]bool
+	allowedHosts   []string
+	ttl            time.Duration // 缓存有效期（按最近使用时间计算）
+	maxEntries     int           // 缓存容量上限
+
+	mu       sync.Mutex
+	cache    map[string]*fileCacheEntry // key: 会话ID + "\x00" + url:<URL> / sha256:<内容摘要>
+	inflight singleflight.Group
+	now      func() time.Time
+}
+
+// NewFileParser 创建文件解析服务
+func NewFileParser(cfg *config.Config, ocrClient *client.OCRClient, files *filestore.Store) *FileParser {
+	schemes := make(map[string]bool, len(cfg.OCR.AllowedSchemes))
+	for _, scheme := range cfg.OCR.AllowedSchemes {
+		schemes[strings.ToLower(scheme)] = true
+	}
+	hosts := make([]string, 0, len(cfg.OCR.AllowedHosts))
+	for _, host := range cfg.OCR.AllowedHosts {
+		hosts = append(hosts, strings.ToLower(host))
+	}
+
+	p := &FileParser{
+		ocrClient:      ocrClient,
+		extractor:      docextract.New(&cfg.Extraction),
+		maxDownload:    cfg.Files.MaxSize,
+		files:          files,
+		allowedSchemes: schemes,
+		allowedHosts:   hosts,
+		ttl:            cfg.Attachments.CacheTTL,
+		maxEntries:     cfg.Attachments.CacheSize,
+		cache:          make(map[string]*fileCacheEntry),
+		now:            time.Now,
+	}
+	p.httpClient = &http.Client{
+		Timeout:   cfg.Extraction.DownloadTimeout,
+		Transport: newFileFetchTransport(),
+		// 重定向目标同样需要通过URL白名单和内网地址校验
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("重定向次数过多")
+			}
+			return p.validateURL(req.URL.String(), !isUserAttachment(req.Context()))
+		},
+	}
+	return p
+}
+
+type userAttachmentKey struct{}
+
+// withUserAttachment 标记本次解析的是用户随消息发送的附件（而非模型调用解析工具时给出的URL）
+func withUserAttachment(ctx context.Context) context.Context {
+	return context.WithValue(ctx, userAttachmentKey{}, true)
+}
+
+// isUserAttachment 是否在解析用户随消息发送的附件
+func isUserAttachment(ctx context.Context) bool {
+	v, _ := ctx.Value(userAttachmentKey{}).(bool)
+	return v
+}
+
+// ValidateURL 校验解析工具给出的文件URL：协议和主机须在允许范围内，
+// 未配置 ocr.allowed_hosts 时不允许解析任何远程URL，"*" 表示允许所有主机；
+// 内网IP和 localhost 始终拒绝，域名解析到内网地址时由下载连接拒绝
+func (p *FileParser) ValidateURL(rawURL string) error {
+	return p.validateURL(rawURL, true)
+}
+
+// validateURL 校验文件URL；requireAllowlist 为 false 时（用户随消息发送的附件）未配置 ocr.allowed_hosts 不限制主机
+func (p *FileParser) validateURL(rawURL string, requireAllowlist bool) error {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("文件URL格式不正确: %s", rawURL)
+	}
+	if !p.allowedSchemes[strings.ToLower(u.Scheme)] {
+		return fmt.Errorf("不支持的文件URL协议: %s", u.Scheme)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if err := checkHostLiteral(host); err != nil {
+		return err
+	}
+	if len(p.allowedHosts) == 0 {
+		if requireAllowlist {
+			return fmt.Errorf("未配置 ocr.allowed_hosts，不支持解析远程文件URL")
+		}
+		return nil
+	}
+	for _, allowed := range p.allowedHosts {
+		if allowed == "*" || host == allowed {
+			return nil
+		}
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok && strings.HasSuffix(host, "."+suffix) {
+			return nil
+		}
+	}
+	return fmt.Errorf("文件URL主机不在允许范围内: %s", host)
+}
+
+// Parse 解析文件，解析结果在 conversationID 对应的作用域内缓存（为空时为无会话请求共享的作用域）
+// fileRef 为文件URL、上传文件ID或 base64 编码的 data: URL；kind 为空时按URL扩展名判断文件类型，
+// 上传文件和 data: URL 按内容识别的类型判断。远程文件按URL缓存，上传文件和 data: URL 按内容摘要缓存，
+// 因此重复发送的历史消息和内容相同的附件不会重复解析；同一文件的并发解析只执行一次
+func (p *FileParser) Parse(ctx context.Context, conversationID, fileRef, kind string) (*ParsedFile, error) {
+	src, err := p.resolveSource(ctx, strings.TrimSpace(fileRef), kind)
+	if err != nil {
+		return nil, err
+	}
+
+	key := conversationID + "\x00" + src.cacheKey
+	if cached, ok := p.lookup(key); ok {
+		metrics.GetGlobalMetrics().RecordFileCache(true)
+		log.Printf("文件解析命中缓存 [%s]: %s", conversationID, describeFileRef(src.ref))
+		cached.URL, cached.FileID = src.url, src.fileID
+		cached.Cached = true
+		return &cached, nil
+	}
+	metrics.GetGlobalMetrics().RecordFileCache(false)
+
+	v, err, _ := p.inflight.Do(key, func() (interface{}, error) {
+		file, err := p.parseSource(ctx, src)
+		if err != nil {
+			return nil, err
+		}
+		file.IsResume = isResumeContent(file.Text)
+		if file.Kind == FileKindImage {
+			file.Pages = 1
+		}
+		p.store(key, *file)
+		return *file, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	file := v.(ParsedFile)
+	file.URL, file.FileID = src.url, src.fileID
+	return &file, nil
+}
+
+// fileSource 解析前的文件来源；上传文件和 data: URL 已读取内容
+type fileSource struct {
+	ref      string // 原始引用，用于日志
+	url      string
+	fileID   string
+	kind     string
+	filename string
+	mimeType string
+	data     []byte
+	cacheKey string // url:<URL> 或 sha256:<内容摘要>
+}
+
+// resolveSource 校验文件引用并确定缓存键：远程URL按URL，上传文件和 data: URL 读取内容后按内容摘要
+func (p *FileParser) resolveSource(ctx context.Context, fileRef, kind string) (*fileSource, error) {
+	src := &fileSource{ref: fileRef, kind: kind}
+	switch {
+	case filestore.IsDataURL(fileRef):
+		// 大小和类型限制与上传文件相同（files.max_size / files.allowed_types），类型按内容识别
+		if p.files == nil {
+			return nil, fmt.Errorf("文件上传服务未启用，无法解析data URL")
+		}
+		declared, data, err := filestore.DecodeDataURL(fileRef, p.files.MaxSize())
+		if err != nil {
+			return nil, err
+		}
+		mimeType, err := p.files.Validate("", data)
+		if err != nil {
+			return nil, err
+		}
+		if declared != "" && declared != mimeType {
+			log.Printf("data URL声明的类型 %s 与内容识别的类型 %s 不一致，按内容类型处理", declared, mimeType)
+		}
+		src.filename, src.mimeType, src.data = "inline"+mimeExtensions[mimeType], mimeType, data
+	case filestore.IsFileID(fileRef):
+		if p.files == nil {
+			return nil, fmt.Errorf("文件上传服务未启用: %s", fileRef)
+		}
+		meta, data, err := p.files.Read(fileRef)
+		if err != nil {
+			return nil, fmt.Errorf("读取上传文件失败 [%s]: %w", fileRef, err)
+		}
+		src.fileID, src.filename, src.mimeType, src.data = fileRef, meta.Filename, meta.MimeType, data
+	default:
+		if err := p.validateURL(fileRef, !isUserAttachment(ctx)); err != nil {
+			return nil, err
+		}
+		if src.kind == "" {
+			src.kind = detectFileKind(fileRef)
+		}
+		src.url = fileRef
+		src.cacheKey = "url:" + fileRef
+		return src, nil
+	}
+
+	src.kind = mimeFileKind(src.mimeType)
+	sum := sha256.Sum256(src.data)
+	src.cacheKey = "sha256:" + hex.EncodeToString(sum[:])
+	return src, nil
+}
+
+// parseSource 解析文件内容
+func (p *FileParser) parseSource(ctx context.Context, src *fileSource) (*ParsedFile, error) {
+	if src.url != "" {
+		return p.parseURL(ctx, src.url, src.kind)
+	}
+	result, err := p.parseContent(ctx, src.ref, src.filename, src.mimeType, src.data)
+	if err != nil {
+		return nil, err
+	}
+	return &ParsedFile{Kind: src.kind, Text: result.Text, Pages: result.Pages}, nil
+}
+
+// parseURL 解析远程文件：PDF/DOCX 先下载并尝试本地提取，否则由OCR服务按URL解析
+func (p *FileParser) parseURL(ctx context.Context, fileURL, kind string) (*ParsedFile, error) {
+	if mimeType := urlMimeType(fileURL); p.extractor.Supports(mimeType) {
+		data, err := p.download(ctx, fileURL)
+		if err != nil {
+			metrics.GetGlobalMetrics().IncExtractionFallbacks()
+			log.Printf("下载文件失败，改由OCR服务解析 [%s]: %v", fileURL, err)
+		} else if res := p.extractLocal(fileURL, filestore.DetectType(data, fileURL), data); res != nil {
+			return &ParsedFile{URL: fileURL, Kind: kind, Text: res.Text, Pages: res.Pages}, nil
+		}
+	}
+
+	start := time.Now()
+	result, err := p.ocrClient.ParseURLDetail(ctx, fileURL)
+	if err != nil {
+		return nil, err
+	}
+	p.recordOCR(fileURL, start, result)
+	return &ParsedFile{URL: fileURL, Kind: kind, Text: result.Text, Pages: result.Pages}, nil
+}
+
+// parseContent 解析已读取的文件内容：先尝试本地提取，无法提取时上传给OCR服务
+func (p *FileParser) parseContent(ctx context.Context, ref, filename, mimeType string, data []byte) (*client.OCRResult, error) {
+	if res := p.extractLocal(ref, mimeType, data); res != nil {
+		return &client.OCRResult{Text: res.Text, Pages: res.Pages}, nil
+	}
+
+	start := time.Now()
+	result, err := p.ocrClient.ParseFileDetail(ctx, filename, data)
+	if err != nil {
+		return nil, err
+	}
+	p.recordOCR(ref, start, result)
+	return result, nil
+}
+
+// extractLocal 尝试本地提取文档文本，类型不支持或没有可用文本层时返回nil（调用方回退到OCR）
+func (p *FileParser) extractLocal(ref, mimeType string, data []byte) *docextract.Result {
+	if !p.extractor.Supports(mimeType) {
+		return nil
+	}
+
+	start := time.Now()
+	res, err := p.extractor.Extract(data, mimeType)
+	elapsed := time.Since(start)
+	if err != nil {
+		metrics.GetGlobalMetrics().IncExtractionFallbacks()
+		if errors.Is(err, docextract.ErrNoTextLayer) {
+			log.Printf("文件没有可用文本层，回退到OCR [%s]: %v（耗时%v）", describeFileRef(ref), err, elapsed)
+		} else {
+			log.Printf("本地提取文件失败，回退到OCR [%s]: %v（耗时%v）", describeFileRef(ref), err, elapsed)
+		}
+		return nil
+	}
+
+	metrics.GetGlobalMetrics().RecordExtraction(res.Method, elapsed)
+	log.Printf("文件解析完成 [%s]: 路径=%s 页数=%d 字符数=%d 耗时=%v", describeFileRef(ref), res.Method, res.Pages, len([]rune(res.Text)), elapsed)
+	return res
+}
+
+// recordOCR 记录OCR解析路径的耗时
+func (p *FileParser) recordOCR(ref string, start time.Time, result *client.OCRResult) {
+	elapsed := time.Since(start)
+	metrics.GetGlobalMetrics().RecordExtraction(ExtractPathOCR, elapsed)
+	log.Printf("文件解析完成 [%s]: 路径=%s 页数=%d 字符数=%d 耗时=%v", describeFileRef(ref), ExtractPathOCR, result.Pages, len([]rune(result.Text)), elapsed)
+}
+
+// download 下载远程文件，大小上限与上传文件相同；只连接公网地址（见 newFileFetchTransport）
+func (p *FileParser) download(ctx context.Context, fileURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建下载请求失败: %w", err)
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("下载文件失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下载文件失败: 状态码 %d", resp.StatusCode)
+	}
+	limit := p.maxDownload
+	if limit <= 0 {
+		limit = 10 << 20
+	}
+	if resp.ContentLength > limit {
+		return nil, fmt.Errorf("%w: %d 字节", filestore.ErrTooLarge, resp.ContentLength)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取下载内容失败: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: 超过 %d 字节", filestore.ErrTooLarge, limit)
+	}
+	return data, nil
+}
+
+// describeFileRef 返回用于日志的文件引用描述，data: URL 只输出类型和长度
+func describeFileRef(fileRef string) string {
+	if !filestore.IsDataURL(fileRef) {
+		return fileRef
+	}
+	meta, _, _ := strings.Cut(fileRef, ",")
+	return fmt.Sprintf("%s,...（共%d字符）", meta, len(fileRef))
+}
+
+// lookup 读取未过期的缓存项
+func (p *FileParser) lookup(key string) (ParsedFile, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.cache[key]
+	if !ok {
+		return ParsedFile{}, false
+	}
+	now := p.now()
+	if p.ttl > 0 && now.Sub(entry.lastUsed) > p.ttl {
+		delete(p.cache, key)
+		return ParsedFile{}, false
+	}
+	entry.lastUsed = now
+	return entry.file, true
+}
+
+// store 写入缓存，并顺带清理过期项；超过容量时淘汰最久未使用的缓存项
+func (p *FileParser) store(key string, file ParsedFile) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	if p.ttl > 0 {
+		for k, entry := range p.cache {
+			if now.Sub(entry.lastUsed) > p.ttl {
+				delete(p.cache, k)
+			}
+		}
+	}
+	if _, exists := p.cache[key]; !exists && p.maxEntries > 0 && len(p.cache) >= p.maxEntries {
+		oldestKey, oldest := "", now
+		for k, entry := range p.cache {
+			if oldestKey == "" || entry.lastUsed.Before(oldest) {
+				oldestKey, oldest = k, entry.lastUsed
+			}
+		}
+		delete(p.cache, oldestKey)
+	}
+	p.cache[key] = &fileCacheEntry{file: file, lastUsed: now}
+}
+
+// mimeFileKind 按MIME类型判断文件类型
+func mimeFileKind(mimeType string) string {
+	switch {
+	case mimeType == "application/pdf":
+		return FileKindPDF
+	case strings.HasPrefix(mimeType, "image/"):
+		return FileKindImage
+	default:
+		return FileKindOther
+	}
+}
+
+// urlMimeType 按URL扩展名推断可本地提取的文档类型，其他情况返回空
+func urlMimeType(fileURL string) string {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return ""
+	}
+	switch strings.ToLower(path.Ext(u.Path)) {
+	case ".pdf":
+		return docextract.MimePDF
+	case ".docx":
+		return docextract.MimeDOCX
+	}
+	return ""
+}
+
+// detectFileKind 按URL扩展名判断文件类型
+func detectFileKind(fileURL string) string {
+	ext := ""
+	if u, err := url.Parse(fileURL); err == nil {
+		ext = strings.ToLower(path.Ext(u.Path))
+	}
+	switch {
+	case ext == ".pdf":
+		return FileKindPDF
+	case imageExtensions[ext]:
+		return FileKindImage
+	default:
+		return FileKindOther
+	}
+}
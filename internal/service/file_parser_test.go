@@ -0,0 +1,459 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"qd-sc/internal/client"
+	"qd-sc/internal/config"
+	"qd-sc/internal/docextract"
+	"qd-sc/internal/filestore"
+	"qd-sc/internal/model"
+	"qd-sc/internal/redact"
+	"qd-sc/internal/tool"
+	"qd-sc/pkg/metrics"
+)
+
+// newTestFileParser 创建连接到假OCR服务的文件解析服务
+func newTestFileParser(t *testing.T, data string, hosts ...string) (*FileParser, *int32) {
+	t.Helper()
+
+	var calls int32
+	ocrServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		_ = json.NewEncoder(w).Encode(client.OCRResponse{Code: 200, Data: data})
+	}))
+	t.Cleanup(ocrServer.Close)
+
+	cfg := &config.Config{}
+	cfg.OCR.BaseURL = ocrServer.URL
+	cfg.OCR.AllowedSchemes = []string{"https"}
+	cfg.OCR.AllowedHosts = hosts
+	// 不访问真实网络，本地提取由 withTestFileServer 单独覆盖
+	disabled := false
+	cfg.Extraction.Enabled = &disabled
+	return NewFileParser(cfg, client.NewOCRClient(cfg), nil), &calls
+}
+
+// textPDF 只有一页文本的PDF
+func textPDF(text string) []byte {
+	content := "BT /F1 12 Tf 72 720 Td (" + text + ") Tj ET"
+	return []byte("%PDF-1.4\n" +
+		"1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n" +
+		"2 0 obj\n<< /Type /Pages /Kids [3 0 R] /Count 1 >>\nendobj\n" +
+		"3 0 obj\n<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>\nendobj\n" +
+		"4 0 obj\n<< /Length " + strconv.Itoa(len(content)) + " >>\nstream\n" + content + "\nendstream\nendobj\n" +
+		"5 0 obj\n<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>\nendobj\n" +
+		"trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+}
+
+// withTestFileServer 启用本地提取，并让 https://files.example.com 的下载指向本地HTTPS文件服务
+// 测试传输层直接连接本地服务，不经过内网地址校验；files 的值以 "redirect:" 开头时重定向到该地址
+func withTestFileServer(t *testing.T, p *FileParser, files map[string][]byte) string {
+	t.Helper()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if target, ok := strings.CutPrefix(string(data), "redirect:"); ok {
+			http.Redirect(w, r, target, http.StatusFound)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+
+	transport := server.Client().Transport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+	}
+	p.extractor = docextract.New(&config.ExtractionConfig{MinCharsPerPage: 10})
+	p.httpClient.Transport = transport
+	return "https://files.example.com"
+}
+
+func TestFileParser_ValidateURL(t *testing.T) {
+	p, _ := newTestFileParser(t, "", "files.example.com", "*.cdn.example.com")
+
+	for _, u := range []string{"https://files.example.com/a.pdf", "https://img.cdn.example.com/b.png"} {
+		if err := p.ValidateURL(u); err != nil {
+			t.Errorf("ValidateURL(%q) = %v, want nil", u, err)
+		}
+	}
+	for _, u := range []string{"http://files.example.com/a.pdf", "file:///etc/passwd", "https://evil.com/a.pdf", "https://cdn.example.com.evil.com/a.png", "not a url"} {
+		if err := p.ValidateURL(u); err == nil {
+			t.Errorf("ValidateURL(%q) = nil, want error", u)
+		}
+	}
+
+	// 未配置主机白名单时拒绝所有远程URL，"*" 允许所有主机
+	closed, _ := newTestFileParser(t, "")
+	if err := closed.ValidateURL("https://files.example.com/a.pdf"); err == nil {
+		t.Error("empty allowed_hosts should reject remote URLs")
+	}
+	open, _ := newTestFileParser(t, "", "*")
+	if err := open.ValidateURL("https://files.example.com/a.pdf"); err != nil {
+		t.Errorf("wildcard allowed_hosts should accept any host: %v", err)
+	}
+
+	// 内网地址即使在白名单中也拒绝
+	for _, u := range []string{
+		"https://127.0.0.1/a.pdf", "https://localhost/a.pdf", "https://10.0.0.8/a.pdf", "https://192.168.1.1/a.pdf",
+		"https://169.254.169.254/latest/meta-data", "https://[::1]/a.pdf", "https://[fd00::1]/a.pdf", "https://0.0.0.0/a.pdf",
+	} {
+		if err := open.ValidateURL(u); err == nil {
+			t.Errorf("ValidateURL(%q) = nil, want private address rejected", u)
+		}
+	}
+}
+
+func TestFileParser_DownloadRejectsPrivateAddresses(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	t.Cleanup(server.Close)
+
+	// 默认传输层在建立连接时校验解析后的地址
+	p, _ := newTestFileParser(t, "", "*")
+	if _, err := p.download(context.Background(), server.URL+"/secret"); err == nil || !strings.Contains(err.Error(), "内网地址") {
+		t.Fatalf("download from loopback = %v, want private address error", err)
+	}
+	for address, public := range map[string]bool{"8.8.8.8:443": true, "[2001:4860:4860::8888]:443": true, "127.0.0.1:443": false, "172.16.0.1:80": false, "100.64.0.1:80": false} {
+		if err := checkDialAddress("tcp", address, nil); (err == nil) != public {
+			t.Errorf("checkDialAddress(%q) = %v, want public=%v", address, err, public)
+		}
+	}
+
+	// 重定向到内网地址时拒绝
+	baseURL := withTestFileServer(t, p, map[string][]byte{"/cv.pdf": []byte("redirect:https://169.254.169.254/latest/meta-data")})
+	if _, err := p.download(context.Background(), baseURL+"/cv.pdf"); err == nil || !strings.Contains(err.Error(), "内网地址") {
+		t.Fatalf("redirect to metadata address = %v, want private address error", err)
+	}
+}
+
+func TestParsePDFTool_CachesPerConversation(t *testing.T) {
+	resume := "个人简历\n教育背景：本科\n工作经历：Java开发\f第二页：技能证书"
+	p, calls := newTestFileParser(t, resume, "files.example.com")
+
+	reg := tool.NewRegistry()
+	if err := reg.Register(newParsePDFTool(BuiltinToolDeps{FileParser: p})); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	call := &model.ToolCall{Function: model.FunctionCall{Name: "parsePDF", Arguments: `{"fileUrl":"https://files.example.com/cv.pdf"}`}}
+
+	ctx := withConversationID(context.Background(), "conv-1")
+	var first, second ParsedFile
+	for _, out := range []*ParsedFile{&first, &second} {
+		result, err := reg.Execute(ctx, call)
+		if err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+		if err := json.Unmarshal([]byte(result), out); err != nil {
+			t.Fatalf("unmarshal %q: %v", result, err)
+		}
+	}
+
+	if first.Kind != FileKindPDF || first.Pages != 2 || !first.IsResume || first.Text != resume || first.Cached {
+		t.Fatalf("unexpected first result: %+v", first)
+	}
+	if !second.Cached || atomic.LoadInt32(calls) != 1 {
+		t.Fatalf("expected cached second result with one OCR call, got %+v after %d calls", second, atomic.LoadInt32(calls))
+	}
+
+	// 其他会话不共享缓存
+	if _, err := reg.Execute(withConversationID(context.Background(), "conv-2"), call); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Fatalf("expected a new OCR call for another conversation, got %d calls", got)
+	}
+}
+
+func TestFileParser_ExtractsTextLayerLocally(t *testing.T) {
+	metrics.GetGlobalMetrics().Reset()
+	p, calls := newTestFileParser(t, "OCR识别结果", "files.example.com")
+	baseURL := withTestFileServer(t, p, map[string][]byte{
+		"/cv.pdf":      textPDF("Resume: Zhang Wei, Java Developer, Qingdao"),
+		"/scanned.pdf": textPDF(""),
+	})
+
+	file, err := p.Parse(context.Background(), "", baseURL+"/cv.pdf", "")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if file.Text != "Resume: Zhang Wei, Java Developer, Qingdao" || file.Pages != 1 || file.Kind != FileKindPDF {
+		t.Fatalf("unexpected local result: %+v", file)
+	}
+	if got := atomic.LoadInt32(calls); got != 0 {
+		t.Fatalf("text PDF should not call OCR, got %d calls", got)
+	}
+
+	// 没有文本层和下载失败时回退到OCR
+	for _, ref := range []string{baseURL + "/scanned.pdf", baseURL + "/missing.pdf"} {
+		file, err := p.Parse(context.Background(), "", ref, "")
+		if err != nil || file.Text != "OCR识别结果" {
+			t.Fatalf("parse %s = %+v, %v; want OCR fallback", ref, file, err)
+		}
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Fatalf("expected 2 OCR calls, got %d", got)
+	}
+
+	stats := metrics.GetGlobalMetrics().GetStats()["extraction"].(map[string]interface{})
+	paths := stats["paths"].(map[string]interface{})
+	if paths[docextract.MethodPDFText] == nil || paths[ExtractPathOCR] == nil || stats["ocr_fallbacks"] != uint64(2) {
+		t.Fatalf("unexpected extraction metrics: %+v", stats)
+	}
+}
+
+func TestParsePDFTool_RedactsPersonalInfo(t *testing.T) {
+	p, _ := newTestFileParser(t, "个人简历\n电话：13812345678\n邮箱：zhangwei@example.com\n工作经历：Java开发", "files.example.com")
+	enabled := true
+	redactor := redact.New(&config.RedactionConfig{Enabled: &enabled, Mode: redact.ModeMask})
+
+	reg := tool.NewRegistry()
+	if err := reg.Register(newParsePDFTool(BuiltinToolDeps{FileParser: p, Redactor: redactor})); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	call := &model.ToolCall{Function: model.FunctionCall{Name: "parsePDF", Arguments: `{"fileUrl":"https://files.example.com/cv.pdf"}`}}
+
+	ctx := withRedactionVault(context.Background())
+	out, err := reg.Execute(ctx, call)
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	var file ParsedFile
+	if err := json.Unmarshal([]byte(out), &file); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if strings.Contains(file.Text, "13812345678") || !strings.Contains(file.Text, "[手机号1]") || !strings.Contains(file.Text, "[邮箱1]") {
+		t.Fatalf("text not redacted: %q", file.Text)
+	}
+	if !file.IsResume {
+		t.Fatalf("resume detection should use the original text")
+	}
+	if restored := redactionVaultFrom(ctx).Restore(file.Text); !strings.Contains(restored, "13812345678") {
+		t.Fatalf("placeholders not recorded in request vault: %q", restored)
+	}
+}
+
+// ocrUpload 假OCR服务收到的上传请求
+type ocrUpload struct {
+	path     string
+	filename string
+	calls    int
+}
+
+// withTestFileStore 为对话服务配置临时文件存储和接收上传内容的假OCR服务
+func withTestFileStore(t *testing.T, s *ChatService, data string) (*filestore.Store, *ocrUpload) {
+	t.Helper()
+
+	upload := &ocrUpload{}
+	ocrServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upload.calls++
+		upload.path = r.URL.Path
+		if _, header, err := r.FormFile("file"); err == nil {
+			upload.filename = header.Filename
+		}
+		_ = json.NewEncoder(w).Encode(client.OCRResponse{Code: 200, Data: data})
+	}))
+	t.Cleanup(ocrServer.Close)
+
+	s.cfg.OCR.BaseURL = ocrServer.URL
+	s.cfg.OCR.FileEndpoint = "/ocr/file"
+	store, err := filestore.New(&config.FilesConfig{Dir: t.TempDir(), MaxSize: 1 << 10, AllowedTypes: []string{"application/pdf", "image/png"}, TTL: time.Hour})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	s.fileParser = NewFileParser(s.cfg, client.NewOCRClient(s.cfg), store)
+	return store, upload
+}
+
+func TestProcessMessage_ResolvesUploadedFile(t *testing.T) {
+	s := newTestChatService(t, &fakeLLM{}, nil)
+	store, upload := withTestFileStore(t, s, "个人简历\n教育背景：本科\n工作经历：Java开发")
+	uploaded, err := store.Save("cv.pdf", "", []byte("%PDF-1.4\n"))
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	msg := model.Message{Role: "user", Content: []interface{}{
+		map[string]interface{}{"type": "text", "text": "帮我看看简历"},
+		map[string]interface{}{"type": "file", "file": map[string]interface{}{"file_id": uploaded.ID}},
+	}}
+	processed, resume := s.processMessageWithFileURLs(context.Background(), msg)
+
+	if upload.path != "/ocr/file" || upload.filename != "cv.pdf" {
+		t.Fatalf("OCR upload request = %s %q", upload.path, upload.filename)
+	}
+	content, _ := processed.Content.(string)
+	if !strings.Contains(content, resumeContentMarker) || !strings.Contains(resume, "Java开发") {
+		t.Fatalf("uploaded resume not resolved: %q", content)
+	}
+}
+
+func TestProcessMessage_DecodesDataURL(t *testing.T) {
+	s := newTestChatService(t, &fakeLLM{}, nil)
+	_, upload := withTestFileStore(t, s, "个人简历\n教育背景：本科\n工作经历：Java开发")
+
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 32)...)
+	// 声明的类型与内容不一致时按内容识别
+	dataURL := "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(png)
+	msg := model.Message{Role: "user", Content: []interface{}{
+		map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": dataURL}},
+	}}
+
+	ctx := withConversationID(context.Background(), "conv-inline")
+	_, resume := s.processMessageWithFileURLs(ctx, msg)
+	if upload.filename != "inline.png" || !strings.Contains(resume, "Java开发") {
+		t.Fatalf("data URL not parsed: filename=%q resume=%q", upload.filename, resume)
+	}
+	s.processMessageWithFileURLs(ctx, msg)
+	if upload.calls != 1 {
+		t.Fatalf("data URL should be cached per conversation, OCR calls = %d", upload.calls)
+	}
+
+	// 超过大小上限或类型不允许时返回解析失败提示，不调用OCR
+	for _, bad := range []string{
+		"data:image/png;base64," + base64.StdEncoding.EncodeToString(append(png, make([]byte, 2048)...)),
+		"data:text/plain;base64," + base64.StdEncoding.EncodeToString([]byte("hello")),
+	} {
+		processed, _ := s.processMessageWithFileURLs(ctx, model.Message{Role: "user", Content: []interface{}{
+			map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": bad}},
+		}})
+		if content, _ := processed.Content.(string); !strings.Contains(content, "解析失败") {
+			t.Errorf("expected parse failure, got %q", content)
+		}
+	}
+	if upload.calls != 1 {
+		t.Fatalf("invalid data URLs reached OCR: %d calls", upload.calls)
+	}
+}
+
+func TestProcessMessage_RemoteImageURLWithDefaultHosts(t *testing.T) {
+	s := newTestChatService(t, &fakeLLM{}, nil)
+	ocrServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(client.OCRResponse{Code: 200, Data: "简历 Java开发 三年经验"})
+	}))
+	t.Cleanup(ocrServer.Close)
+	s.cfg.OCR.BaseURL = ocrServer.URL
+	s.fileParser = NewFileParser(s.cfg, client.NewOCRClient(s.cfg), nil)
+
+	// 默认配置未设置 ocr.allowed_hosts：用户随消息发送的远程图片照常解析
+	image := func(u string) model.Message {
+		return model.Message{Role: "user", Content: []interface{}{
+			map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": u}},
+		}}
+	}
+	processed, _ := s.processMessageWithFileURLs(context.Background(), image("https://files.example.com/cv.png"))
+	if content, _ := processed.Content.(string); !strings.Contains(content, "Java开发") {
+		t.Fatalf("remote attachment not parsed: %q", content)
+	}
+
+	// 内网地址的附件仍然拒绝
+	processed, _ = s.processMessageWithFileURLs(context.Background(), image("https://127.0.0.1/cv.png"))
+	if content, _ := processed.Content.(string); !strings.Contains(content, "内网地址") {
+		t.Fatalf("private attachment should be rejected: %q", content)
+	}
+
+	// 模型调用的解析工具未配置白名单时拒绝
+	if _, err := parseFileTool(context.Background(), s.fileParser, s.redactor, "https://files.example.com/cv.png", FileKindImage); err == nil || !strings.Contains(err.Error(), "ocr.allowed_hosts") {
+		t.Fatalf("parseImage without allowed_hosts = %v, want rejection", err)
+	}
+}
+
+func TestProcessMessage_ParsesAttachmentsConcurrentlyWithCache(t *testing.T) {
+	metrics.GetGlobalMetrics().Reset()
+	s := newTestChatService(t, &fakeLLM{}, nil)
+	s.cfg.Attachments.Concurrency = 2
+
+	var calls, active, peak int32
+	ocrServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if n <= old || atomic.CompareAndSwapInt32(&peak, old, n) {
+				break
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+
+		var req struct {
+			URL string `json:"url"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		_ = json.NewEncoder(w).Encode(client.OCRResponse{Code: 200, Data: "识别内容 " + req.URL})
+	}))
+	t.Cleanup(ocrServer.Close)
+	s.cfg.OCR.BaseURL = ocrServer.URL
+	s.fileParser = NewFileParser(s.cfg, client.NewOCRClient(s.cfg), nil)
+
+	var parts []interface{}
+	for _, name := range []string{"a.png", "b.png", "c.png", "d.png"} {
+		parts = append(parts, map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": "https://files.example.com/" + name}})
+	}
+	msg := model.Message{Role: "user", Content: parts}
+
+	// 无会话ID的请求每轮都会重发历史消息
+	for i := 0; i < 2; i++ {
+		processed, _ := s.processMessageWithFileURLs(context.Background(), msg)
+		content, _ := processed.Content.(string)
+		if strings.Index(content, "a.png") > strings.Index(content, "b.png") || strings.Index(content, "c.png") > strings.Index(content, "d.png") {
+			t.Fatalf("attachments out of order: %q", content)
+		}
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 4 {
+		t.Fatalf("expected 4 OCR calls for 4 distinct files, got %d", got)
+	}
+	if got := atomic.LoadInt32(&peak); got != 2 {
+		t.Fatalf("expected peak concurrency 2, got %d", got)
+	}
+	stats := metrics.GetGlobalMetrics().GetStats()["extraction"].(map[string]interface{})
+	if stats["cache_hits"] != uint64(4) || stats["cache_misses"] != uint64(4) {
+		t.Fatalf("unexpected cache metrics: %+v", stats)
+	}
+}
+
+func TestFileParser_CachesByContentHash(t *testing.T) {
+	s := newTestChatService(t, &fakeLLM{}, nil)
+	store, upload := withTestFileStore(t, s, "个人简历\n教育背景：本科\n工作经历：Java开发")
+
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 32)...)
+	first, err := store.Save("a.png", "", png)
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	second, err := store.Save("b.png", "", png)
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	dataURL := "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)
+
+	for _, ref := range []string{first.ID, second.ID, dataURL} {
+		file, err := s.fileParser.Parse(context.Background(), "", ref, "")
+		if err != nil {
+			t.Fatalf("parse %s: %v", describeFileRef(ref), err)
+		}
+		if ref != dataURL && file.FileID != ref {
+			t.Fatalf("cached result should carry the requested file id, got %q want %q", file.FileID, ref)
+		}
+	}
+	if upload.calls != 1 {
+		t.Fatalf("identical content should be parsed once, OCR calls = %d", upload.calls)
+	}
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"qd-sc/internal/grounding"
+	"qd-sc/internal/model"
+	"qd-sc/pkg/metrics"
+)
+
+// groundingState 单次请求的岗位事实核验状态
+type groundingState struct {
+	verifier    *grounding.Verifier
+	verdict     *model.GroundingVerdict // 本次请求的汇总核验结果，未进行核验时为nil
+	regenerated int                     // 已要求模型重新生成的次数
+}
+
+// newGroundingState 创建核验状态，knownJobs 为会话中此前工具返回的岗位
+func newGroundingState(knownJobs []model.FormattedJob) *groundingState {
+	return &groundingState{verifier: grounding.NewVerifier(knownJobs...)}
+}
+
+// groundingActive 是否需要缓冲并核验本轮文本（已有工具返回的岗位记录时才核验）
+func (s *ChatService) groundingActive(g *groundingState) bool {
+	enabled := s.cfg.Grounding.Enabled != nil && *s.cfg.Grounding.Enabled
+	return enabled && g.verifier.HasRecords()
+}
+
+// maxRegenerate regenerate 模式下最多重新生成次数，未配置时为0
+func (s *ChatService) maxRegenerate() int {
+	if s.cfg.Grounding.MaxRegenerate == nil {
+		return 0
+	}
+	return *s.cfg.Grounding.MaxRegenerate
+}
+
+// groundContent 核验文本中的岗位事实，按配置处理不可信内容
+// 返回处理后的文本；regenerate 模式下需要模型重新回答时返回无法核实的事实
+func (s *ChatService) groundContent(g *groundingState, content string, canRegenerate bool) (string, []model.GroundingClaim) {
+	verdict := g.verifier.Verify(content)
+	if verdict.Checked == 0 {
+		return content, nil
+	}
+
+	if !verdict.Grounded {
+		verdict.Action = s.cfg.Grounding.Mode
+		if verdict.Action == grounding.ModeRegenerate && (!canRegenerate || g.regenerated >= s.maxRegenerate()) {
+			// 无法再重新生成时退化为删除
+			verdict.Action = grounding.ModeStrip
+		}
+		log.Printf("岗位事实核验未通过: %d/%d 条无法核实 [%s]，处理方式: %s",
+			len(verdict.Unsupported), verdict.Checked, grounding.ClaimTexts(verdict.Unsupported), verdict.Action)
+	}
+	metrics.GetGlobalMetrics().RecordGrounding(len(verdict.Unsupported), verdict.Action)
+	g.merge(verdict)
+
+	switch verdict.Action {
+	case grounding.ModeRegenerate:
+		g.regenerated++
+		return "", verdict.Unsupported
+	case grounding.ModeFlag:
+		return grounding.Flag(content, verdict.Unsupported), nil
+	case grounding.ModeStrip:
+		return grounding.Strip(content, verdict.Unsupported), nil
+	default:
+		return content, nil
+	}
+}
+
+// regenerateInstruction 要求模型依据工具结果重新回答的提示
+func regenerateInstruction(unsupported []model.GroundingClaim) string {
+	return fmt.Sprintf("你的回复中包含本次查询结果中不存在的岗位信息（%s）。请仅依据工具返回的岗位数据重新回答，不要编造公司、岗位、薪资或链接。",
+		grounding.ClaimTexts(unsupported))
+}
+
+// merge 汇总一次核验结果
+func (g *groundingState) merge(v model.GroundingVerdict) {
+	if g.verdict == nil {
+		g.verdict = &model.GroundingVerdict{Grounded: true}
+	}
+	g.verdict.Checked += v.Checked
+	g.verdict.Unsupported = append(g.verdict.Unsupported, v.Unsupported...)
+	g.verdict.Grounded = g.verdict.Grounded && v.Grounded
+	if v.Action != "" {
+		g.verdict.Action = v.Action
+	}
+}
+
+// exposedVerdict 返回需要随响应输出的核验结果（未开启 grounding.expose_verdict 时为nil）
+func (s *ChatService) exposedVerdict(g *groundingState) *model.GroundingVerdict {
+	if !s.cfg.Grounding.ExposeVerdict {
+		return nil
+	}
+	return g.verdict
+}
+
+// parseJobResponse 解析岗位工具返回的结果
+func parseJobResponse(result string) (*model.JobResponse, error) {
+	var jobResp model.JobResponse
+	if err := json.Unmarshal([]byte(result), &jobResp); err != nil {
+		return nil, err
+	}
+	return &jobResp, nil
+}
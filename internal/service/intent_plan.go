@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"log"
+	"qd-sc/internal/intent"
+	"qd-sc/internal/model"
+	"qd-sc/internal/tool"
+	"strings"
+)
+
+// fileContentMarkers 用户消息中文件解析内容的起始标记
+var fileContentMarkers = []string{"[用户上传的", "[图片解析失败"}
+
+// intentPlan 根据意图确定的工具范围、tool_choice 和幻觉防护策略
+type intentPlan struct {
+	intent     intent.Result
+	tools      []model.Tool
+	toolChoice interface{}
+	guard      bool // 是否缓冲文本并拦截岗位幻觉
+}
+
+// classifyIntent 识别最后一条用户消息的意图
+// 用户在回答上一轮的地点候选选择时直接视为岗位搜索，不再调用分类器
+func (s *ChatService) classifyIntent(ctx context.Context, messages []model.Message) intent.Result {
+	input := intentInput(messages)
+	if place := choosePlace(placeOptionsFrom(ctx), input.Text); place != nil {
+		return intent.Result{Label: intent.LabelJobSearch, Confidence: 1, Source: "place_choice", Reason: "选择地点候选: " + place.Name}
+	}
+	return s.intentClassifier.Classify(ctx, input)
+}
+
+// intentInput 从最后一条用户消息构造意图识别输入，文件解析内容只作为标记，不参与文本匹配
+func intentInput(messages []model.Message) intent.Input {
+	var content string
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			content, _ = messages[i].Content.(string)
+			break
+		}
+	}
+
+	text := content
+	for _, marker := range fileContentMarkers {
+		if idx := strings.Index(text, marker); idx >= 0 {
+			text = text[:idx]
+		}
+	}
+
+	return intent.Input{
+		Text:              strings.TrimSpace(text),
+		HasResume:         strings.Contains(content, resumeContentMarker),
+		HasNonResumeImage: containsNonResumeImageHint(content),
+	}
+}
+
+// planForIntent 根据意图确定本次请求提供给模型的工具和调用策略
+func (s *ChatService) planForIntent(result intent.Result) intentPlan {
+	plan := intentPlan{intent: result, toolChoice: "auto"}
+	isJobTool := func(t tool.Tool) bool { return t.Flags().JobTool }
+	isPolicyTool := func(t tool.Tool) bool { return t.Flags().PolicyTool }
+
+	switch result.Label {
+	case intent.LabelJobSearch:
+		// 岗位场景：auto 模式让模型自行决定是否调用工具，幻觉由拦截机制兜底
+		plan.tools = s.tools.Definitions()
+		plan.guard = true
+	case intent.LabelPolicy:
+		// 政策咨询：不提供岗位工具；意图明确时强制先检索政策
+		plan.tools = s.tools.DefinitionsFor(func(t tool.Tool) bool { return !isJobTool(t) })
+		if threshold := s.cfg.Intent.LLMThreshold; threshold == nil || result.Confidence >= *threshold {
+			if policyTools := s.tools.Filter(isPolicyTool); len(policyTools) > 0 {
+				plan.toolChoice = forcedToolChoice(policyTools[0].Name())
+			}
+		}
+	case intent.LabelResumeReview:
+		// 简历点评：只保留文件解析、位置等辅助工具
+		plan.tools = s.tools.DefinitionsFor(func(t tool.Tool) bool { return !isJobTool(t) && !isPolicyTool(t) })
+	case intent.LabelChitchat:
+		// 闲聊：不提供工具
+		plan.tools = nil
+		plan.toolChoice = nil
+	default:
+		plan.tools = s.tools.Definitions()
+	}
+
+	if len(plan.tools) == 0 {
+		plan.tools = nil
+		plan.toolChoice = nil
+	}
+
+	log.Printf("意图策略: label=%s 工具数=%d guard=%v", result.Label, len(plan.tools), plan.guard)
+	return plan
+}
+
+// forcedToolChoice 强制调用指定工具的 tool_choice
+func forcedToolChoice(name string) interface{} {
+	return map[string]interface{}{
+		"type": "function",
+		"function": map[string]string{
+			"name": name,
+		},
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"testing"
+
+	"qd-sc/internal/intent"
+	"qd-sc/internal/model"
+)
+
+// toolNames 提取工具定义中的名称
+func toolNames(tools []model.Tool) map[string]bool {
+	names := make(map[string]bool, len(tools))
+	for _, t := range tools {
+		names[t.Function.Name] = true
+	}
+	return names
+}
+
+func TestRunAgent_IntentDrivesToolsAndChoice(t *testing.T) {
+	llm := &fakeLLM{turns: [][]model.ChatCompletionChunk{
+		textTurn("您好！"),
+		textTurn("请问您想了解哪方面的政策？"),
+		textTurn("工作日一般九点上班。"),
+	}}
+	s := newTestChatService(t, llm, nil)
+
+	collectEvents(t, s, "你好")
+	collectEvents(t, s, "创业补贴怎么申请")
+	events := collectEvents(t, s, "工作日几点上班")
+
+	// 闲聊：不提供工具
+	if chitchat := llm.requests[0]; len(chitchat.Tools) != 0 || chitchat.ToolChoice != nil {
+		t.Fatalf("chitchat should not offer tools, got %d tools, choice %#v", len(chitchat.Tools), chitchat.ToolChoice)
+	}
+
+	// 政策咨询：不提供岗位工具，强制先检索政策
+	policy := llm.requests[1]
+	names := toolNames(policy.Tools)
+	if names["queryJobsByArea"] || names["queryJobsByLocation"] || !names["queryPolicy"] {
+		t.Fatalf("unexpected policy tools: %v", names)
+	}
+	choice, ok := policy.ToolChoice.(map[string]interface{})
+	if !ok || choice["function"].(map[string]interface{})["name"] != "queryPolicy" {
+		t.Fatalf("expected forced queryPolicy, got %#v", policy.ToolChoice)
+	}
+
+	// “工作日”不是找工作：提供全部工具但不缓冲拦截，文本直接流式输出
+	if got := joinContent(events); got != "工作日一般九点上班。" {
+		t.Fatalf("content = %q", got)
+	}
+	if other := llm.requests[2]; other.ToolChoice != "auto" || len(other.Tools) != len(s.tools.List()) {
+		t.Fatalf("other intent should offer all tools with auto choice, got %d tools, choice %#v", len(other.Tools), other.ToolChoice)
+	}
+}
+
+func TestIntentInput_IgnoresFileContent(t *testing.T) {
+	messages := []model.Message{
+		{Role: "user", Content: "你好"},
+		{Role: "assistant", Content: "您好"},
+		{Role: "user", Content: "帮我看看\n\n" + resumeContentMarker + "求职意向：Java开发岗位"},
+	}
+
+	in := intentInput(messages)
+	if in.Text != "帮我看看" || !in.HasResume || in.HasNonResumeImage {
+		t.Fatalf("unexpected intent input: %+v", in)
+	}
+	svc := newTestChatService(t, &fakeLLM{}, nil)
+	if got := svc.planForIntent(intent.Result{Label: intent.LabelResumeReview}); toolNames(got.tools)["queryJobsByArea"] || got.guard {
+		t.Fatalf("resume review should not offer job tools or guard: %+v", got)
+	}
+}
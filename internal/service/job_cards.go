@@ -0,0 +1,43 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"qd-sc/internal/model"
+)
+
+// RenderJobCards 将岗位查询结果渲染为展示片段
+// 第一个片段为引导语，其后每个片段是一个 ``` job-json 代码块包裹的岗位卡片
+func RenderJobCards(jobResp *model.JobResponse) []string {
+	if jobResp == nil || len(jobResp.JobListings) == 0 {
+		if jobResp != nil && jobResp.Exhausted {
+			return []string{"\n\n没有更多符合条件的岗位了，可以换个关键词或放宽筛选条件再试试。\n"}
+		}
+		return []string{"\n\n未找到符合条件的岗位。\n"}
+	}
+
+	segments := make([]string, 0, len(jobResp.JobListings)+1)
+	segments = append(segments, fmt.Sprintf("\n\n为您找到 %d 个相关岗位：\n\n", len(jobResp.JobListings)))
+
+	for i, job := range jobResp.JobListings {
+		// 如果是最后一个岗位且有data字段，添加data
+		if i == len(jobResp.JobListings)-1 && jobResp.Data != nil {
+			job.Data = jobResp.Data
+		}
+
+		jobJSON, err := json.MarshalIndent(job, "", "  ")
+		if err != nil {
+			log.Printf("格式化岗位失败: %v", err)
+			continue
+		}
+
+		segments = append(segments, fmt.Sprintf("``` job-json\n%s\n```\n\n", string(jobJSON)))
+	}
+
+	if jobResp.Exhausted {
+		segments = append(segments, "以上是全部符合条件的岗位。\n")
+	}
+
+	return segments
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"qd-sc/internal/client"
+	"qd-sc/internal/model"
+	"sort"
+	"sync"
+)
+
+// errNoJobLocation 岗位没有坐标，也没有可解析的工作地址
+var errNoJobLocation = errors.New("岗位缺少工作地点")
+
+// commuteResult 单个岗位的通勤计算结果
+type commuteResult struct {
+	commute *model.JobCommute
+	err     error
+}
+
+// applyCommute 按请求的通勤条件计算从出发地（latitude、longitude）到各岗位的通勤时间
+// rows 与 resp.JobListings 一一对应；设置 MaxCommuteMinutes 时去掉超时和无法计算通勤的岗位，
+// SortByCommute 时按通勤时间从短到长排序（无法计算的排在最后），去掉的岗位数记入 CommuteExcluded
+func (s *JobService) applyCommute(ctx context.Context, req *model.JobQueryRequest, rows []model.JobListing, resp *model.JobResponse) {
+	if req == nil || !req.HasCommute() || s.router == nil || len(resp.JobListings) == 0 {
+		return
+	}
+	origin, err := client.ParseCoordinate(req.Latitude, req.Longitude)
+	if err != nil {
+		log.Printf("通勤出发地无效: %v", err)
+		return
+	}
+	mode := client.RouteMode(req.CommuteMode)
+
+	results := s.commutes(ctx, mode, origin, rows)
+
+	type item struct {
+		job     model.FormattedJob
+		minutes int
+	}
+	items := make([]item, 0, len(resp.JobListings))
+	excluded, failed := 0, 0
+	for i, job := range resp.JobListings {
+		job.Data = nil
+		r := results[i]
+		if r.err != nil {
+			failed++
+			log.Printf("计算通勤失败 [%s %s]: %v", job.CompanyName, job.JobTitle, r.err)
+			if req.MaxCommuteMinutes > 0 {
+				excluded++
+				continue
+			}
+			items = append(items, item{job: job, minutes: -1})
+			continue
+		}
+		if req.MaxCommuteMinutes > 0 && r.commute.Minutes > req.MaxCommuteMinutes {
+			excluded++
+			continue
+		}
+		job.Commute = r.commute
+		items = append(items, item{job: job, minutes: r.commute.Minutes})
+	}
+
+	if req.SortByCommute {
+		sort.SliceStable(items, func(i, j int) bool {
+			a, b := items[i].minutes, items[j].minutes
+			if (a < 0) != (b < 0) {
+				return b < 0
+			}
+			return a < b
+		})
+	}
+
+	resp.JobListings = make([]model.FormattedJob, len(items))
+	for i, it := range items {
+		resp.JobListings[i] = it.job
+	}
+	if n := len(resp.JobListings); n > 0 && resp.Data != nil {
+		resp.JobListings[n-1].Data = resp.Data
+	}
+	resp.CommuteExcluded += excluded
+	log.Printf("通勤计算完成(%s): %d 条岗位，无法计算 %d 条，过滤 %d 条", mode.Label(), len(rows), failed, excluded)
+}
+
+// commutes 并发计算各岗位的通勤时间，结果按岗位顺序返回
+// 并发数和总超时由 commute 配置控制，超时未完成的岗位记为失败
+func (s *JobService) commutes(ctx context.Context, mode client.RouteMode, origin client.Coordinate, rows []model.JobListing) []commuteResult {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Commute.Timeout)
+	defer cancel()
+
+	results := make([]commuteResult, len(rows))
+	sem := make(chan struct{}, s.cfg.Commute.Concurrency)
+	var wg sync.WaitGroup
+	for i := range rows {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i].err = ctx.Err()
+				return
+			}
+
+			dest, err := s.jobDestination(ctx, &rows[i])
+			if err != nil {
+				results[i].err = err
+				return
+			}
+			route, err := s.router.Route(ctx, mode, origin, dest)
+			if err != nil {
+				results[i].err = err
+				return
+			}
+			results[i].commute = &model.JobCommute{
+				Mode:     mode.Label(),
+				Minutes:  int((route.Duration.Seconds() + 59) / 60),
+				Distance: route.Distance,
+			}
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+// jobDestination 岗位工作地点坐标：优先使用列表中的坐标，没有时查询岗位详情，
+// 详情也没有坐标时按工作地址解析
+func (s *JobService) jobDestination(ctx context.Context, job *model.JobListing) (client.Coordinate, error) {
+	if job.Latitude != 0 || job.Longitude != 0 {
+		return client.Coordinate{Latitude: float64(job.Latitude), Longitude: float64(job.Longitude)}, nil
+	}
+	if job.JobID == "" {
+		return client.Coordinate{}, errNoJobLocation
+	}
+
+	detail, err := s.jobClient.GetJobDetail(ctx, job.JobID)
+	if err != nil {
+		return client.Coordinate{}, fmt.Errorf("查询岗位详情失败: %w", err)
+	}
+	if detail.Code != 200 || detail.Data == nil {
+		return client.Coordinate{}, errNoJobLocation
+	}
+	if detail.Data.Latitude != 0 || detail.Data.Longitude != 0 {
+		return client.Coordinate{Latitude: float64(detail.Data.Latitude), Longitude: float64(detail.Data.Longitude)}, nil
+	}
+	if detail.Data.WorkAddress == "" {
+		return client.Coordinate{}, errNoJobLocation
+	}
+	return s.router.Geocode(ctx, detail.Data.WorkAddress)
+}
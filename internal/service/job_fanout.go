@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"qd-sc/internal/model"
+	"qd-sc/internal/tool"
+	"strings"
+	"sync"
+)
+
+// jobQueryResult 单个岗位查询的结果
+type jobQueryResult struct {
+	label string // 查询描述，如 "市南区·前端"
+	resp  *model.JobAPIResponse
+	err   error
+}
+
+// buildJobQueryRequests 构建并校验岗位查询请求
+// jobTitle/jobTitles 与 jobLocationAreaCode/jobLocationAreaCodes 合并去重后按区域×岗位名称展开，
+// 其余筛选条件相同；只有一个组合时返回单个请求
+func (s *JobService) buildJobQueryRequests(params map[string]interface{}) ([]*model.JobQueryRequest, error) {
+	reqs, err := s.expandJobQueryRequests(params)
+	if err != nil {
+		return nil, err
+	}
+	for _, req := range reqs {
+		if err := s.ValidateJobQuery(req); err != nil {
+			return nil, err
+		}
+	}
+	return reqs, nil
+}
+
+// expandJobQueryRequests 按区域×岗位名称展开查询请求
+func (s *JobService) expandJobQueryRequests(params map[string]interface{}) ([]*model.JobQueryRequest, error) {
+	base, err := s.buildJobQueryRequest(params)
+	if err != nil {
+		return nil, err
+	}
+
+	args := tool.Args(params)
+	titles := mergeValues(args.Strings("jobTitle"), args.Strings("jobTitles"))
+	areas, err := s.resolveAreaCodes(mergeValues(args.Strings("jobLocationAreaCode"), args.Strings("jobLocationAreaCodes")))
+	if err != nil {
+		return nil, err
+	}
+	if len(titles) <= 1 && len(areas) <= 1 {
+		if len(areas) == 1 {
+			base.JobLocationAreaCode = areas[0]
+		}
+		if len(titles) == 1 {
+			base.JobTitle = titles[0]
+		}
+		return []*model.JobQueryRequest{base}, nil
+	}
+
+	if len(titles) == 0 {
+		titles = []string{base.JobTitle}
+	}
+	if len(areas) == 0 {
+		areas = []string{base.JobLocationAreaCode}
+	}
+	if n := len(titles) * len(areas); n > maxFanOutQueries {
+		return nil, fmt.Errorf("查询组合过多（%d 个区域 × %d 个岗位名称），最多 %d 个组合，请减少区域或岗位名称", len(areas), len(titles), maxFanOutQueries)
+	}
+
+	reqs := make([]*model.JobQueryRequest, 0, len(titles)*len(areas))
+	for _, area := range areas {
+		for _, title := range titles {
+			req := *base
+			req.JobLocationAreaCode = area
+			req.JobTitle = title
+			reqs = append(reqs, &req)
+		}
+	}
+	return reqs, nil
+}
+
+// resolveAreaCodes 将区域代码或名称解析为区域代码，解析后相同的区域只保留一个
+func (s *JobService) resolveAreaCodes(values []string) ([]string, error) {
+	var problems []string
+	codes := make([]string, 0, len(values))
+	for _, v := range values {
+		code, err := s.resolveAreaCode(v)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s，%v", v, err))
+			continue
+		}
+		codes = append(codes, code)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: jobLocationAreaCode 无效: %s", ErrInvalidJobQuery, strings.Join(problems, "；"))
+	}
+	return mergeValues(codes, nil), nil
+}
+
+// mergeValues 合并两个列表并去重，保持原顺序
+func mergeValues(a, b []string) []string {
+	var merged []string
+	seen := make(map[string]bool, len(a)+len(b))
+	for _, v := range append(append([]string(nil), a...), b...) {
+		if !seen[v] {
+			seen[v] = true
+			merged = append(merged, v)
+		}
+	}
+	return merged
+}
+
+// fanOutJobs 并发执行多个岗位查询，合并去重后返回，每个岗位附上查到它的查询
+// 部分查询失败时返回其余查询的结果，失败原因记录在 queries 中；全部失败时返回错误
+func (s *JobService) fanOutJobs(ctx context.Context, reqs []*model.JobQueryRequest) (string, error) {
+	results := s.fetchAll(ctx, reqs)
+	if err := allFailed(results); err != nil {
+		return "", err
+	}
+
+	// 失败的查询记为尚未展示第一页，继续翻页时从第一页开始
+	queries := make([]model.JobQueryRequest, len(reqs))
+	for i, req := range reqs {
+		queries[i] = *req
+		if results[i].err != nil {
+			queries[i].Current--
+		}
+	}
+	jobQueryStateFrom(ctx).set(queries)
+
+	merger := newJobMerger(nil)
+	merger.merge(results)
+	resp := s.mergedResponse(ctx, reqs[0], merger, true)
+	log.Printf("合并查询岗位: %d 个查询，去重后 %d 条", len(reqs), len(resp.JobListings))
+	return s.formatJobResponse(resp)
+}
+
+// fetchAll 并发执行多个岗位查询，结果按请求顺序返回
+// 岗位API返回非成功代码时记为该查询的错误
+func (s *JobService) fetchAll(ctx context.Context, reqs []*model.JobQueryRequest) []jobQueryResult {
+	results := make([]jobQueryResult, len(reqs))
+	fetch := func(i int) {
+		r := jobQueryResult{label: s.queryLabel(reqs[i])}
+		r.resp, r.err = s.fetchJobs(ctx, reqs[i])
+		if r.err != nil {
+			r.err = fmt.Errorf("查询岗位失败: %w", r.err)
+		} else if err := apiError(r.resp); err != nil {
+			r.err = err
+		}
+		if r.err != nil && len(reqs) > 1 {
+			log.Printf("查询岗位失败 [%s]: %v", r.label, r.err)
+		}
+		results[i] = r
+	}
+
+	if len(reqs) == 1 {
+		fetch(0)
+		return results
+	}
+
+	var wg sync.WaitGroup
+	for i := range reqs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			fetch(i)
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+// allFailed 所有查询都失败时返回第一个错误
+func allFailed(results []jobQueryResult) error {
+	for _, r := range results {
+		if r.err == nil {
+			return nil
+		}
+	}
+	if len(results) == 0 {
+		return nil
+	}
+	return results[0].err
+}
+
+// queryLabel 查询描述：区域名称·岗位名称，缺少的部分省略
+func (s *JobService) queryLabel(req *model.JobQueryRequest) string {
+	var parts []string
+	if req.JobLocationAreaCode != "" {
+		area := req.JobLocationAreaCode
+		if r := s.regions.ByCode(area); r != nil {
+			area = r.Name
+		}
+		parts = append(parts, area)
+	}
+	if title := strings.TrimSpace(req.JobTitle); title != "" {
+		parts = append(parts, title)
+	}
+	if len(parts) == 0 {
+		return "全部岗位"
+	}
+	return strings.Join(parts, "·")
+}
+
+// mergedResponse 将合并后的岗位格式化为查询结果
+// withSources 为true时为每个岗位附上查到它的查询，并返回各查询的概况
+func (s *JobService) mergedResponse(ctx context.Context, req *model.JobQueryRequest, merger *jobMerger, withSources bool) *model.JobResponse {
+	resp := &model.JobResponse{JobListings: []model.FormattedJob{}}
+	if len(merger.rows) > 0 {
+		apiResp := &model.JobAPIResponse{Code: 200, Rows: merger.rows}
+		if !withSources {
+			apiResp.Data = merger.data
+		}
+		resp = s.rankAndFormat(ctx, req, apiResp)
+	}
+	if !withSources {
+		return resp
+	}
+
+	// 重排后按去重键找回岗位的来源
+	for i := range resp.JobListings {
+		job := &resp.JobListings[i]
+		for _, key := range jobKeys(job.AppJobURL, job.CompanyName, job.JobTitle) {
+			if idx, ok := merger.index[key]; ok {
+				job.Sources = merger.sources[idx]
+				break
+			}
+		}
+	}
+	resp.Queries = merger.queries
+	return resp
+}
+
+// jobMerger 合并多个查询的岗位：按查询轮流取岗位，使各查询靠前的结果都能排在前面；
+// 按职位链接和公司+岗位名称去重，重复的岗位只记录来源
+type jobMerger struct {
+	skip    map[string]bool // 跳过的职位链接（已展示过的岗位）
+	index   map[string]int  // 去重键 -> rows 下标
+	rows    []model.JobListing
+	sources [][]string
+	queries []model.JobQuerySource
+	data    interface{} // 最后一个成功查询的额外数据
+}
+
+// newJobMerger 创建岗位合并器，skip 中的职位链接不会出现在结果中
+func newJobMerger(skip map[string]bool) *jobMerger {
+	return &jobMerger{
+		skip:  skip,
+		index: make(map[string]int),
+	}
+}
+
+// merge 合并一批查询结果，并累计各查询的概况（同一查询多次合并时按描述累加）
+func (m *jobMerger) merge(results []jobQueryResult) {
+	for _, r := range results {
+		q := m.query(r.label)
+		if r.err != nil {
+			q.Error = r.err.Error()
+			continue
+		}
+		q.Error = ""
+		q.Count += len(r.resp.Rows)
+		if r.resp.Total > 0 {
+			q.Total = r.resp.Total
+		}
+		m.data = r.resp.Data
+	}
+
+	for i := 0; ; i++ {
+		more := false
+		for _, r := range results {
+			if r.err != nil || i >= len(r.resp.Rows) {
+				continue
+			}
+			more = true
+			m.add(r.resp.Rows[i], r.label)
+		}
+		if !more {
+			return
+		}
+	}
+}
+
+// query 返回描述对应的查询概况，不存在时创建
+func (m *jobMerger) query(label string) *model.JobQuerySource {
+	for i := range m.queries {
+		if m.queries[i].Query == label {
+			return &m.queries[i]
+		}
+	}
+	m.queries = append(m.queries, model.JobQuerySource{Query: label})
+	return &m.queries[len(m.queries)-1]
+}
+
+// add 追加一个岗位，已出现过的岗位只追加来源
+func (m *jobMerger) add(row model.JobListing, label string) {
+	if row.AppJobURL != "" && m.skip[row.AppJobURL] {
+		return
+	}
+
+	keys := jobKeys(row.AppJobURL, row.CompanyName, row.JobTitle)
+	for _, key := range keys {
+		if idx, ok := m.index[key]; ok {
+			if !containsString(m.sources[idx], label) {
+				m.sources[idx] = append(m.sources[idx], label)
+			}
+			// 同一岗位的另一个键也指向它，便于之后按任一键去重
+			for _, k := range keys {
+				if _, exists := m.index[k]; !exists {
+					m.index[k] = idx
+				}
+			}
+			return
+		}
+	}
+
+	idx := len(m.rows)
+	m.rows = append(m.rows, row)
+	m.sources = append(m.sources, []string{label})
+	for _, key := range keys {
+		m.index[key] = idx
+	}
+}
+
+// jobKeys 岗位的去重键：职位链接，以及公司+岗位名称（忽略大小写和首尾空白）
+func jobKeys(appJobURL, companyName, jobTitle string) []string {
+	var keys []string
+	if appJobURL != "" {
+		keys = append(keys, "url:"+appJobURL)
+	}
+	company := strings.ToLower(strings.TrimSpace(companyName))
+	title := strings.ToLower(strings.TrimSpace(jobTitle))
+	if company != "" && title != "" {
+		keys = append(keys, "job:"+company+"|"+title)
+	}
+	return keys
+}
+
+// containsString 列表中是否包含指定值
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"qd-sc/internal/model"
+	"sync"
+)
+
+// jobQueryState 会话最近一次岗位查询条件，岗位工具查询成功后更新，moreJobs 在此基础上翻页
+// 多区域/多岗位名称查询时保存展开后的每个查询
+type jobQueryState struct {
+	mu   sync.Mutex
+	reqs []model.JobQueryRequest
+}
+
+// newJobQueryState 以会话中保存的查询条件初始化，last 为空表示尚未查询过
+func newJobQueryState(last []model.JobQueryRequest) *jobQueryState {
+	state := &jobQueryState{}
+	state.set(last)
+	return state
+}
+
+// get 返回查询条件的副本，未查询过时返回nil
+func (s *jobQueryState) get() []model.JobQueryRequest {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.reqs) == 0 {
+		return nil
+	}
+	return append([]model.JobQueryRequest(nil), s.reqs...)
+}
+
+// set 记录查询条件的副本，空列表忽略
+func (s *jobQueryState) set(reqs []model.JobQueryRequest) {
+	if s == nil || len(reqs) == 0 {
+		return
+	}
+	copied := append([]model.JobQueryRequest(nil), reqs...)
+	s.mu.Lock()
+	s.reqs = copied
+	s.mu.Unlock()
+}
+
+// jobQueryStateKey 上下文中岗位查询状态的键
+type jobQueryStateKey struct{}
+
+// withJobQueryState 在上下文中记录岗位查询状态
+func withJobQueryState(ctx context.Context, state *jobQueryState) context.Context {
+	return context.WithValue(ctx, jobQueryStateKey{}, state)
+}
+
+// jobQueryStateFrom 读取上下文中的岗位查询状态，没有时返回nil（nil状态的读写均为空操作）
+func jobQueryStateFrom(ctx context.Context) *jobQueryState {
+	state, _ := ctx.Value(jobQueryStateKey{}).(*jobQueryState)
+	return state
+}
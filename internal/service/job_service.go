@@ -1,113 +1,528 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"qd-sc/internal/client"
 	"qd-sc/internal/config"
+	"qd-sc/internal/jobcache"
+	"qd-sc/internal/match"
 	"qd-sc/internal/model"
+	"qd-sc/internal/normalize"
+	"qd-sc/internal/region"
+	"qd-sc/pkg/metrics"
 	"qd-sc/pkg/utils"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrJobNotFound 岗位不存在或已下架
+var ErrJobNotFound = errors.New("岗位不存在或已下架")
+
+// ErrInvalidJobQuery 岗位查询参数不合法
+var ErrInvalidJobQuery = errors.New("岗位查询参数不合法")
+
+// 岗位搜索分页和半径限制
+const (
+	defaultSearchPageSize = 10
+	maxSearchPageSize     = 50
+	defaultSearchRadius   = "10"
+	maxSearchRadius       = 50
+
+	// maxMorePages 继续翻页时最多连续请求的页数（整页都已展示过时自动跳到下一页）
+	maxMorePages = 3
+
+	// maxFanOutQueries 一次岗位查询最多展开的区域×岗位名称组合数
+	maxFanOutQueries = 6
 )
 
 // JobService 岗位服务
 type JobService struct {
 	cfg       *config.Config
 	jobClient *client.JobClient
+	scorer    *match.Scorer
+	cache     jobcache.Cache
+	regions   *region.Tree           // 区域名称解析
+	router    client.RoutingProvider // 通勤路线规划，为nil时不支持通勤条件
+	inflight  singleflight.Group     // 合并并发的相同查询
 }
 
 // NewJobService 创建岗位服务
-func NewJobService(cfg *config.Config, jobClient *client.JobClient) *JobService {
+// scorer 为nil时不按求职者画像重排岗位，cache 为nil时每次查询都请求岗位API，
+// router 为nil或关闭 commute.enabled 时不支持按通勤时间筛选
+func NewJobService(cfg *config.Config, jobClient *client.JobClient, scorer *match.Scorer, cache jobcache.Cache, router client.RoutingProvider) *JobService {
+	if cfg.Commute.Enabled != nil && !*cfg.Commute.Enabled {
+		router = nil
+	}
 	return &JobService{
 		cfg:       cfg,
 		jobClient: jobClient,
+		scorer:    scorer,
+		cache:     cache,
+		regions:   region.New(&cfg.City),
+		router:    router,
 	}
 }
 
 // QueryJobsByArea 根据区域代码查询岗位
-func (s *JobService) QueryJobsByArea(params map[string]interface{}) (string, error) {
-	return s.queryJobs(params)
+func (s *JobService) QueryJobsByArea(ctx context.Context, params map[string]interface{}) (string, error) {
+	return s.queryJobs(ctx, params)
 }
 
 // QueryJobsByLocation 根据经纬度查询岗位
-func (s *JobService) QueryJobsByLocation(params map[string]interface{}) (string, error) {
-	return s.queryJobs(params)
+func (s *JobService) QueryJobsByLocation(ctx context.Context, params map[string]interface{}) (string, error) {
+	return s.queryJobs(ctx, params)
 }
 
-// queryJobs 通用岗位查询方法
-func (s *JobService) queryJobs(params map[string]interface{}) (string, error) {
-	req := s.buildJobQueryRequest(params)
+// GetJobDetail 查询单个岗位详情
+// 岗位API返回404或没有数据时返回 ErrJobNotFound
+func (s *JobService) GetJobDetail(ctx context.Context, jobID string) (*model.FormattedJobDetail, error) {
+	jobID = strings.TrimSpace(jobID)
+	if jobID == "" {
+		return nil, fmt.Errorf("岗位ID不能为空")
+	}
 
-	apiResp, err := s.jobClient.QueryJobs(req)
+	apiResp, err := s.jobClient.GetJobDetail(ctx, jobID)
 	if err != nil {
-		return "", fmt.Errorf("查询岗位失败: %w", err)
+		return nil, fmt.Errorf("查询岗位详情失败: %w", err)
 	}
 
+	if apiResp.Code == 404 || (apiResp.Code == 200 && apiResp.Data == nil) {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
 	if apiResp.Code != 200 {
 		errMsg := apiResp.Msg
 		if errMsg == "" {
 			errMsg = fmt.Sprintf("API返回错误代码: %d", apiResp.Code)
 		}
-		return "", fmt.Errorf("岗位API返回错误: %s", errMsg)
+		return nil, fmt.Errorf("岗位API返回错误: %s", errMsg)
+	}
+
+	detail := s.jobClient.FormatJobDetail(apiResp.Data)
+	if detail.JobID == "" {
+		detail.JobID = jobID
+	}
+	return detail, nil
+}
+
+// SearchJobs 按筛选条件直接查询岗位（不经过对话），返回格式化后的岗位和分页信息
+// 参数不合法时返回 ErrInvalidJobQuery
+func (s *JobService) SearchJobs(ctx context.Context, req *model.JobQueryRequest) (*model.JobSearchResponse, error) {
+	if err := s.ValidateJobQuery(req); err != nil {
+		return nil, err
+	}
+
+	apiResp, err := s.fetchJobs(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询岗位失败: %w", err)
+	}
+	if err := apiError(apiResp); err != nil {
+		return nil, err
+	}
+
+	formatted := s.jobClient.FormatJobResponse(apiResp)
+	s.applyCommute(ctx, req, apiResp.Rows, formatted)
+	hasMore := len(apiResp.Rows) == req.PageSize
+	if apiResp.Total > 0 {
+		hasMore = req.Current*req.PageSize < apiResp.Total
+	}
+
+	return &model.JobSearchResponse{
+		JobResponse: *formatted,
+		Pagination: model.Pagination{
+			Current:  req.Current,
+			PageSize: req.PageSize,
+			Count:    len(formatted.JobListings),
+			Total:    apiResp.Total,
+			HasMore:  hasMore,
+		},
+	}, nil
+}
+
+// ValidateJobQuery 校验岗位查询参数并补全默认值（页码、每页数量、有坐标时的搜索半径、通勤方式）
+// 代码类参数必须是学历、经验、企业类型和区域代码表中的值；通勤条件需要同时提供出发地坐标
+func (s *JobService) ValidateJobQuery(req *model.JobQueryRequest) error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrInvalidJobQuery, fmt.Sprintf(format, args...))
+	}
+
+	if req.Current == 0 {
+		req.Current = 1
+	}
+	if req.Current < 0 {
+		return invalid("current 必须大于0")
+	}
+	if req.PageSize == 0 {
+		req.PageSize = defaultSearchPageSize
+	}
+	if req.PageSize < 0 || req.PageSize > maxSearchPageSize {
+		return invalid("pageSize 须在1-%d之间", maxSearchPageSize)
+	}
+
+	req.JobTitle = strings.TrimSpace(req.JobTitle)
+
+	if req.Order != "" && req.Order != "0" && req.Order != "1" && req.Order != "2" {
+		return invalid("order 无效: %s（可选值: 0 推荐, 1 最热, 2 最新）", req.Order)
+	}
+	if req.Education != "" && model.EducationMap[req.Education] == "" {
+		return invalid("education 无效: %s（可选值: %s）", req.Education, codeChoices(model.EducationMap))
+	}
+	if req.Experience != "" && model.ExperienceMap[req.Experience] == "" {
+		return invalid("experience 无效: %s（可选值: %s）", req.Experience, codeChoices(model.ExperienceMap))
+	}
+	if req.CompanyNature != "" && model.CompanyNatureMap[req.CompanyNature] == "" {
+		return invalid("companyNature 无效: %s（可选值: %s）", req.CompanyNature, codeChoices(model.CompanyNatureMap))
+	}
+	if req.JobLocationAreaCode != "" {
+		code, err := s.resolveAreaCode(req.JobLocationAreaCode)
+		if err != nil {
+			return invalid("jobLocationAreaCode 无效: %s，%v", req.JobLocationAreaCode, err)
+		}
+		req.JobLocationAreaCode = code
+	}
+
+	minSalary, err := parseSalary("minSalary", req.MinSalary)
+	if err != nil {
+		return invalid("%v", err)
+	}
+	maxSalary, err := parseSalary("maxSalary", req.MaxSalary)
+	if err != nil {
+		return invalid("%v", err)
+	}
+	if minSalary > 0 && maxSalary > 0 && minSalary > maxSalary {
+		return invalid("minSalary 不能大于 maxSalary")
+	}
+
+	if (req.Latitude == "") != (req.Longitude == "") {
+		return invalid("latitude 和 longitude 必须同时提供")
+	}
+	if req.HasCommute() {
+		if s.router == nil {
+			return invalid("当前未启用通勤时间计算，请去掉 commuteMode、maxCommuteMinutes、sortByCommute")
+		}
+		if req.Latitude == "" {
+			return invalid("按通勤时间筛选需要提供出发地的 latitude 和 longitude")
+		}
+		if req.MaxCommuteMinutes < 0 {
+			return invalid("maxCommuteMinutes 必须大于0")
+		}
+		mode, err := client.ParseRouteMode(req.CommuteMode, client.RouteMode(s.cfg.Commute.DefaultMode))
+		if err != nil {
+			return invalid("commuteMode %v", err)
+		}
+		req.CommuteMode = string(mode)
+	}
+	if req.Latitude == "" {
+		if req.Radius != "" {
+			return invalid("radius 需要与 latitude、longitude 一起使用")
+		}
+		return nil
+	}
+	if v, err := strconv.ParseFloat(req.Latitude, 64); err != nil || v < -90 || v > 90 {
+		return invalid("latitude 无效: %s", req.Latitude)
+	}
+	if v, err := strconv.ParseFloat(req.Longitude, 64); err != nil || v < -180 || v > 180 {
+		return invalid("longitude 无效: %s", req.Longitude)
+	}
+	if req.Radius == "" {
+		req.Radius = defaultSearchRadius
+	}
+	if v, err := strconv.ParseFloat(req.Radius, 64); err != nil || v <= 0 || v > maxSearchRadius {
+		return invalid("radius 须在0-%d千米之间", maxSearchRadius)
+	}
+	return nil
+}
+
+// resolveAreaCode 将区域代码或名称（简称、别名、拼音、街道）解析为区域代码
+func (s *JobService) resolveAreaCode(value string) (string, error) {
+	if s.regions.ByCode(value) != nil {
+		return value, nil
+	}
+	r, err := s.regions.Resolve(value)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("区域 %q 解析为 %s(%s)", value, r.DisplayName(), r.Code)
+	return r.Code, nil
+}
+
+// parseSalary 解析薪资参数（元/月），空字符串返回0
+func parseSalary(name, value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("%s 必须是非负整数（元/月）: %s", name, value)
+	}
+	return v, nil
+}
+
+// codeChoices 按代码数值顺序列出代码表，例如 "3 大专, 4 本科"
+func codeChoices(codes map[string]string) string {
+	keys := make([]string, 0, len(codes))
+	for k := range codes {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, _ := strconv.Atoi(keys[i])
+		b, _ := strconv.Atoi(keys[j])
+		return a < b
+	})
+
+	choices := make([]string, len(keys))
+	for i, k := range keys {
+		choices[i] = k + " " + codes[k]
+	}
+	return strings.Join(choices, ", ")
+}
+
+// queryJobs 通用岗位查询方法
+// 上下文中有求职者画像时按匹配度重排岗位，并附上匹配度和推荐理由
+// 提供多个区域代码或岗位名称时展开为多个查询并发执行，合并去重后返回
+// 查询成功后记录查询条件，供 moreJobs 继续翻页
+func (s *JobService) queryJobs(ctx context.Context, params map[string]interface{}) (string, error) {
+	reqs, err := s.buildJobQueryRequests(params)
+	if err != nil {
+		return "", err
+	}
+	if len(reqs) > 1 {
+		return s.fanOutJobs(ctx, reqs)
 	}
+	req := reqs[0]
+
+	apiResp, err := s.fetchJobs(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("查询岗位失败: %w", err)
+	}
+	if err := apiError(apiResp); err != nil {
+		return "", err
+	}
+	jobQueryStateFrom(ctx).set([]model.JobQueryRequest{*req})
 
 	if len(apiResp.Rows) == 0 {
 		return s.formatEmptyResult(), nil
 	}
+	return s.formatJobResponse(s.rankAndFormat(ctx, req, apiResp))
+}
 
-	// 格式化响应
-	formattedResp := s.jobClient.FormatJobResponse(apiResp)
-	return s.formatJobResponse(formattedResp)
+// MoreJobs 在会话最近一次岗位查询的基础上继续翻页，跳过已展示过的岗位（按职位链接）
+// 一整页都已展示过时自动再翻一页，最多 maxMorePages 页；所有查询都没有更多结果时设置 Exhausted
+func (s *JobService) MoreJobs(ctx context.Context) (string, error) {
+	state := jobQueryStateFrom(ctx)
+	last := state.get()
+	if len(last) == 0 {
+		return "", fmt.Errorf("还没有查询过岗位，请先按条件查询岗位")
+	}
+
+	merger := newJobMerger(shownJobsFrom(ctx).urls())
+	active := make([]*model.JobQueryRequest, len(last))
+	for i := range last {
+		active[i] = &last[i]
+	}
+	failed := false
+	for page := 0; page < maxMorePages && len(merger.rows) == 0 && len(active) > 0; page++ {
+		for _, req := range active {
+			req.Current++
+		}
+		results := s.fetchAll(ctx, active)
+		if err := allFailed(results); err != nil {
+			return "", err
+		}
+		merger.merge(results)
+
+		// 查询失败的保留原页码，下次继续；没有更多结果的不再翻页
+		var next []*model.JobQueryRequest
+		failed = false
+		for i, r := range results {
+			if r.err != nil {
+				active[i].Current--
+				failed = true
+				continue
+			}
+			if !pageExhausted(active[i], r.resp) {
+				next = append(next, active[i])
+			}
+		}
+		active = next
+	}
+	exhausted := len(active) == 0 && !failed
+	state.set(last)
+
+	resp := s.mergedResponse(ctx, &last[0], merger, len(last) > 1)
+	resp.Exhausted = exhausted
+	log.Printf("继续翻页: %d 个查询，新岗位 %d 条，已无更多: %v", len(last), len(resp.JobListings), exhausted)
+	return s.formatJobResponse(resp)
 }
 
-// buildJobQueryRequest 构建岗位查询请求
-func (s *JobService) buildJobQueryRequest(params map[string]interface{}) *model.JobQueryRequest {
-	req := &model.JobQueryRequest{
-		Current:  1,
-		PageSize: 10,
+// pageExhausted 本页之后是否已没有更多结果：不足一页，或已达到岗位总数
+func pageExhausted(req *model.JobQueryRequest, resp *model.JobAPIResponse) bool {
+	return len(resp.Rows) < req.PageSize || (resp.Total > 0 && req.Current*req.PageSize >= resp.Total)
+}
+
+// rankAndFormat 有求职者画像时按匹配度重排岗位，再格式化为展示结构
+// req 带通勤条件时再计算通勤时间，按通勤筛选和排序
+func (s *JobService) rankAndFormat(ctx context.Context, req *model.JobQueryRequest, apiResp *model.JobAPIResponse) *model.JobResponse {
+	ranked := s.scorer.Rank(ctx, resumeProfileFrom(ctx), apiResp.Rows)
+	if ranked != nil {
+		rows := make([]model.JobListing, len(ranked))
+		for i, r := range ranked {
+			rows[i] = apiResp.Rows[r.Index]
+		}
+		apiResp.Rows = rows
 	}
 
-	// 解析参数
-	if v, ok := params["current"].(float64); ok {
-		req.Current = int(v)
+	// 格式化响应
+	formattedResp := s.jobClient.FormatJobResponse(apiResp)
+	for i, r := range ranked {
+		formattedResp.JobListings[i].MatchScore = r.Score
+		formattedResp.JobListings[i].MatchReasons = r.Reasons
 	}
-	if v, ok := params["pageSize"].(float64); ok {
-		req.PageSize = int(v)
+	if len(ranked) > 0 {
+		log.Printf("岗位已按简历匹配度重排: %d 条，最高匹配度 %d", len(ranked), ranked[0].Score)
 	}
-	if v, ok := params["jobTitle"].(string); ok {
-		req.JobTitle = v
+	s.applyCommute(ctx, req, apiResp.Rows, formattedResp)
+	return formattedResp
+}
+
+// apiError 岗位API返回非成功代码时转换为错误
+func apiError(apiResp *model.JobAPIResponse) error {
+	if apiResp.Code == 200 {
+		return nil
 	}
-	if v, ok := params["latitude"].(string); ok {
-		req.Latitude = v
+	errMsg := apiResp.Msg
+	if errMsg == "" {
+		errMsg = fmt.Sprintf("API返回错误代码: %d", apiResp.Code)
 	}
-	if v, ok := params["longitude"].(string); ok {
-		req.Longitude = v
+	return fmt.Errorf("岗位API返回错误: %s", errMsg)
+}
+
+// fetchJobs 查询岗位API：相同条件（规范化后）的查询优先使用缓存，并发的相同查询只请求一次
+// 只缓存成功的响应；返回值是独立的副本，调用方可以重排岗位
+func (s *JobService) fetchJobs(ctx context.Context, req *model.JobQueryRequest) (*model.JobAPIResponse, error) {
+	if s.cache == nil {
+		return s.jobClient.QueryJobs(req)
 	}
-	if v, ok := params["radius"].(string); ok {
-		req.Radius = v
+
+	m := metrics.GetGlobalMetrics()
+	key := jobcache.Key(req)
+	if resp, ok, err := s.cache.Get(ctx, key); err != nil {
+		log.Printf("读取岗位缓存失败: %v", err)
+	} else if ok {
+		m.RecordJobCache(true)
+		return resp, nil
 	}
-	if v, ok := params["order"].(string); ok {
-		req.Order = v
+	m.RecordJobCache(false)
+
+	v, err, shared := s.inflight.Do(key, func() (interface{}, error) {
+		resp, err := s.jobClient.QueryJobs(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Code == 200 {
+			if err := s.cache.Set(ctx, key, resp); err != nil {
+				log.Printf("写入岗位缓存失败: %v", err)
+			}
+		}
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	if v, ok := params["minSalary"].(string); ok {
-		req.MinSalary = v
+	if shared {
+		m.IncJobCacheShared()
 	}
-	if v, ok := params["maxSalary"].(string); ok {
-		req.MaxSalary = v
+
+	// 合并的调用方共享同一个结果，各自复制一份
+	resp := *v.(*model.JobAPIResponse)
+	resp.Rows = append([]model.JobListing(nil), resp.Rows...)
+	return &resp, nil
+}
+
+// PurgeCache 清空岗位查询缓存，返回清除的条目数
+func (s *JobService) PurgeCache(ctx context.Context) (int, error) {
+	if s.cache == nil {
+		return 0, nil
 	}
-	if v, ok := params["experience"].(string); ok {
-		req.Experience = v
+	n, err := s.cache.Purge(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("清空岗位缓存失败: %w", err)
 	}
-	if v, ok := params["education"].(string); ok {
-		req.Education = v
+	log.Printf("岗位缓存已清空: %d 条", n)
+	return n, nil
+}
+
+// buildJobQueryRequest 构建岗位查询请求
+// 参数先经过规范化：数字和字符串互相转换，薪资表达式（如 8k、1万5、年薪20万）换算为元/月，
+// 经验、学历说法（如 3年经验、应届、本科以上）转换为代码；无法识别的参数汇总为 ErrInvalidJobQuery 返回给模型
+func (s *JobService) buildJobQueryRequest(params map[string]interface{}) (*model.JobQueryRequest, error) {
+	req := &model.JobQueryRequest{}
+	var problems []string
+	report := func(name string, err error) {
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s %v", name, err))
+		}
 	}
-	if v, ok := params["companyNature"].(string); ok {
-		req.CompanyNature = v
+
+	var err error
+	req.Current, err = normalize.Int(params["current"], 1)
+	report("current", err)
+	req.PageSize, err = normalize.Int(params["pageSize"], 10)
+	report("pageSize", err)
+
+	for _, field := range []struct {
+		name string
+		dst  *string
+	}{
+		{"jobTitle", &req.JobTitle},
+		{"latitude", &req.Latitude},
+		{"longitude", &req.Longitude},
+		{"radius", &req.Radius},
+		{"order", &req.Order},
+		{"companyNature", &req.CompanyNature},
+		{"jobLocationAreaCode", &req.JobLocationAreaCode},
+	} {
+		*field.dst, err = normalize.String(params[field.name])
+		report(field.name, err)
 	}
-	if v, ok := params["jobLocationAreaCode"].(string); ok {
-		req.JobLocationAreaCode = v
+
+	req.Experience, err = normalize.Experience(params["experience"])
+	report("experience", err)
+	req.Education, err = normalize.Education(params["education"])
+	report("education", err)
+
+	req.CommuteMode, err = normalize.String(params["commuteMode"])
+	report("commuteMode", err)
+	req.MaxCommuteMinutes, err = normalize.Minutes(params["maxCommuteMinutes"])
+	report("maxCommuteMinutes", err)
+	req.SortByCommute, err = normalize.Bool(params["sortByCommute"])
+	report("sortByCommute", err)
+
+	for _, field := range []struct {
+		name  string
+		dst   *string
+		upper bool
+	}{
+		{"minSalary", &req.MinSalary, false},
+		{"maxSalary", &req.MaxSalary, true},
+	} {
+		salary, err := normalize.MonthlySalary(params[field.name], field.upper)
+		report(field.name, err)
+		if salary > 0 {
+			*field.dst = strconv.Itoa(salary)
+		}
 	}
 
-	return req
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidJobQuery, strings.Join(problems, "；"))
+	}
+	return req, nil
 }
 
 // formatJobResponse 格式化岗位响应为JSON字符串
@@ -0,0 +1,445 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"qd-sc/internal/client"
+	"qd-sc/internal/config"
+	"qd-sc/internal/jobcache"
+	"qd-sc/internal/model"
+	"qd-sc/internal/tool"
+)
+
+func TestValidateJobQuery(t *testing.T) {
+	s := newTestChatService(t, &fakeLLM{}, nil)
+
+	req := &model.JobQueryRequest{JobTitle: " Java ", Latitude: "36.06", Longitude: "120.38"}
+	if err := s.jobService.ValidateJobQuery(req); err != nil {
+		t.Fatalf("valid query rejected: %v", err)
+	}
+	if req.Current != 1 || req.PageSize != 10 || req.Radius != "10" || req.JobTitle != "Java" {
+		t.Fatalf("defaults not applied: %+v", req)
+	}
+
+	cases := []struct {
+		req  model.JobQueryRequest
+		want string
+	}{
+		{model.JobQueryRequest{Education: "11"}, "education 无效: 11（可选值: -1 学历不限, 0 初中及以下"},
+		{model.JobQueryRequest{Experience: "8"}, "experience 无效: 8"},
+		{model.JobQueryRequest{CompanyNature: "6"}, "companyNature 无效: 6"},
+		{model.JobQueryRequest{JobLocationAreaCode: "42"}, "jobLocationAreaCode 无效: 42"},
+		{model.JobQueryRequest{Order: "3"}, "order 无效"},
+		{model.JobQueryRequest{PageSize: 100}, "pageSize 须在1-50之间"},
+		{model.JobQueryRequest{MinSalary: "9000", MaxSalary: "5000"}, "minSalary 不能大于 maxSalary"},
+		{model.JobQueryRequest{MinSalary: "八千"}, "minSalary 必须是非负整数"},
+		{model.JobQueryRequest{Latitude: "36.06"}, "必须同时提供"},
+		{model.JobQueryRequest{Radius: "5"}, "radius 需要与 latitude、longitude 一起使用"},
+		{model.JobQueryRequest{Latitude: "36.06", Longitude: "120.38", Radius: "80"}, "radius 须在0-50千米之间"},
+	}
+	for _, tc := range cases {
+		req := tc.req
+		err := s.jobService.ValidateJobQuery(&req)
+		if !errors.Is(err, ErrInvalidJobQuery) || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%+v: got %v, want %q", tc.req, err, tc.want)
+		}
+	}
+}
+
+func TestSearchJobs_Pagination(t *testing.T) {
+	rows := []model.JobListing{
+		{JobID: "1", JobTitle: "会计", CompanyName: "海洋财务", Education: "3", JobLocationAreaCode: 3},
+		{JobID: "2", JobTitle: "出纳", CompanyName: "城阳商贸", MinSalary: 4000, MaxSalary: 5000},
+	}
+	s := newTestChatService(t, &fakeLLM{}, rows)
+
+	result, err := s.jobService.SearchJobs(context.Background(), &model.JobQueryRequest{JobTitle: "会计", PageSize: 2, Education: "3"})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(result.JobListings) != 2 || result.JobListings[0].Location != "崂山区" || result.JobListings[0].Education != "大专" {
+		t.Fatalf("unexpected listings: %+v", result.JobListings)
+	}
+	want := model.Pagination{Current: 1, PageSize: 2, Count: 2, HasMore: true}
+	if result.Pagination != want {
+		t.Fatalf("pagination = %+v, want %+v", result.Pagination, want)
+	}
+
+	result, _ = s.jobService.SearchJobs(context.Background(), &model.JobQueryRequest{PageSize: 10})
+	if result.Pagination.HasMore {
+		t.Fatalf("short page should not have more: %+v", result.Pagination)
+	}
+
+	if _, err := s.jobService.SearchJobs(context.Background(), &model.JobQueryRequest{Education: "abc"}); !errors.Is(err, ErrInvalidJobQuery) {
+		t.Fatalf("invalid education = %v", err)
+	}
+}
+
+func TestQueryJobs_CachedAndDeduplicated(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	jobServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		_ = json.NewEncoder(w).Encode(model.JobAPIResponse{Code: 200, Rows: []model.JobListing{
+			{JobTitle: "Java开发工程师", CompanyName: "青岛软件园"},
+		}})
+	}))
+	t.Cleanup(jobServer.Close)
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf("job_api:\n  base_url: %q\n", jobServer.URL)), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	s := NewJobService(cfg, client.NewJobClient(cfg), nil, jobcache.NewMemoryCache(time.Minute, 10), nil)
+
+	// 并发的相同查询只请求一次岗位API
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.QueryJobsByArea(context.Background(), tool.Args{"jobTitle": "Java", "jobLocationAreaCode": "0"}); err != nil {
+				t.Errorf("query: %v", err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("concurrent identical queries hit upstream %d times", n)
+	}
+
+	// 规范化后相同的查询命中缓存
+	if _, err := s.QueryJobsByArea(context.Background(), tool.Args{"jobTitle": " Java ", "jobLocationAreaCode": "0", "current": float64(1)}); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("cached query hit upstream, calls = %d", n)
+	}
+
+	if n, err := s.PurgeCache(context.Background()); err != nil || n != 1 {
+		t.Fatalf("purge = %d, %v", n, err)
+	}
+	if _, err := s.QueryJobsByArea(context.Background(), tool.Args{"jobTitle": "Java", "jobLocationAreaCode": "0"}); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Fatalf("query after purge should hit upstream, calls = %d", n)
+	}
+}
+
+func TestMoreJobs_SkipsShownAndReportsExhausted(t *testing.T) {
+	pages := map[string][]model.JobListing{
+		"1": {{JobTitle: "A", AppJobURL: "u1"}, {JobTitle: "B", AppJobURL: "u2"}},
+		"2": {{JobTitle: "B", AppJobURL: "u2"}, {JobTitle: "C", AppJobURL: "u3"}},
+		"3": {{JobTitle: "D", AppJobURL: "u4"}},
+	}
+	jobServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(model.JobAPIResponse{Code: 200, Rows: pages[r.URL.Query().Get("current")], Total: 5})
+	}))
+	t.Cleanup(jobServer.Close)
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf("job_api:\n  base_url: %q\n", jobServer.URL)), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	s := NewJobService(cfg, client.NewJobClient(cfg), nil, nil, nil)
+
+	state := newJobQueryState(nil)
+	shown := newShownJobs(nil)
+	ctx := withShownJobs(withJobQueryState(context.Background(), state), shown)
+
+	more := func() model.JobResponse {
+		t.Helper()
+		result, err := s.MoreJobs(ctx)
+		if err != nil {
+			t.Fatalf("more jobs: %v", err)
+		}
+		var resp model.JobResponse
+		if err := json.Unmarshal([]byte(result), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		shown.add(resp.JobListings)
+		return resp
+	}
+
+	result, err := s.QueryJobsByArea(ctx, tool.Args{"jobTitle": "Java", "pageSize": float64(2)})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	var first model.JobResponse
+	if err := json.Unmarshal([]byte(result), &first); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	shown.add(first.JobListings)
+
+	// 第2页中已展示的 B 被跳过
+	if resp := more(); len(resp.JobListings) != 1 || resp.JobListings[0].JobTitle != "C" || resp.Exhausted {
+		t.Fatalf("unexpected page 2: %+v", resp)
+	}
+	// 第3页不足一页，返回剩余岗位并标记没有更多
+	if resp := more(); len(resp.JobListings) != 1 || resp.JobListings[0].JobTitle != "D" || !resp.Exhausted {
+		t.Fatalf("unexpected page 3: %+v", resp)
+	}
+	if last := state.get(); len(last) != 1 || last[0].Current != 3 || last[0].JobTitle != "Java" {
+		t.Fatalf("query state not advanced: %+v", last)
+	}
+}
+
+func TestQueryJobsByArea_FansOutAndMerges(t *testing.T) {
+	rows := map[string][]model.JobListing{
+		"0|前端": {
+			{JobTitle: "前端开发", CompanyName: "甲公司", AppJobURL: "a"},
+			{JobTitle: "前端实习生", CompanyName: "乙公司", AppJobURL: "b"},
+		},
+		"0|Web开发": {
+			{JobTitle: "前端开发", CompanyName: "甲公司", AppJobURL: "a"},
+			{JobTitle: "前端工程师", CompanyName: "丙公司", AppJobURL: "c1"},
+		},
+		"3|前端": {
+			{JobTitle: "前端工程师 ", CompanyName: "丙公司", AppJobURL: "c2"},
+			{JobTitle: "Web前端", CompanyName: "丁公司", AppJobURL: "d"},
+		},
+	}
+	jobServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		key := q.Get("jobLocationAreaCode") + "|" + q.Get("jobTitle")
+		if key == "3|Web开发" {
+			_ = json.NewEncoder(w).Encode(model.JobAPIResponse{Code: 500, Msg: "服务繁忙"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(model.JobAPIResponse{Code: 200, Rows: rows[key]})
+	}))
+	t.Cleanup(jobServer.Close)
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf("job_api:\n  base_url: %q\n", jobServer.URL)), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	s := NewJobService(cfg, client.NewJobClient(cfg), nil, nil, nil)
+	state := newJobQueryState(nil)
+	ctx := withJobQueryState(context.Background(), state)
+
+	result, err := s.QueryJobsByArea(ctx, tool.Args{
+		"jobTitles":            []interface{}{"前端", "Web开发"},
+		"jobLocationAreaCodes": []interface{}{"0", "3"},
+	})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	var resp model.JobResponse
+	if err := json.Unmarshal([]byte(result), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	// 按查询轮流合并；链接相同或公司+岗位名称相同的岗位只保留一条
+	var urls []string
+	for _, job := range resp.JobListings {
+		urls = append(urls, job.AppJobURL)
+	}
+	if strings.Join(urls, ",") != "a,c2,b,d" {
+		t.Fatalf("merged order = %v", urls)
+	}
+	if got := strings.Join(resp.JobListings[0].Sources, ","); got != "市南区·前端,市南区·Web开发" {
+		t.Fatalf("sources of a = %q", got)
+	}
+	if got := strings.Join(resp.JobListings[1].Sources, ","); got != "崂山区·前端,市南区·Web开发" {
+		t.Fatalf("sources of c = %q", got)
+	}
+	if len(resp.Queries) != 4 || resp.Queries[0].Count != 2 || !strings.Contains(resp.Queries[3].Error, "服务繁忙") {
+		t.Fatalf("unexpected queries: %+v", resp.Queries)
+	}
+
+	// 失败的查询记为尚未查询第一页
+	last := state.get()
+	if len(last) != 4 || last[0].Current != 1 || last[3].Current != 0 || last[3].JobTitle != "Web开发" {
+		t.Fatalf("unexpected query state: %+v", last)
+	}
+
+	if _, err := s.QueryJobsByArea(ctx, tool.Args{
+		"jobTitle":             "前端",
+		"jobLocationAreaCodes": []interface{}{"0", "1", "2", "3", "4", "5", "6"},
+	}); err == nil || !strings.Contains(err.Error(), "查询组合过多") {
+		t.Fatalf("expected too many combinations error, got %v", err)
+	}
+}
+
+func TestQueryJobsByArea_NormalizesArguments(t *testing.T) {
+	var got url.Values
+	jobServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+		_ = json.NewEncoder(w).Encode(model.JobAPIResponse{Code: 200})
+	}))
+	t.Cleanup(jobServer.Close)
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf("job_api:\n  base_url: %q\n", jobServer.URL)), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	s := NewJobService(cfg, client.NewJobClient(cfg), nil, nil, nil)
+
+	if _, err := s.QueryJobsByArea(context.Background(), tool.Args{
+		"jobTitle":            "Java",
+		"current":             "2",
+		"pageSize":            float64(5),
+		"minSalary":           "8k",
+		"maxSalary":           "1万5",
+		"experience":          "3年经验",
+		"education":           "本科以上",
+		"jobLocationAreaCode": float64(3),
+	}); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	want := map[string]string{
+		"current": "2", "pageSize": "5", "minSalary": "8000", "maxSalary": "15000",
+		"experience": "5", "education": "4", "jobLocationAreaCode": "3",
+	}
+	for k, v := range want {
+		if got.Get(k) != v {
+			t.Errorf("%s = %q, want %q", k, got.Get(k), v)
+		}
+	}
+
+	// 无法识别的参数返回给模型，而不是被忽略
+	_, err = s.QueryJobsByArea(context.Background(), tool.Args{"jobTitle": "Java", "minSalary": "很多", "experience": "资深"})
+	if !errors.Is(err, ErrInvalidJobQuery) || !strings.Contains(err.Error(), "minSalary") || !strings.Contains(err.Error(), "experience") {
+		t.Fatalf("expected invalid argument feedback, got %v", err)
+	}
+}
+
+// fakeRouter 按终点纬度返回固定通勤时间的路线规划，地址按表解析
+type fakeRouter struct {
+	minutes   map[float64]int
+	addresses map[string]client.Coordinate
+	mu        sync.Mutex
+	modes     []client.RouteMode
+}
+
+func (f *fakeRouter) Route(ctx context.Context, mode client.RouteMode, origin, destination client.Coordinate) (*client.Route, error) {
+	f.mu.Lock()
+	f.modes = append(f.modes, mode)
+	f.mu.Unlock()
+	m, ok := f.minutes[destination.Latitude]
+	if !ok {
+		return nil, client.ErrNoRoute
+	}
+	return &client.Route{Duration: time.Duration(m) * time.Minute, Distance: m * 500}, nil
+}
+
+func (f *fakeRouter) Geocode(ctx context.Context, address string) (client.Coordinate, error) {
+	if c, ok := f.addresses[address]; ok {
+		return c, nil
+	}
+	return client.Coordinate{}, client.ErrPlaceNotFound
+}
+
+func TestQueryJobsByLocation_Commute(t *testing.T) {
+	jobServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/j3") {
+			_ = json.NewEncoder(w).Encode(model.JobDetailAPIResponse{Code: 200, Data: &model.JobDetail{WorkAddress: "崂山区科苑纬一路1号"}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(model.JobAPIResponse{Code: 200, Rows: []model.JobListing{
+			{JobID: "j1", JobTitle: "远", AppJobURL: "u1", Latitude: 36.1, Longitude: 120.4},
+			{JobID: "j2", JobTitle: "近", AppJobURL: "u2", Latitude: 36.2, Longitude: 120.4},
+			{JobID: "j3", JobTitle: "按地址", AppJobURL: "u3"},
+			{JobID: "j4", JobTitle: "无路线", AppJobURL: "u4", Latitude: 36.4, Longitude: 120.4},
+		}})
+	}))
+	t.Cleanup(jobServer.Close)
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf("job_api:\n  base_url: %q\n", jobServer.URL+"/list")), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	router := &fakeRouter{
+		minutes:   map[float64]int{36.1: 70, 36.2: 25, 36.3: 40},
+		addresses: map[string]client.Coordinate{"崂山区科苑纬一路1号": {Latitude: 36.3, Longitude: 120.4}},
+	}
+	s := NewJobService(cfg, client.NewJobClient(cfg), nil, nil, router)
+
+	query := func(args tool.Args) model.JobResponse {
+		t.Helper()
+		args["jobTitle"], args["latitude"], args["longitude"] = "Java", "36.06", "120.38"
+		result, err := s.QueryJobsByLocation(withJobQueryState(context.Background(), newJobQueryState(nil)), args)
+		if err != nil {
+			t.Fatalf("query: %v", err)
+		}
+		var resp model.JobResponse
+		if err := json.Unmarshal([]byte(result), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+	titles := func(resp model.JobResponse) string {
+		var names []string
+		for _, job := range resp.JobListings {
+			names = append(names, job.JobTitle)
+		}
+		return strings.Join(names, ",")
+	}
+
+	// 按通勤时间排序，无法计算的排在最后；工作地址解析出的坐标同样计算通勤
+	resp := query(tool.Args{"sortByCommute": true})
+	if got := titles(resp); got != "近,按地址,远,无路线" {
+		t.Fatalf("sorted = %s", got)
+	}
+	if c := resp.JobListings[0].Commute; c == nil || c.Minutes != 25 || c.Mode != "公交地铁" || c.Distance != 12500 {
+		t.Fatalf("commute = %+v", c)
+	}
+	if resp.JobListings[3].Commute != nil || router.modes[0] != client.RouteTransit {
+		t.Fatalf("unroutable job should have no commute, modes = %v", router.modes)
+	}
+
+	// 最长通勤时间过滤超时和无法计算的岗位，保持原顺序
+	resp = query(tool.Args{"maxCommuteMinutes": "45分钟", "commuteMode": "开车"})
+	if got := titles(resp); got != "近,按地址" || resp.CommuteExcluded != 2 {
+		t.Fatalf("filtered = %s, excluded = %d", got, resp.CommuteExcluded)
+	}
+	if resp.JobListings[0].Commute.Mode != "驾车" {
+		t.Fatalf("mode = %s", resp.JobListings[0].Commute.Mode)
+	}
+
+	// 通勤条件需要出发地坐标
+	if _, err := s.QueryJobsByArea(context.Background(), tool.Args{"jobTitle": "Java", "maxCommuteMinutes": float64(30)}); !errors.Is(err, ErrInvalidJobQuery) {
+		t.Fatalf("commute without origin should be rejected, got %v", err)
+	}
+	if err := NewJobService(cfg, client.NewJobClient(cfg), nil, nil, nil).ValidateJobQuery(&model.JobQueryRequest{Latitude: "36", Longitude: "120", SortByCommute: true}); !errors.Is(err, ErrInvalidJobQuery) {
+		t.Fatalf("commute without router should be rejected, got %v", err)
+	}
+}
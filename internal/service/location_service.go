@@ -2,10 +2,19 @@ package service
 
 import (
 	"fmt"
+	"math"
 	"qd-sc/internal/client"
 	"qd-sc/internal/config"
+	"qd-sc/internal/model"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
+// closeConfidence 与最高置信度相差不超过该值的候选视为同样可能
+const closeConfidence = 0.1
+
 // LocationService 地理位置服务
 type LocationService struct {
 	cfg        *config.Config
@@ -20,11 +29,112 @@ func NewLocationService(cfg *config.Config, amapClient *client.AmapClient) *Loca
 	}
 }
 
-// QueryLocation 查询地点经纬度
-func (s *LocationService) QueryLocation(keywords string) (latitude, longitude string, err error) {
-	lat, lng, err := s.amapClient.GetLocationCoordinates(keywords)
+// SearchCandidates 查询地点候选，按置信度从高到低返回最多 amap.max_candidates 个（未配置时不限制）
+// 置信度相近的候选相距超过 amap.ambiguous_distance_km 时设置 Ambiguous，不返回坐标，由用户选择
+func (s *LocationService) SearchCandidates(keywords string) (*model.LocationResult, error) {
+	resp, err := s.amapClient.SearchPlace(keywords)
 	if err != nil {
-		return "", "", fmt.Errorf("查询地点失败: %w", err)
+		return nil, fmt.Errorf("查询地点失败: %w", err)
+	}
+
+	type scored struct {
+		candidate model.PlaceCandidate
+		coord     client.Coordinate
+	}
+	var candidates []scored
+	seen := make(map[string]bool)
+	for rank, poi := range resp.Pois {
+		coord, err := client.ParseLocation(poi.Location)
+		if err != nil || seen[poi.Name+poi.Address] {
+			continue
+		}
+		seen[poi.Name+poi.Address] = true
+		candidates = append(candidates, scored{
+			candidate: model.PlaceCandidate{
+				Name:       poi.Name,
+				Address:    poi.Address,
+				District:   poi.AdName,
+				Latitude:   strconv.FormatFloat(coord.Latitude, 'f', -1, 64),
+				Longitude:  strconv.FormatFloat(coord.Longitude, 'f', -1, 64),
+				Confidence: placeConfidence(keywords, poi.Name, rank),
+			},
+			coord: coord,
+		})
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("查询地点失败: %w: %s", client.ErrPlaceNotFound, keywords)
+	}
+
+	// 按置信度排序，相同时保持高德的相关度顺序
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].candidate.Confidence > candidates[j].candidate.Confidence
+	})
+	if limit := s.cfg.Amap.MaxCandidates; limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	result := &model.LocationResult{Keywords: keywords}
+	best := candidates[0]
+	for i, c := range candidates {
+		c.candidate.Index = i + 1
+		result.Candidates = append(result.Candidates, c.candidate)
+		if i > 0 && best.candidate.Confidence-c.candidate.Confidence <= closeConfidence &&
+			best.coord.DistanceKm(c.coord) > s.cfg.Amap.AmbiguousDistanceKm {
+			result.Ambiguous = true
+		}
+	}
+
+	if result.Ambiguous {
+		result.Message = fmt.Sprintf("「%s」匹配到多个相距较远的地点，需要用户从候选中选择", keywords)
+		return result, nil
+	}
+	result.Latitude = best.candidate.Latitude
+	result.Longitude = best.candidate.Longitude
+	result.Message = fmt.Sprintf("成功获取地点 %s 的坐标（%s）", keywords, placeLabel(best.candidate))
+	return result, nil
+}
+
+// placeConfidence 候选地点与关键词的匹配置信度（0-1）
+// 名称（去掉括号中的分店说明）与关键词相同最高，互相包含次之，否则按关键词字符出现比例计算；
+// 高德返回的排名越靠后置信度越低
+func placeConfidence(keywords, name string, rank int) float64 {
+	kw := strings.ToLower(strings.Join(strings.Fields(keywords), ""))
+	full := strings.ToLower(strings.Join(strings.Fields(name), ""))
+	base := full
+	if i := strings.IndexAny(base, "(（"); i > 0 {
+		base = base[:i]
+	}
+
+	var score float64
+	switch {
+	case kw == "":
+	case base == kw || full == kw:
+		score = 1
+	case strings.Contains(full, kw) || strings.Contains(kw, base):
+		shorter, longer := utf8.RuneCountInString(kw), utf8.RuneCountInString(base)
+		if shorter > longer {
+			shorter, longer = longer, shorter
+		}
+		score = 0.6 + 0.3*float64(shorter)/float64(longer)
+	default:
+		hits := 0
+		for _, r := range kw {
+			if strings.ContainsRune(full, r) {
+				hits++
+			}
+		}
+		score = 0.5 * float64(hits) / float64(utf8.RuneCountInString(kw))
+	}
+
+	score *= math.Max(0.5, 1-0.05*float64(rank))
+	return math.Round(score*100) / 100
+}
+
+// placeLabel 地点展示名称，如 "万达广场(CBD店)，市北区 延吉路116号"
+func placeLabel(c model.PlaceCandidate) string {
+	label := c.Name
+	if where := strings.TrimSpace(c.District + " " + c.Address); where != "" {
+		label += "，" + where
 	}
-	return lat, lng, nil
+	return label
 }
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"qd-sc/internal/model"
+	"qd-sc/internal/normalize"
+	"qd-sc/internal/tool"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// placeOptionsPattern 匹配回复中 RenderPlaceOptions 输出的地点候选
+var placeOptionsPattern = regexp.MustCompile("(?s)``` location-options\\s*\\n(.*?)\\n```")
+
+// placeIndexPattern 按序号选择候选的说法，如 "2"、"第二个"、"选3"、"1号"
+var placeIndexPattern = regexp.MustCompile(`^(?:选|就|要|是)?第?([0-9]+|[一二两三四五六七八九十])(?:个|项|号|条)?(?:吧|的|那个)?$`)
+
+// chineseIndex 中文序号
+var chineseIndex = map[string]int{"一": 1, "二": 2, "两": 2, "三": 3, "四": 4, "五": 5, "六": 6, "七": 7, "八": 8, "九": 9, "十": 10}
+
+// RenderPlaceOptions 将有歧义的地点查询结果渲染为澄清问题
+// 先列出带序号的候选，再附 ``` location-options 代码块（前端可渲染为可选按钮，下一轮据此解析用户的选择）
+func RenderPlaceOptions(result *model.LocationResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "「%s」有多个位置，请问您指的是哪一个？\n\n", result.Keywords)
+	for _, c := range result.Candidates {
+		fmt.Fprintf(&b, "%d. %s\n", c.Index, placeLabel(c))
+	}
+
+	options, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		log.Printf("格式化地点候选失败: %v", err)
+	} else {
+		fmt.Fprintf(&b, "\n``` location-options\n%s\n```\n", options)
+	}
+	b.WriteString("\n回复序号或地点名称即可。\n")
+	return b.String()
+}
+
+// pendingPlaceOptions 最近一条助手消息中等待用户选择的地点候选，没有时返回nil
+// 只看最近一条助手消息：之后的对话已经离开地点选择时不再解析
+func pendingPlaceOptions(history []model.Message) *model.LocationResult {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role != "assistant" {
+			continue
+		}
+		content, ok := history[i].Content.(string)
+		if !ok {
+			return nil
+		}
+		m := placeOptionsPattern.FindAllStringSubmatch(content, -1)
+		if len(m) == 0 {
+			return nil
+		}
+		var result model.LocationResult
+		if err := json.Unmarshal([]byte(m[len(m)-1][1]), &result); err != nil || len(result.Candidates) == 0 {
+			return nil
+		}
+		return &result
+	}
+	return nil
+}
+
+// choosePlace 根据用户回复（序号、名称或区县）从候选中选出一个，无法确定时返回nil
+func choosePlace(options *model.LocationResult, reply string) *model.PlaceCandidate {
+	if options == nil {
+		return nil
+	}
+	text := strings.Join(strings.Fields(reply), "")
+	text = strings.TrimRight(text, "。.!！")
+	if text == "" {
+		return nil
+	}
+
+	if m := placeIndexPattern.FindStringSubmatch(text); m != nil {
+		index, err := strconv.Atoi(m[1])
+		if err != nil {
+			index = chineseIndex[m[1]]
+		}
+		for i := range options.Candidates {
+			if options.Candidates[i].Index == index {
+				return &options.Candidates[i]
+			}
+		}
+		return nil
+	}
+
+	// 名称或区县只匹配到一个候选时选中
+	var match *model.PlaceCandidate
+	for i := range options.Candidates {
+		c := &options.Candidates[i]
+		if !placeMentioned(text, c) {
+			continue
+		}
+		if match != nil {
+			return nil
+		}
+		match = c
+	}
+	return match
+}
+
+// placeMentioned 回复中是否提到候选的名称、分店或所在区县
+func placeMentioned(text string, c *model.PlaceCandidate) bool {
+	name := strings.Join(strings.Fields(c.Name), "")
+	if strings.Contains(text, name) || (utf8.RuneCountInString(text) >= 2 && strings.Contains(name, text)) {
+		return true
+	}
+	// 括号中的分店说明，如 "万达广场(台东店)" 中的 "台东"
+	if i := strings.IndexAny(name, "(（"); i >= 0 {
+		branch := strings.TrimFunc(name[i:], func(r rune) bool { return strings.ContainsRune("()（）", r) })
+		branch = strings.TrimSuffix(branch, "店")
+		if utf8.RuneCountInString(branch) >= 2 && strings.Contains(text, branch) {
+			return true
+		}
+	}
+	district := strings.TrimRight(c.District, "区县市")
+	return utf8.RuneCountInString(district) >= 2 && strings.Contains(text, district)
+}
+
+// placeOptionsKey 上下文中等待选择的地点候选的键
+type placeOptionsKey struct{}
+
+// withPlaceOptions 在上下文中记录等待用户选择的地点候选，供 queryJobsByLocation 按 placeOption 解析
+func withPlaceOptions(ctx context.Context, options *model.LocationResult) context.Context {
+	return context.WithValue(ctx, placeOptionsKey{}, options)
+}
+
+// placeOptionsFrom 获取上下文中等待选择的地点候选，没有时返回nil
+func placeOptionsFrom(ctx context.Context) *model.LocationResult {
+	options, _ := ctx.Value(placeOptionsKey{}).(*model.LocationResult)
+	return options
+}
+
+// applyPlaceOption 将 placeOption（用户选择的候选序号或名称）解析为坐标写入 latitude、longitude
+// 未提供 placeOption 时要求已有坐标
+func applyPlaceOption(ctx context.Context, args tool.Args) error {
+	option, err := normalize.String(args["placeOption"])
+	if err != nil {
+		return fmt.Errorf("%w: placeOption %v", ErrInvalidJobQuery, err)
+	}
+	if option == "" {
+		if lat, _ := normalize.String(args["latitude"]); lat == "" {
+			return fmt.Errorf("%w: 缺少latitude/longitude参数，请先调用queryLocation获取坐标或传入placeOption", ErrInvalidJobQuery)
+		}
+		return nil
+	}
+
+	options := placeOptionsFrom(ctx)
+	place := choosePlace(options, option)
+	if place == nil {
+		if options == nil {
+			return fmt.Errorf("%w: 当前没有待选择的地点候选，请先调用queryLocation", ErrInvalidJobQuery)
+		}
+		return fmt.Errorf("%w: placeOption %q 无法对应到地点候选，请向用户确认", ErrInvalidJobQuery, option)
+	}
+	args["latitude"] = place.Latitude
+	args["longitude"] = place.Longitude
+	delete(args, "placeOption")
+	log.Printf("按用户选择的地点查询岗位: %s", placeLabel(*place))
+	return nil
+}
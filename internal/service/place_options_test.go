@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"qd-sc/internal/client"
+	"qd-sc/internal/config"
+	"qd-sc/internal/model"
+	"qd-sc/internal/tool"
+)
+
+// wandaOptions 两个同名且相距较远的万达广场候选
+var wandaOptions = &model.LocationResult{
+	Keywords:  "万达广场",
+	Ambiguous: true,
+	Candidates: []model.PlaceCandidate{
+		{Index: 1, Name: "万达广场(CBD店)", Address: "延吉路116号", District: "市北区", Latitude: "36.0921", Longitude: "120.3801", Confidence: 1},
+		{Index: 2, Name: "万达广场(黄岛店)", Address: "长江中路216号", District: "黄岛区", Latitude: "35.9602", Longitude: "120.1951", Confidence: 0.95},
+	},
+}
+
+func TestPlaceConfidence(t *testing.T) {
+	cases := []struct {
+		keywords, name string
+		rank           int
+		want           float64
+	}{
+		{"万达广场", "万达广场(CBD店)", 0, 1},
+		{"万达广场", "万达广场(黄岛店)", 1, 0.95},
+		{"万达", "万达广场", 0, 0.75},
+		{"五四广场", "五四广场地铁站", 0, 0.77},
+		{"火车站", "青岛站", 0, 0.17},
+	}
+	for _, c := range cases {
+		if got := placeConfidence(c.keywords, c.name, c.rank); got != c.want {
+			t.Errorf("placeConfidence(%q, %q, %d) = %v, want %v", c.keywords, c.name, c.rank, got, c.want)
+		}
+	}
+}
+
+func TestSearchCandidates_UnsetMaxCandidates(t *testing.T) {
+	amap := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := model.AmapPlaceResponse{Status: "1", Pois: []model.AmapPlace{
+			{Name: "五四广场", Location: "120.3844,36.0622", Address: "东海西路", AdName: "市南区"},
+			{Name: "五四广场-地铁站", Location: "120.3851,36.0630", Address: "3号线", AdName: "市南区"},
+		}}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(amap.Close)
+
+	// 未经 config.Load 的配置中 max_candidates 为0，不应截断为空列表
+	cfg := &config.Config{Amap: config.AmapConfig{BaseURL: amap.URL, Timeout: time.Second}}
+	result, err := NewLocationService(cfg, client.NewAmapClient(cfg)).SearchCandidates("五四广场")
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(result.Candidates) != 2 || result.Latitude != "36.0622" {
+		t.Fatalf("result = %+v", result)
+	}
+}
+
+func TestChoosePlace(t *testing.T) {
+	cases := map[string]int{
+		"2":       2,
+		"第二个":     2,
+		"选1":      1,
+		"1号。":     1,
+		"黄岛那个":    2,
+		"CBD店吧":   1,
+		"市北区的":    1,
+		"万达广场":    0, // 两个候选都匹配，无法确定
+		"第三个":     0,
+		"附近有什么工作": 0,
+	}
+	for reply, want := range cases {
+		got := choosePlace(wandaOptions, reply)
+		if (got == nil && want != 0) || (got != nil && got.Index != want) {
+			t.Errorf("choosePlace(%q) = %+v, want index %d", reply, got, want)
+		}
+	}
+	if choosePlace(nil, "1") != nil {
+		t.Error("choosePlace without options should return nil")
+	}
+}
+
+func TestPendingPlaceOptions(t *testing.T) {
+	rendered := RenderPlaceOptions(wandaOptions)
+	if !strings.Contains(rendered, "2. 万达广场(黄岛店)，黄岛区 长江中路216号") {
+		t.Fatalf("candidates not listed: %q", rendered)
+	}
+
+	history := []model.Message{
+		{Role: "user", Content: "万达广场附近的工作"},
+		{Role: "assistant", Content: rendered},
+		{Role: "user", Content: "第二个"},
+	}
+	options := pendingPlaceOptions(history)
+	if options == nil || len(options.Candidates) != 2 || options.Candidates[1].Latitude != "35.9602" {
+		t.Fatalf("options not parsed from history: %+v", options)
+	}
+
+	// 之后的助手回复不再是地点选择时不解析更早的候选
+	history = append(history, model.Message{Role: "assistant", Content: "为您找到以下岗位"}, model.Message{Role: "user", Content: "1"})
+	if options := pendingPlaceOptions(history); options != nil {
+		t.Fatalf("stale options should be ignored: %+v", options)
+	}
+}
+
+func TestApplyPlaceOption(t *testing.T) {
+	ctx := withPlaceOptions(context.Background(), wandaOptions)
+
+	args := tool.Args{"jobTitle": "销售", "placeOption": "黄岛店"}
+	if err := applyPlaceOption(ctx, args); err != nil {
+		t.Fatalf("applyPlaceOption: %v", err)
+	}
+	if args["latitude"] != "35.9602" || args["longitude"] != "120.1951" {
+		t.Fatalf("coordinates not applied: %+v", args)
+	}
+
+	if err := applyPlaceOption(ctx, tool.Args{"placeOption": "5"}); err == nil {
+		t.Fatal("expected error for unknown option")
+	}
+	if err := applyPlaceOption(context.Background(), tool.Args{"jobTitle": "销售"}); err == nil || !strings.Contains(err.Error(), "queryLocation") {
+		t.Fatalf("expected missing coordinates error, got %v", err)
+	}
+	if err := applyPlaceOption(context.Background(), tool.Args{"latitude": "36.1", "longitude": "120.4"}); err != nil {
+		t.Fatalf("explicit coordinates should pass: %v", err)
+	}
+}
+
+func TestRunAgent_AmbiguousPlaceAsksUserToChoose(t *testing.T) {
+	amap := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := model.AmapPlaceResponse{Status: "1", Pois: []model.AmapPlace{
+			{Name: "万达广场(CBD店)", Location: "120.3801,36.0921", Address: "延吉路116号", AdName: "市北区"},
+			{Name: "万达广场(黄岛店)", Location: "120.1951,35.9602", Address: "长江中路216号", AdName: "黄岛区"},
+		}}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(amap.Close)
+
+	llm := &fakeLLM{turns: [][]model.ChatCompletionChunk{
+		toolCallTurn("queryLocation", `{"keywords":"万达广场"}`),
+		toolCallTurn("queryJobsByLocation", `{"jobTitle":"销售","current":1,"pageSize":10,"radius":"5","placeOption":"第二个"}`),
+	}}
+	rows := []model.JobListing{{JobTitle: "销售顾问", CompanyName: "黄岛商贸", AppJobURL: "https://jobs.example/1"}}
+	s := newTestChatService(t, llm, rows, fmt.Sprintf("amap:\n  base_url: %q\n", amap.URL))
+
+	// 第一轮：地点有歧义，列出候选并结束，不再请求模型
+	runTurn(t, s, "conv-place", "万达广场附近有什么销售岗位")
+	if len(llm.requests) != 1 {
+		t.Fatalf("expected turn to end after clarification, got %d LLM requests", len(llm.requests))
+	}
+	conv, err := s.sessions.Get(context.Background(), "conv-place")
+	if err != nil {
+		t.Fatalf("get conversation: %v", err)
+	}
+	reply, _ := conv.Messages[len(conv.Messages)-1].Content.(string)
+	if !strings.Contains(reply, "``` location-options") || !strings.Contains(reply, "黄岛区") {
+		t.Fatalf("expected location options in reply, got %q", reply)
+	}
+
+	// 第二轮：用户选择第二个，按所选地点查询岗位
+	events, errs := s.RunAgent(context.Background(), &model.ChatCompletionRequest{
+		Model:          ExposedModelName,
+		Messages:       []model.Message{{Role: "user", Content: "第二个"}},
+		ConversationID: "conv-place",
+	})
+	var cards *model.JobResponse
+	for e := range events {
+		if e.Type == AgentEventToolCallFinished && e.ToolError != nil {
+			t.Fatalf("tool error: %v", e.ToolError)
+		}
+		if e.Type == AgentEventJobCards {
+			cards = e.Jobs
+		}
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("RunAgent error: %v", err)
+	}
+	if cards == nil || len(cards.JobListings) != 1 {
+		t.Fatalf("expected job cards after choosing place, got %+v", cards)
+	}
+}
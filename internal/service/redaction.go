@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+	"qd-sc/internal/redact"
+)
+
+type redactionVaultKey struct{}
+
+// withRedactionVault 为本次请求创建占位符表，同一请求内相同的敏感信息使用相同占位符
+func withRedactionVault(ctx context.Context) context.Context {
+	return context.WithValue(ctx, redactionVaultKey{}, redact.NewVault())
+}
+
+// redactionVaultFrom 读取上下文中的占位符表，不存在时返回nil
+func redactionVaultFrom(ctx context.Context) *redact.Vault {
+	vault, _ := ctx.Value(redactionVaultKey{}).(*redact.Vault)
+	return vault
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"log"
+	"qd-sc/internal/model"
+	"qd-sc/internal/tool"
+)
+
+// resumeProfileKey 上下文中求职者画像的键
+type resumeProfileKey struct{}
+
+// withResumeProfile 在上下文中记录求职者画像，供岗位工具预填查询条件
+func withResumeProfile(ctx context.Context, profile *model.ResumeProfile) context.Context {
+	if profile == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, resumeProfileKey{}, profile)
+}
+
+// resumeProfileFrom 读取上下文中的求职者画像，没有时返回nil
+func resumeProfileFrom(ctx context.Context) *model.ResumeProfile {
+	profile, _ := ctx.Value(resumeProfileKey{}).(*model.ResumeProfile)
+	return profile
+}
+
+// parseResumeProfile 从简历内容中提取求职者画像，未配置解析器或没有简历时返回nil
+func (s *ChatService) parseResumeProfile(ctx context.Context, resume string) *model.ResumeProfile {
+	if resume == "" || s.resumeParser == nil {
+		return nil
+	}
+	profile := s.resumeParser.Parse(ctx, resume)
+	if profile.IsEmpty() {
+		log.Printf("简历中未提取到画像信息")
+		return nil
+	}
+	return profile
+}
+
+// applyProfileDefaults 用求职者画像预填模型未提供的岗位查询条件（学历、经验、岗位名称）
+// 模型显式给出的参数保持不变
+func applyProfileDefaults(args tool.Args, profile *model.ResumeProfile) tool.Args {
+	if profile == nil {
+		return args
+	}
+
+	filled := make(tool.Args, len(args)+3)
+	for k, v := range args {
+		filled[k] = v
+	}
+	setDefault := func(key, value string) {
+		if value == "" {
+			return
+		}
+		if current, ok := filled.String(key); ok && current != "" {
+			return
+		}
+		filled[key] = value
+	}
+	// 模型已给出多个岗位名称时不再补充画像中的求职意向
+	if len(filled.Strings("jobTitles")) == 0 {
+		setDefault("jobTitle", profile.JobTitle())
+	}
+	setDefault("education", profile.Education)
+	setDefault("experience", profile.Experience)
+	return filled
+}
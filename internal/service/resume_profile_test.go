@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"qd-sc/internal/client"
+	"qd-sc/internal/config"
+	"qd-sc/internal/model"
+	"qd-sc/internal/tool"
+)
+
+func TestApplyProfileDefaults(t *testing.T) {
+	profile := &model.ResumeProfile{Education: "4", Experience: "5", Titles: []string{"Java开发工程师"}}
+
+	args := applyProfileDefaults(tool.Args{"jobTitle": "", "experience": "2", "current": float64(1)}, profile)
+	if args["jobTitle"] != "Java开发工程师" || args["education"] != "4" || args["experience"] != "2" || args["current"] != float64(1) {
+		t.Fatalf("unexpected args: %v", args)
+	}
+	if got := applyProfileDefaults(tool.Args{"jobTitle": "产品经理"}, nil); len(got) != 1 {
+		t.Fatalf("nil profile should not change args: %v", got)
+	}
+}
+
+func TestPrepareMessages_IncludesProfile(t *testing.T) {
+	s := newTestChatService(t, &fakeLLM{}, nil)
+	ctx := withResumeProfile(context.Background(), &model.ResumeProfile{Education: "4", DesiredTitle: "会计"})
+
+	messages := s.prepareMessages([]model.Message{{Role: "user", Content: "帮我找工作"}}, resumeProfileFrom(ctx))
+	system, _ := messages[0].Content.(string)
+	if !strings.Contains(system, "本科（education=4）") || !strings.Contains(system, "求职意向：会计") {
+		t.Fatalf("profile missing from system prompt: %q", system)
+	}
+}
+
+func TestQueryJobs_RanksByProfile(t *testing.T) {
+	rows := []model.JobListing{
+		{JobTitle: "餐厅服务员", CompanyName: "海味餐饮", Education: "-1", Experience: "0", MinSalary: 3500, MaxSalary: 4500},
+		{JobTitle: "Java开发工程师", CompanyName: "海洋软件", Education: "4", Experience: "5", MinSalary: 9000, MaxSalary: 13000, JobLocationAreaCode: 3},
+	}
+	s := newTestChatService(t, &fakeLLM{}, rows)
+	profile := &model.ResumeProfile{Education: "4", ExperienceYears: 4, Experience: "5", DesiredTitle: "Java开发", PreferredDistrict: "崂山区", PreferredAreaCode: "3"}
+
+	result, err := s.jobService.QueryJobsByArea(withResumeProfile(context.Background(), profile), tool.Args{"jobTitle": ""})
+	if err != nil {
+		t.Fatalf("query jobs: %v", err)
+	}
+	jobResp, err := parseJobResponse(result)
+	if err != nil {
+		t.Fatalf("parse result: %v", err)
+	}
+	top := jobResp.JobListings[0]
+	if top.CompanyName != "海洋软件" || top.MatchScore < jobResp.JobListings[1].MatchScore || len(top.MatchReasons) == 0 {
+		t.Fatalf("jobs not ranked by profile: %+v", jobResp.JobListings)
+	}
+
+	// 没有画像时保持接口返回顺序，不附加匹配度
+	result, _ = s.jobService.QueryJobsByArea(context.Background(), tool.Args{"jobTitle": "服务员"})
+	jobResp, _ = parseJobResponse(result)
+	if jobResp.JobListings[0].CompanyName != "海味餐饮" || jobResp.JobListings[0].MatchScore != 0 {
+		t.Fatalf("unexpected ranking without profile: %+v", jobResp.JobListings)
+	}
+}
+
+func TestQueryJobsByAreaTool_UserConditionsOverrideProfile(t *testing.T) {
+	var queries []url.Values
+	jobServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		_ = json.NewEncoder(w).Encode(model.JobAPIResponse{Code: 200})
+	}))
+	t.Cleanup(jobServer.Close)
+
+	cfg := &config.Config{}
+	cfg.JobAPI.BaseURL = jobServer.URL
+	reg := tool.NewRegistry()
+	if err := reg.Register(newQueryJobsByAreaTool(BuiltinToolDeps{
+		Config:     cfg,
+		JobService: NewJobService(cfg, client.NewJobClient(cfg), nil, nil, nil),
+	})); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	// 本科、3-5年经验的求职者
+	ctx := withResumeProfile(context.Background(), &model.ResumeProfile{Education: "4", Experience: "5", DesiredTitle: "会计"})
+	for _, arguments := range []string{
+		`{"current":1,"pageSize":10}`,
+		`{"jobTitle":"出纳","education":"大专","experience":"不限","current":1,"pageSize":10}`,
+	} {
+		call := &model.ToolCall{Function: model.FunctionCall{Name: "queryJobsByArea", Arguments: arguments}}
+		if _, err := reg.Execute(ctx, call); err != nil {
+			t.Fatalf("execute %s: %v", arguments, err)
+		}
+	}
+
+	if len(queries) != 2 {
+		t.Fatalf("expected 2 job API requests, got %d", len(queries))
+	}
+	if q := queries[0]; q.Get("jobTitle") != "会计" || q.Get("education") != "4" || q.Get("experience") != "5" {
+		t.Fatalf("profile should prefill job title, education and experience: %v", q)
+	}
+	if q := queries[1]; q.Get("jobTitle") != "出纳" || q.Get("education") != "3" || q.Get("experience") != "0" {
+		t.Fatalf("user conditions should override the profile: %v", q)
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"qd-sc/internal/model"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// jobCardPattern 匹配回复中 RenderJobCards 输出的岗位卡片
+var jobCardPattern = regexp.MustCompile("(?s)``` job-json\\s*\\n(.*?)\\n```")
+
+// shownJobs 本次请求中用户已经看到的岗位，按展示批次保存
+// 岗位详情工具只接受其中的岗位，序号按最近一批岗位解析；继续翻页时跳过其中的岗位
+type shownJobs struct {
+	mu      sync.Mutex
+	batches [][]model.FormattedJob
+}
+
+// newShownJobs 从历史消息中的岗位卡片恢复已展示的岗位，每条助手消息为一批
+func newShownJobs(history []model.Message) *shownJobs {
+	shown := &shownJobs{}
+	for _, msg := range history {
+		if msg.Role != "assistant" {
+			continue
+		}
+		content, ok := msg.Content.(string)
+		if !ok {
+			continue
+		}
+		shown.add(parseJobCards(content))
+	}
+	return shown
+}
+
+// parseJobCards 解析文本中的岗位卡片
+func parseJobCards(content string) []model.FormattedJob {
+	var jobs []model.FormattedJob
+	for _, m := range jobCardPattern.FindAllStringSubmatch(content, -1) {
+		var job model.FormattedJob
+		if err := json.Unmarshal([]byte(m[1]), &job); err == nil {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
+
+// add 追加一批已展示的岗位，空批次忽略
+func (s *shownJobs) add(jobs []model.FormattedJob) {
+	if len(jobs) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, jobs)
+}
+
+// resolve 按岗位ID或序号（从1开始，对应最近一批岗位）查找已展示的岗位
+func (s *shownJobs) resolve(jobID string, index int) (model.FormattedJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.batches) == 0 {
+		return model.FormattedJob{}, fmt.Errorf("当前对话中还没有展示过岗位，请先查询岗位")
+	}
+
+	if jobID = strings.TrimSpace(jobID); jobID != "" {
+		for i := len(s.batches) - 1; i >= 0; i-- {
+			for _, job := range s.batches[i] {
+				if job.JobID == jobID {
+					return job, nil
+				}
+			}
+		}
+		return model.FormattedJob{}, fmt.Errorf("岗位 %s 不在已展示的岗位中，只能查询已展示岗位的详情", jobID)
+	}
+
+	latest := s.batches[len(s.batches)-1]
+	if index < 1 || index > len(latest) {
+		return model.FormattedJob{}, fmt.Errorf("序号 %d 超出范围，最近一次展示了 %d 个岗位", index, len(latest))
+	}
+	job := latest[index-1]
+	if job.JobID == "" {
+		return model.FormattedJob{}, fmt.Errorf("第 %d 个岗位缺少岗位ID，无法查询详情", index)
+	}
+	return job, nil
+}
+
+// urls 已展示岗位的职位链接集合，返回的集合可由调用方修改
+func (s *shownJobs) urls() map[string]bool {
+	seen := make(map[string]bool)
+	if s == nil {
+		return seen
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, batch := range s.batches {
+		for _, job := range batch {
+			if job.AppJobURL != "" {
+				seen[job.AppJobURL] = true
+			}
+		}
+	}
+	return seen
+}
+
+// shownJobsKey 上下文中已展示岗位的键
+type shownJobsKey struct{}
+
+// withShownJobs 在上下文中记录已展示的岗位，供岗位详情和继续翻页工具使用
+func withShownJobs(ctx context.Context, shown *shownJobs) context.Context {
+	return context.WithValue(ctx, shownJobsKey{}, shown)
+}
+
+// shownJobsFrom 读取上下文中已展示的岗位，没有时返回nil
+func shownJobsFrom(ctx context.Context) *shownJobs {
+	shown, _ := ctx.Value(shownJobsKey{}).(*shownJobs)
+	return shown
+}
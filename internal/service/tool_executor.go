@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"qd-sc/internal/model"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// toolCallResult 单个工具调用的执行结果
+type toolCallResult struct {
+	content  string
+	err      error
+	duration time.Duration
+}
+
+// executeToolCalls 并发执行同一轮中的多个工具调用，结果按原始顺序返回
+// 每个调用有独立的超时，某个调用失败或超时不会取消其他调用
+func (s *ChatService) executeToolCalls(ctx context.Context, calls []model.ToolCall) []toolCallResult {
+	results := make([]toolCallResult, len(calls))
+	if len(calls) == 0 {
+		return results
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := range calls {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = s.executePooledToolCall(ctx, &calls[i])
+		}(i)
+	}
+	wg.Wait()
+
+	if len(calls) > 1 {
+		log.Printf("并发执行 %d 个工具调用完成，总耗时: %v", len(calls), time.Since(start))
+	}
+	return results
+}
+
+// executePooledToolCall 在工作池中执行单个工具调用（带超时）
+func (s *ChatService) executePooledToolCall(ctx context.Context, call *model.ToolCall) toolCallResult {
+	callCtx, cancel := context.WithTimeout(ctx, s.cfg.Performance.ToolTimeout)
+	defer cancel()
+
+	start := time.Now()
+
+	// 获取工作池令牌，池满时等待
+	select {
+	case s.toolPool <- struct{}{}:
+	case <-callCtx.Done():
+		return toolCallResult{err: fmt.Errorf("等待工具执行资源超时: %w", callCtx.Err()), duration: time.Since(start)}
+	}
+
+	done := make(chan toolCallResult, 1)
+	go func() {
+		// 令牌在工具真正返回后才释放，保证同时运行的工具数量不超过池大小
+		defer func() { <-s.toolPool }()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[PANIC] 工具 %s 执行异常: %v\n%s", call.Function.Name, r, string(debug.Stack()))
+				done <- toolCallResult{err: fmt.Errorf("工具执行异常: %v", r)}
+			}
+		}()
+
+		content, err := s.executeToolCall(callCtx, call)
+		done <- toolCallResult{content: content, err: err}
+	}()
+
+	select {
+	case result := <-done:
+		result.duration = time.Since(start)
+		return result
+	case <-callCtx.Done():
+		// 部分客户端不支持context，超时后不再等待其返回
+		return toolCallResult{err: fmt.Errorf("工具执行超时(%v): %w", s.cfg.Performance.ToolTimeout, callCtx.Err()), duration: time.Since(start)}
+	}
+}
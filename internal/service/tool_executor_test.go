@@ -0,0 +1,159 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"qd-sc/internal/model"
+	"qd-sc/internal/tool"
+)
+
+// registerSleepTool 注册一个休眠指定时间后返回的测试工具，并记录最大并发数
+func registerSleepTool(t *testing.T, s *ChatService, name string, delay time.Duration, fail bool, running, peak *int32) {
+	t.Helper()
+	s.tools.MustRegister(&tool.Func{
+		ToolName: name,
+		Handler: func(ctx context.Context, args tool.Args) (string, error) {
+			n := atomic.AddInt32(running, 1)
+			defer atomic.AddInt32(running, -1)
+			for {
+				old := atomic.LoadInt32(peak)
+				if n <= old || atomic.CompareAndSwapInt32(peak, old, n) {
+					break
+				}
+			}
+
+			time.Sleep(delay)
+			if fail {
+				return "", errors.New("boom")
+			}
+			return "result-" + name, nil
+		},
+	})
+}
+
+func toolCalls(names ...string) []model.ToolCall {
+	calls := make([]model.ToolCall, 0, len(names))
+	for i, name := range names {
+		calls = append(calls, model.ToolCall{
+			ID:       fmt.Sprintf("call_%d", i),
+			Function: model.FunctionCall{Name: name, Arguments: `{}`},
+		})
+	}
+	return calls
+}
+
+func TestExecuteToolCalls_ParallelOrderedAndIsolated(t *testing.T) {
+	s := newTestChatService(t, &fakeLLM{}, nil)
+	s.cfg.Performance.ToolTimeout = 150 * time.Millisecond
+
+	var running, peak int32
+	registerSleepTool(t, s, "slowA", 60*time.Millisecond, false, &running, &peak)
+	registerSleepTool(t, s, "failing", 10*time.Millisecond, true, &running, &peak)
+	registerSleepTool(t, s, "hanging", time.Second, false, &running, &peak)
+	registerSleepTool(t, s, "slowB", 60*time.Millisecond, false, &running, &peak)
+
+	start := time.Now()
+	results := s.executeToolCalls(context.Background(), toolCalls("slowA", "failing", "hanging", "slowB"))
+	elapsed := time.Since(start)
+
+	if elapsed > 500*time.Millisecond {
+		t.Fatalf("tool calls did not run concurrently, took %v", elapsed)
+	}
+	if results[0].err != nil || results[0].content != "result-slowA" {
+		t.Fatalf("slowA: %+v", results[0])
+	}
+	if results[1].err == nil {
+		t.Fatalf("expected failing tool to report error")
+	}
+	if results[2].err == nil || !errors.Is(results[2].err, context.DeadlineExceeded) {
+		t.Fatalf("expected hanging tool to time out, got %+v", results[2])
+	}
+	if results[3].err != nil || results[3].content != "result-slowB" {
+		t.Fatalf("slowB should not be cancelled by siblings: %+v", results[3])
+	}
+	if atomic.LoadInt32(&peak) < 2 {
+		t.Fatalf("expected concurrent execution, peak=%d", peak)
+	}
+}
+
+func TestExecuteToolCalls_PoolSizeBoundsConcurrency(t *testing.T) {
+	s := newTestChatService(t, &fakeLLM{}, nil)
+	s.toolPool = make(chan struct{}, 2)
+
+	var running, peak int32
+	for _, name := range []string{"t1", "t2", "t3", "t4", "t5"} {
+		registerSleepTool(t, s, name, 30*time.Millisecond, false, &running, &peak)
+	}
+
+	results := s.executeToolCalls(context.Background(), toolCalls("t1", "t2", "t3", "t4", "t5"))
+	for i, r := range results {
+		if want := fmt.Sprintf("result-t%d", i+1); r.err != nil || r.content != want {
+			t.Fatalf("result %d = %+v, want %q", i, r, want)
+		}
+	}
+	if got := atomic.LoadInt32(&peak); got > 2 {
+		t.Fatalf("pool size 2 exceeded, peak=%d", got)
+	}
+}
+
+func TestExecuteToolCall_RedactsLoggedArguments(t *testing.T) {
+	s := newTestChatService(t, &fakeLLM{}, nil)
+	s.tools.MustRegister(&tool.Func{
+		ToolName: "echo",
+		Handler: func(ctx context.Context, args tool.Args) (string, error) {
+			return "", errors.New("联系电话 13812345678 无效")
+		},
+	})
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	s.executeToolCalls(context.Background(), []model.ToolCall{{
+		ID:       "call_0",
+		Function: model.FunctionCall{Name: "echo", Arguments: `{"name":"张伟","phone":"13812345678","idCard":"370202199001011237"}`},
+	}})
+	if strings.Contains(logs.String(), "13812345678") || strings.Contains(logs.String(), "370202199001011237") {
+		t.Fatalf("personal data leaked to logs: %s", logs.String())
+	}
+}
+
+func TestRunAgent_RedactsErrorsSentToLLM(t *testing.T) {
+	llm := &fakeLLM{turns: [][]model.ChatCompletionChunk{
+		toolCallTurn("lookup", `{}`),
+		textTurn("查询失败了"),
+	}}
+	s := newTestChatService(t, llm, nil)
+	s.tools.MustRegister(&tool.Func{
+		ToolName: "lookup",
+		Handler: func(ctx context.Context, args tool.Args) (string, error) {
+			return "", errors.New("上游返回错误: 用户 zhangwei@example.com 手机 13812345678 不存在")
+		},
+	})
+
+	collectEvents(t, s, "帮我查一下")
+	if len(llm.requests) != 2 {
+		t.Fatalf("expected 2 LLM requests, got %d", len(llm.requests))
+	}
+	for _, msg := range llm.requests[1].Messages {
+		content, _ := msg.Content.(string)
+		if strings.Contains(content, "13812345678") || strings.Contains(content, "zhangwei@example.com") {
+			t.Fatalf("tool error sent to LLM without redaction: %q", content)
+		}
+	}
+
+	// 附件解析失败的提示同样脱敏
+	text, _ := s.formatAttachment(context.Background(), nil, errors.New("下载文件失败: https://files.example.com/cv.pdf?phone=13812345678"))
+	if strings.Contains(text, "13812345678") || !strings.Contains(text, "图片解析失败") {
+		t.Fatalf("attachment error not redacted: %q", text)
+	}
+}
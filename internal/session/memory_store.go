@@ -0,0 +1,139 @@
+package session
+
+import (
+	"context"
+	"qd-sc/internal/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore 内存会话存储（带TTL过期和后台清理）
+type MemoryStore struct {
+	mu            sync.RWMutex
+	conversations map[string]*model.Conversation
+	ttl           time.Duration
+	stop          chan struct{}
+	stopOnce      sync.Once
+
+	now func() time.Time // 便于测试注入
+}
+
+// NewMemoryStore 创建内存会话存储
+// cleanupInterval 大于0时启动后台清理过期会话
+func NewMemoryStore(ttl, cleanupInterval time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		conversations: make(map[string]*model.Conversation),
+		ttl:           ttl,
+		stop:          make(chan struct{}),
+		now:           time.Now,
+	}
+
+	if cleanupInterval > 0 {
+		go s.cleanupLoop(cleanupInterval)
+	}
+	return s
+}
+
+// Get 获取会话
+func (s *MemoryStore) Get(ctx context.Context, id string) (*model.Conversation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	conv, ok := s.conversations[id]
+	if !ok || s.expired(conv) {
+		return nil, ErrNotFound
+	}
+	return conv.Clone(), nil
+}
+
+// Save 保存会话并刷新过期时间
+func (s *MemoryStore) Save(ctx context.Context, conv *model.Conversation) error {
+	now := s.now()
+	stored := conv.Clone()
+	if stored.CreatedAt.IsZero() {
+		stored.CreatedAt = now
+	}
+	stored.UpdatedAt = now
+	stored.ExpiresAt = now.Add(s.ttl)
+
+	s.mu.Lock()
+	s.conversations[stored.ID] = stored
+	s.mu.Unlock()
+
+	// 回写时间字段，方便调用方直接返回给客户端
+	conv.CreatedAt, conv.UpdatedAt, conv.ExpiresAt = stored.CreatedAt, stored.UpdatedAt, stored.ExpiresAt
+	return nil
+}
+
+// Delete 删除会话
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conv, ok := s.conversations[id]
+	if !ok || s.expired(conv) {
+		delete(s.conversations, id)
+		return ErrNotFound
+	}
+	delete(s.conversations, id)
+	return nil
+}
+
+// List 列出未过期的会话摘要
+func (s *MemoryStore) List(ctx context.Context) ([]model.ConversationSummary, error) {
+	s.mu.RLock()
+	summaries := make([]model.ConversationSummary, 0, len(s.conversations))
+	for _, conv := range s.conversations {
+		if !s.expired(conv) {
+			summaries = append(summaries, conv.Summary())
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].UpdatedAt.After(summaries[j].UpdatedAt)
+	})
+	return summaries, nil
+}
+
+// Close 停止后台清理
+func (s *MemoryStore) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	return nil
+}
+
+// expired 会话是否已过期（ttl<=0 表示永不过期）
+func (s *MemoryStore) expired(conv *model.Conversation) bool {
+	return s.ttl > 0 && s.now().After(conv.ExpiresAt)
+}
+
+// removeExpired 清理过期会话，返回清理数量
+func (s *MemoryStore) removeExpired() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for id, conv := range s.conversations {
+		if s.expired(conv) {
+			delete(s.conversations, id)
+			removed++
+		}
+	}
+	return removed
+}
+
+// cleanupLoop 定期清理过期会话
+func (s *MemoryStore) cleanupLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.removeExpired()
+		case <-s.stop:
+			return
+		}
+	}
+}
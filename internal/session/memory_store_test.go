@@ -0,0 +1,76 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"qd-sc/internal/model"
+)
+
+func TestMemoryStore_SaveGetIsolated(t *testing.T) {
+	s := NewMemoryStore(time.Hour, 0)
+	defer s.Close()
+	ctx := context.Background()
+
+	conv := &model.Conversation{ID: "c1", Messages: []model.Message{{Role: "user", Content: "你好"}}}
+	if err := s.Save(ctx, conv); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if conv.CreatedAt.IsZero() || conv.ExpiresAt.Before(conv.UpdatedAt) {
+		t.Fatalf("timestamps not set: %+v", conv)
+	}
+
+	// 修改调用方持有的会话不应影响存储内容
+	conv.Messages[0].Content = "已修改"
+	got, err := s.Get(ctx, "c1")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Messages[0].Content != "你好" {
+		t.Fatalf("stored conversation shares memory with caller: %+v", got.Messages)
+	}
+	got.Messages = append(got.Messages, model.Message{Role: "assistant", Content: "x"})
+	again, _ := s.Get(ctx, "c1")
+	if len(again.Messages) != 1 {
+		t.Fatalf("returned conversation shares memory with store")
+	}
+}
+
+func TestMemoryStore_ExpiryListAndDelete(t *testing.T) {
+	s := NewMemoryStore(time.Minute, 0)
+	defer s.Close()
+	ctx := context.Background()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	_ = s.Save(ctx, &model.Conversation{ID: "old"})
+	now = now.Add(30 * time.Second)
+	_ = s.Save(ctx, &model.Conversation{ID: "new", Resume: "简历"})
+
+	list, _ := s.List(ctx)
+	if len(list) != 2 || list[0].ID != "new" || !list[0].HasResume {
+		t.Fatalf("unexpected list: %+v", list)
+	}
+
+	// old 已过期
+	now = now.Add(45 * time.Second)
+	if _, err := s.Get(ctx, "old"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected expired conversation, got %v", err)
+	}
+	if list, _ := s.List(ctx); len(list) != 1 {
+		t.Fatalf("expired conversation still listed: %+v", list)
+	}
+	if removed := s.removeExpired(); removed != 1 {
+		t.Fatalf("removeExpired = %d, want 1", removed)
+	}
+
+	if err := s.Delete(ctx, "new"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := s.Delete(ctx, "new"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found on second delete, got %v", err)
+	}
+}
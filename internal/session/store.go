@@ -0,0 +1,37 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"qd-sc/internal/config"
+	"qd-sc/internal/model"
+)
+
+// ErrNotFound 会话不存在或已过期
+var ErrNotFound = errors.New("会话不存在或已过期")
+
+// Store 会话存储
+// 内存实现之外，可按此接口增加文件、SQLite 等持久化后端
+type Store interface {
+	// Get 获取会话，不存在或已过期时返回 ErrNotFound
+	Get(ctx context.Context, id string) (*model.Conversation, error)
+	// Save 保存会话并刷新过期时间
+	Save(ctx context.Context, conv *model.Conversation) error
+	// Delete 删除（立即过期）会话，不存在时返回 ErrNotFound
+	Delete(ctx context.Context, id string) error
+	// List 列出未过期的会话摘要，按更新时间倒序
+	List(ctx context.Context) ([]model.ConversationSummary, error)
+	// Close 释放存储资源
+	Close() error
+}
+
+// NewStore 根据配置创建会话存储
+func NewStore(cfg *config.SessionConfig) (Store, error) {
+	switch cfg.Backend {
+	case "", "memory":
+		return NewMemoryStore(cfg.TTL, cfg.CleanupInterval), nil
+	default:
+		return nil, fmt.Errorf("不支持的会话存储后端: %s", cfg.Backend)
+	}
+}
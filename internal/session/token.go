@@ -0,0 +1,30 @@
+package session
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"qd-sc/internal/model"
+)
+
+// ErrForbidden 会话令牌缺失或与创建会话时签发的令牌不一致
+var ErrForbidden = errors.New("会话令牌无效，无权访问该会话")
+
+// NewToken 生成会话令牌（创建会话时签发给客户端，之后读取、删除和继续会话时用于证明所有权）
+func NewToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成会话令牌失败: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CheckToken 校验调用方提供的会话令牌，会话没有令牌时一律拒绝
+func CheckToken(conv *model.Conversation, token string) error {
+	if conv.Token == "" || subtle.ConstantTimeCompare([]byte(conv.Token), []byte(token)) != 1 {
+		return ErrForbidden
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"qd-sc/internal/model"
+	"sync"
+)
+
+// Registry 工具注册表
+// 启动时注册工具，LLM请求的工具列表和工具调用分发都来自注册表
+type Registry struct {
+	mu    sync.RWMutex
+	tools map[string]Tool
+	order []string // 注册顺序，保证提供给模型的工具列表稳定
+}
+
+// NewRegistry 创建工具注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		tools: make(map[string]Tool),
+	}
+}
+
+// Register 注册工具，名称重复时返回错误
+func (r *Registry) Register(t Tool) error {
+	name := t.Name()
+	if name == "" {
+		return fmt.Errorf("工具名称不能为空")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tools[name]; exists {
+		return fmt.Errorf("工具已注册: %s", name)
+	}
+	r.tools[name] = t
+	r.order = append(r.order, name)
+	return nil
+}
+
+// MustRegister 注册工具，失败时panic（用于启动阶段）
+func (r *Registry) MustRegister(tools ...Tool) {
+	for _, t := range tools {
+		if err := r.Register(t); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Get 按名称获取工具
+func (r *Registry) Get(name string) (Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.tools[name]
+	return t, ok
+}
+
+// List 按注册顺序返回全部工具
+func (r *Registry) List() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tools := make([]Tool, 0, len(r.order))
+	for _, name := range r.order {
+		tools = append(tools, r.tools[name])
+	}
+	return tools
+}
+
+// Filter 按注册顺序返回满足条件的工具
+func (r *Registry) Filter(match func(Tool) bool) []Tool {
+	var result []Tool
+	for _, t := range r.List() {
+		if match(t) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// Definitions 返回提供给LLM的工具定义列表
+func (r *Registry) Definitions() []model.Tool {
+	return r.DefinitionsFor(func(Tool) bool { return true })
+}
+
+// DefinitionsFor 返回满足条件的工具定义，按注册顺序排列
+func (r *Registry) DefinitionsFor(keep func(Tool) bool) []model.Tool {
+	tools := r.Filter(keep)
+	defs := make([]model.Tool, 0, len(tools))
+	for _, t := range tools {
+		defs = append(defs, t.Definition())
+	}
+	return defs
+}
+
+// Flags 获取工具特性标记，未注册的工具返回零值
+func (r *Registry) Flags(name string) Flags {
+	if t, ok := r.Get(name); ok {
+		return t.Flags()
+	}
+	return Flags{}
+}
+
+// Execute 解析参数并执行工具调用
+func (r *Registry) Execute(ctx context.Context, call *model.ToolCall) (string, error) {
+	t, ok := r.Get(call.Function.Name)
+	if !ok {
+		return "", fmt.Errorf("未知的工具: %s", call.Function.Name)
+	}
+
+	args, err := ParseArgs(call.Function.Arguments)
+	if err != nil {
+		return "", err
+	}
+
+	return t.Execute(ctx, args)
+}
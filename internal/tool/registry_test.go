@@ -0,0 +1,84 @@
+package tool
+
+import (
+	"context"
+	"testing"
+
+	"qd-sc/internal/model"
+)
+
+func echoTool(name string, flags Flags) *Func {
+	return &Func{
+		ToolName:  name,
+		ToolFlags: flags,
+		Parameters: map[string]interface{}{
+			"type": "object",
+		},
+		Handler: func(ctx context.Context, args Args) (string, error) {
+			v, _ := args.String("value")
+			return name + ":" + v, nil
+		},
+	}
+}
+
+func TestRegistry_RegisterAndDispatch(t *testing.T) {
+	reg := NewRegistry()
+	reg.MustRegister(echoTool("b", Flags{}), echoTool("a", Flags{JobTool: true}))
+
+	if err := reg.Register(echoTool("a", Flags{})); err == nil {
+		t.Fatalf("expected duplicate registration to fail")
+	}
+
+	defs := reg.Definitions()
+	if len(defs) != 2 || defs[0].Function.Name != "b" || defs[1].Function.Name != "a" {
+		t.Fatalf("definitions should keep registration order, got %+v", defs)
+	}
+	if !reg.Flags("a").JobTool || reg.Flags("b").JobTool || reg.Flags("missing").JobTool {
+		t.Fatalf("unexpected flags lookup result")
+	}
+
+	got, err := reg.Execute(context.Background(), &model.ToolCall{
+		Function: model.FunctionCall{Name: "a", Arguments: `{"value":42}`},
+	})
+	if err != nil || got != "a:42" {
+		t.Fatalf("Execute() = %q, %v", got, err)
+	}
+
+	if _, err := reg.Execute(context.Background(), &model.ToolCall{
+		Function: model.FunctionCall{Name: "missing", Arguments: `{}`},
+	}); err == nil {
+		t.Fatalf("expected unknown tool error")
+	}
+	if _, err := reg.Execute(context.Background(), &model.ToolCall{
+		Function: model.FunctionCall{Name: "a", Arguments: ``},
+	}); err == nil {
+		t.Fatalf("expected empty arguments error")
+	}
+}
+
+func TestArgs_TypedAccessors(t *testing.T) {
+	args, err := ParseArgs(`{"page":"3","size":20,"name":"Java","bad":"x","areas":["0"," 3 ",3,"","0"]}`)
+	if err != nil {
+		t.Fatalf("ParseArgs: %v", err)
+	}
+	if args.Int("page", 1) != 3 || args.Int("size", 1) != 20 || args.Int("bad", 7) != 7 || args.Int("missing", 9) != 9 {
+		t.Fatalf("Int accessor mismatch: %+v", args)
+	}
+	if got := args.Strings("areas"); len(got) != 2 || got[0] != "0" || got[1] != "3" {
+		t.Fatalf("Strings(areas) = %q", got)
+	}
+	if got := args.Strings("name"); len(got) != 1 || got[0] != "Java" {
+		t.Fatalf("Strings(name) = %q", got)
+	}
+	if got := args.Strings("missing"); got != nil {
+		t.Fatalf("Strings(missing) = %q", got)
+	}
+
+	var decoded struct {
+		Name string `json:"name"`
+		Size int    `json:"size"`
+	}
+	if err := args.Decode(&decoded); err != nil || decoded.Name != "Java" || decoded.Size != 20 {
+		t.Fatalf("Decode() = %+v, %v", decoded, err)
+	}
+}
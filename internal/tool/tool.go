@@ -0,0 +1,154 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"qd-sc/internal/model"
+	"strconv"
+	"strings"
+)
+
+// Flags 工具特性标记
+type Flags struct {
+	// JobTool 岗位查询工具：调用后视为已获取真实岗位数据，解除岗位幻觉拦截
+	JobTool bool
+	// TerminatesStream 调用成功后直接把结果展示为岗位卡片并结束对话（结果须为 model.JobResponse 的JSON）
+	TerminatesStream bool
+	// PolicyTool 政策咨询工具：政策咨询意图下优先调用
+	PolicyTool bool
+	// Clarifies 结果为 model.LocationResult，有歧义时直接向用户展示候选项并结束本轮，等待用户选择
+	Clarifies bool
+}
+
+// Tool 可被模型调用的工具
+type Tool interface {
+	// Name 工具名称，在注册表中唯一
+	Name() string
+	// Definition 提供给LLM的工具定义（名称、描述、参数JSON Schema）
+	Definition() model.Tool
+	// Flags 工具特性标记
+	Flags() Flags
+	// Execute 执行工具调用
+	Execute(ctx context.Context, args Args) (string, error)
+}
+
+// HandlerFunc 工具处理函数
+type HandlerFunc func(ctx context.Context, args Args) (string, error)
+
+// Func 基于函数实现的工具，适合大多数无状态工具
+type Func struct {
+	ToolName    string                 // 工具名称
+	Description string                 // 工具描述
+	Parameters  map[string]interface{} // 参数JSON Schema
+	ToolFlags   Flags                  // 工具特性标记
+	Handler     HandlerFunc            // 处理函数
+}
+
+// Name 工具名称
+func (f *Func) Name() string {
+	return f.ToolName
+}
+
+// Definition 工具定义
+func (f *Func) Definition() model.Tool {
+	return model.Tool{
+		Type: "function",
+		Function: model.FunctionDef{
+			Name:        f.ToolName,
+			Description: f.Description,
+			Parameters:  f.Parameters,
+		},
+	}
+}
+
+// Flags 工具特性标记
+func (f *Func) Flags() Flags {
+	return f.ToolFlags
+}
+
+// Execute 执行工具调用
+func (f *Func) Execute(ctx context.Context, args Args) (string, error) {
+	return f.Handler(ctx, args)
+}
+
+// Args 工具调用参数（模型生成的JSON对象）
+type Args map[string]interface{}
+
+// ParseArgs 解析模型生成的参数JSON
+func ParseArgs(raw string) (Args, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, fmt.Errorf("工具参数为空")
+	}
+
+	var args Args
+	if err := json.Unmarshal([]byte(raw), &args); err != nil {
+		return nil, fmt.Errorf("解析工具参数失败: %w", err)
+	}
+	if args == nil {
+		args = Args{}
+	}
+	return args, nil
+}
+
+// String 获取字符串参数，数字会被转换为字符串
+func (a Args) String(key string) (string, bool) {
+	switch v := a[key].(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// Int 获取整数参数，支持数字和数字字符串，缺失或无法解析时返回默认值
+func (a Args) Int(key string, def int) int {
+	switch v := a[key].(type) {
+	case float64:
+		return int(v)
+	case string:
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return n
+		}
+	}
+	return def
+}
+
+// Strings 获取字符串列表参数，单个字符串视为只有一项的列表，列表中的数字转换为字符串
+// 去除空白项和重复项，保持原顺序
+func (a Args) Strings(key string) []string {
+	var items []interface{}
+	switch v := a[key].(type) {
+	case []interface{}:
+		items = v
+	case string, float64:
+		items = []interface{}{v}
+	}
+
+	var values []string
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		value, ok := Args{key: item}.String(key)
+		value = strings.TrimSpace(value)
+		if !ok || value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		values = append(values, value)
+	}
+	return values
+}
+
+// Decode 将参数解码到结构体
+func (a Args) Decode(v interface{}) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("序列化工具参数失败: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("解码工具参数失败: %w", err)
+	}
+	return nil
+}
@@ -18,6 +18,23 @@ type Metrics struct {
 	// 延迟统计
 	requestLatency sync.Map // map[string]*LatencyStats
 
+	// 岗位事实核验
+	groundingChecks      uint64
+	groundingUngrounded  uint64
+	groundingUnsupported uint64
+	groundingActions     sync.Map // map[string]*uint64
+
+	// 文件解析路径（本地提取 / OCR）及耗时
+	extractionLatency   sync.Map // map[string]*LatencyStats
+	extractionFallbacks uint64   // 尝试本地提取失败后回退到OCR的次数
+	fileCacheHits       uint64   // 文件解析结果缓存命中次数
+	fileCacheMisses     uint64
+
+	// 岗位查询缓存
+	jobCacheHits   uint64
+	jobCacheMisses uint64
+	jobCacheShared uint64 // 与并发的相同查询合并、未单独请求岗位API的次数
+
 	// 系统指标
 	startTime time.Time
 
@@ -69,9 +86,47 @@ func (m *Metrics) IncStreamRequests() {
 
 // RecordLatency 记录请求延迟
 func (m *Metrics) RecordLatency(endpoint string, duration time.Duration) {
+	recordLatency(&m.requestLatency, endpoint, duration)
+}
+
+// RecordExtraction 记录一次文件解析及其耗时，path 为解析路径（pdf_text、docx、ocr）
+func (m *Metrics) RecordExtraction(path string, duration time.Duration) {
+	recordLatency(&m.extractionLatency, path, duration)
+}
+
+// IncExtractionFallbacks 增加本地提取失败回退到OCR的次数
+func (m *Metrics) IncExtractionFallbacks() {
+	atomic.AddUint64(&m.extractionFallbacks, 1)
+}
+
+// RecordFileCache 记录一次文件解析结果缓存查询
+func (m *Metrics) RecordFileCache(hit bool) {
+	if hit {
+		atomic.AddUint64(&m.fileCacheHits, 1)
+	} else {
+		atomic.AddUint64(&m.fileCacheMisses, 1)
+	}
+}
+
+// RecordJobCache 记录一次岗位查询缓存查询
+func (m *Metrics) RecordJobCache(hit bool) {
+	if hit {
+		atomic.AddUint64(&m.jobCacheHits, 1)
+	} else {
+		atomic.AddUint64(&m.jobCacheMisses, 1)
+	}
+}
+
+// IncJobCacheShared 增加与并发相同查询合并的次数
+func (m *Metrics) IncJobCacheShared() {
+	atomic.AddUint64(&m.jobCacheShared, 1)
+}
+
+// recordLatency 将一次耗时计入 key 对应的延迟统计
+func recordLatency(latency *sync.Map, key string, duration time.Duration) {
 	durationMs := uint64(duration.Milliseconds())
 
-	val, _ := m.requestLatency.LoadOrStore(endpoint, &LatencyStats{
+	val, _ := latency.LoadOrStore(key, &LatencyStats{
 		min: durationMs,
 		max: durationMs,
 	})
@@ -90,6 +145,21 @@ func (m *Metrics) RecordLatency(endpoint string, duration time.Duration) {
 	}
 }
 
+// RecordGrounding 记录一次岗位事实核验
+// unsupported 为无法核实的事实数量，action 为对不可信内容的处理方式（可为空）
+func (m *Metrics) RecordGrounding(unsupported int, action string) {
+	atomic.AddUint64(&m.groundingChecks, 1)
+	if unsupported == 0 {
+		return
+	}
+	atomic.AddUint64(&m.groundingUngrounded, 1)
+	atomic.AddUint64(&m.groundingUnsupported, uint64(unsupported))
+	if action != "" {
+		val, _ := m.groundingActions.LoadOrStore(action, new(uint64))
+		atomic.AddUint64(val.(*uint64), 1)
+	}
+}
+
 // GetStats 获取统计信息
 func (m *Metrics) GetStats() map[string]interface{} {
 	var memStats runtime.MemStats
@@ -103,28 +173,21 @@ func (m *Metrics) GetStats() map[string]interface{} {
 	uptime := time.Since(m.startTime)
 	qps := float64(totalReq) / uptime.Seconds()
 
-	latencyStats := make(map[string]interface{})
-	m.requestLatency.Range(func(key, value interface{}) bool {
-		endpoint := key.(string)
-		stats := value.(*LatencyStats)
-
-		stats.mu.RLock()
-		defer stats.mu.RUnlock()
-
-		avg := uint64(0)
-		if stats.count > 0 {
-			avg = stats.sum / stats.count
-		}
+	latencyStats := latencySnapshot(&m.requestLatency)
 
-		latencyStats[endpoint] = map[string]interface{}{
-			"count":  stats.count,
-			"avg_ms": avg,
-			"min_ms": stats.min,
-			"max_ms": stats.max,
-		}
+	groundingActions := make(map[string]uint64)
+	m.groundingActions.Range(func(key, value interface{}) bool {
+		groundingActions[key.(string)] = atomic.LoadUint64(value.(*uint64))
 		return true
 	})
 
+	jobCacheHits := atomic.LoadUint64(&m.jobCacheHits)
+	jobCacheMisses := atomic.LoadUint64(&m.jobCacheMisses)
+	jobCacheHitRatio := 0.0
+	if total := jobCacheHits + jobCacheMisses; total > 0 {
+		jobCacheHitRatio = float64(jobCacheHits) / float64(total)
+	}
+
 	return map[string]interface{}{
 		"requests": map[string]interface{}{
 			"total":   totalReq,
@@ -138,6 +201,24 @@ func (m *Metrics) GetStats() map[string]interface{} {
 			"uptime":  uptime.String(),
 			"latency": latencyStats,
 		},
+		"grounding": map[string]interface{}{
+			"checks":             atomic.LoadUint64(&m.groundingChecks),
+			"ungrounded":         atomic.LoadUint64(&m.groundingUngrounded),
+			"unsupported_claims": atomic.LoadUint64(&m.groundingUnsupported),
+			"actions":            groundingActions,
+		},
+		"extraction": map[string]interface{}{
+			"paths":         latencySnapshot(&m.extractionLatency),
+			"ocr_fallbacks": atomic.LoadUint64(&m.extractionFallbacks),
+			"cache_hits":    atomic.LoadUint64(&m.fileCacheHits),
+			"cache_misses":  atomic.LoadUint64(&m.fileCacheMisses),
+		},
+		"job_cache": map[string]interface{}{
+			"hits":      jobCacheHits,
+			"misses":    jobCacheMisses,
+			"hit_ratio": jobCacheHitRatio,
+			"shared":    atomic.LoadUint64(&m.jobCacheShared),
+		},
 		"system": map[string]interface{}{
 			"goroutines":      runtime.NumGoroutine(),
 			"cpu_cores":       runtime.NumCPU(),
@@ -158,5 +239,41 @@ func (m *Metrics) Reset() {
 	atomic.StoreUint64(&m.failedRequests, 0)
 	atomic.StoreUint64(&m.streamRequests, 0)
 	m.requestLatency = sync.Map{}
+	atomic.StoreUint64(&m.groundingChecks, 0)
+	atomic.StoreUint64(&m.groundingUngrounded, 0)
+	atomic.StoreUint64(&m.groundingUnsupported, 0)
+	m.groundingActions = sync.Map{}
+	m.extractionLatency = sync.Map{}
+	atomic.StoreUint64(&m.extractionFallbacks, 0)
+	atomic.StoreUint64(&m.fileCacheHits, 0)
+	atomic.StoreUint64(&m.fileCacheMisses, 0)
+	atomic.StoreUint64(&m.jobCacheHits, 0)
+	atomic.StoreUint64(&m.jobCacheMisses, 0)
+	atomic.StoreUint64(&m.jobCacheShared, 0)
 	m.startTime = time.Now()
 }
+
+// latencySnapshot 导出延迟统计
+func latencySnapshot(latency *sync.Map) map[string]interface{} {
+	snapshot := make(map[string]interface{})
+	latency.Range(func(key, value interface{}) bool {
+		stats := value.(*LatencyStats)
+
+		stats.mu.RLock()
+		defer stats.mu.RUnlock()
+
+		avg := uint64(0)
+		if stats.count > 0 {
+			avg = stats.sum / stats.count
+		}
+
+		snapshot[key.(string)] = map[string]interface{}{
+			"count":  stats.count,
+			"avg_ms": avg,
+			"min_ms": stats.min,
+			"max_ms": stats.max,
+		}
+		return true
+	})
+	return snapshot
+}